@@ -4,9 +4,12 @@ import (
 	"context"
 	"todo-list/configs"
 	"todo-list/internal/builder"
+	"todo-list/internal/worker"
+	"todo-list/migrations"
 	"todo-list/pkg/cache"
 	"todo-list/pkg/database"
 	"todo-list/pkg/server"
+	"todo-list/pkg/token"
 	"fmt"
 	"log"
 	"os"
@@ -20,13 +23,24 @@ func main() {
 
 	db, err := database.InitDatabase(cfg.PostgresConfig)
 	checkError(err)
+	checkError(database.Migrate(db, migrations.FS))
 
 	rdb := cache.InitCache(cfg.RedisConfig)
 
-	publicRoutes := builder.BuildPublicRoutes(cfg, db, rdb)
-	privateRoutes := builder.BuildPrivateRoutes(cfg, db, rdb)
+	keys, err := token.InitKeySet(cfg.JWT, cfg.ENV)
+	checkError(err)
+
+	publicRoutes := builder.BuildPublicRoutes(cfg, db, rdb, keys)
+	privateRoutes := builder.BuildPrivateRoutes(cfg, db, rdb, keys)
 
-	srv := server.NewServer(cfg, publicRoutes, privateRoutes)
+	workers, err := builder.BuildWorkers(cfg, db, rdb, keys)
+	checkError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runWorkers(ctx, workers)
+
+	srv := server.NewServer(cfg, cache.NewCacheable(rdb), keys, builder.BuildTokenAuthenticator(db), publicRoutes, privateRoutes)
 	runServer(srv, cfg.PORT)
 	waitForShutdown(srv)
 }
@@ -44,6 +58,12 @@ func runServer(srv *server.Server, port string) {
 	}()
 }
 
+func runWorkers(ctx context.Context, workers []*worker.Periodic) {
+	for _, w := range workers {
+		go w.Run(ctx)
+	}
+}
+
 func waitForShutdown(srv *server.Server) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
@@ -0,0 +1,84 @@
+// Command mockoidc menjalankan identity provider OIDC palsu untuk mencoba
+// login OIDC secara lokal. Setiap login langsung disetujui sebagai user
+// yang diatur lewat flag, tanpa halaman login.
+//
+//	go run ./cmd/mockoidc -groups todo-admins
+//
+// lalu isi .env dengan:
+//
+//	OIDC_ISSUER="http://127.0.0.1:9998/oidc"
+//	OIDC_CLIENT_ID="todo-list"
+//	OIDC_CLIENT_SECRET="todo-list-secret"
+//	OIDC_SCOPES="openid,profile,email,groups"
+//	OIDC_ROLE_CLAIM="groups"
+//	OIDC_ADMIN_VALUES="todo-admins"
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/oauth2-proxy/mockoidc"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9998", "listen address")
+	clientID := flag.String("client-id", "todo-list", "OAuth2 client ID")
+	clientSecret := flag.String("client-secret", "todo-list-secret", "OAuth2 client secret")
+	subject := flag.String("subject", "mock-user-1", "subject of the logged in user")
+	username := flag.String("username", "jane.doe", "preferred_username of the logged in user")
+	email := flag.String("email", "jane.doe@example.com", "email of the logged in user")
+	groups := flag.String("groups", "", "comma separated groups of the logged in user")
+	flag.Parse()
+
+	m, err := mockoidc.NewServer(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.ClientID = *clientID
+	m.ClientSecret = *clientSecret
+
+	user := &mockoidc.MockUser{
+		Subject:           *subject,
+		Email:             *email,
+		EmailVerified:     true,
+		PreferredUsername: *username,
+	}
+	if *groups != "" {
+		user.Groups = strings.Split(*groups, ",")
+	}
+	// user dimasukkan ke antrean sebelum setiap request authorize agar
+	// selalu login sebagai user yang sama
+	err = m.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == mockoidc.AuthorizationEndpoint {
+				m.QueueUser(user)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := m.Start(ln, nil); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("mock OIDC provider running, issuer %s, client ID %q, client secret %q", m.Issuer(), m.ClientID, m.ClientSecret)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+	<-quit
+	if err := m.Shutdown(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,17 +2,45 @@ package configs
 
 import (
 	"errors"
+	"net"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	ENV            string         `env:"ENV" envDefault:"dev"`
-	PORT           string         `env:"PORT" envDefault:"8080"`
-	PostgresConfig PostgresConfig `envPrefix:"POSTGRES_"`
-	JWT            JWTConfig      `envPrefix:"JWT_"`
-	RedisConfig    RedisConfig    `envPrefix:"REDIS_"`
+	ENV            string            `env:"ENV" envDefault:"dev"`
+	PORT           string            `env:"PORT" envDefault:"8080"`
+	BaseURL        string            `env:"BASE_URL" envDefault:"http://localhost:8080"`
+	TrustedProxies []string          `env:"TRUSTED_PROXIES"`
+	PostgresConfig PostgresConfig    `envPrefix:"POSTGRES_"`
+	JWT            JWTConfig         `envPrefix:"JWT_"`
+	RedisConfig    RedisConfig       `envPrefix:"REDIS_"`
+	Trash          TrashConfig       `envPrefix:"TRASH_"`
+	Archive        ArchiveConfig     `envPrefix:"ARCHIVE_"`
+	Rank           RankConfig        `envPrefix:"RANK_"`
+	Idempotency    IdempotencyConfig `envPrefix:"IDEMPOTENCY_"`
+	Webhook        WebhookConfig     `envPrefix:"WEBHOOK_"`
+	Outbox         OutboxConfig      `envPrefix:"OUTBOX_"`
+	Job            JobConfig         `envPrefix:"JOB_"`
+	Mail           MailConfig        `envPrefix:"MAIL_"`
+	SMTP           SMTPConfig        `envPrefix:"SMTP_"`
+	Reminder       ReminderConfig    `envPrefix:"REMINDER_"`
+	Digest         DigestConfig      `envPrefix:"DIGEST_"`
+	OIDC           OIDCConfig        `envPrefix:"OIDC_"`
+}
+
+type TrashConfig struct {
+	RetentionDays int           `env:"RETENTION_DAYS" envDefault:"30"`
+	PurgeInterval time.Duration `env:"PURGE_INTERVAL" envDefault:"1h"`
+}
+
+// ArchiveConfig mengatur auto-archive todo yang sudah selesai.
+// AfterDays 0 berarti auto-archive dimatikan.
+type ArchiveConfig struct {
+	AfterDays int           `env:"AFTER_DAYS" envDefault:"7"`
+	Interval  time.Duration `env:"INTERVAL" envDefault:"1h"`
 }
 
 type RedisConfig struct {
@@ -21,8 +49,19 @@ type RedisConfig struct {
 	Password string `env:"PASSWORD" envDefault:""`
 }
 
+// DefaultJWTSecret hanya boleh dipakai di ENV dev.
+const DefaultJWTSecret = "secret"
+
+// JWTConfig memilih cara token ditandatangani. HS256 memakai SecretKey.
+// RS256 dan EdDSA memakai private key PEM di SigningKey, kid diambil dari
+// JWK thumbprint. Untuk rotasi tanpa downtime, tambahkan key baru ke
+// VerificationKeys di semua replica, pindahkan SigningKey ke key baru, lalu
+// hapus key lama dari VerificationKeys setelah token lama kedaluwarsa.
 type JWTConfig struct {
-	SecretKey string `env:"SECRET_KEY" envDefault:"secret"`
+	SecretKey        string   `env:"SECRET_KEY" envDefault:"secret"`
+	Algorithm        string   `env:"ALGORITHM" envDefault:"HS256"`
+	SigningKey       string   `env:"SIGNING_KEY"`
+	VerificationKeys []string `env:"VERIFICATION_KEYS"`
 }
 
 type PostgresConfig struct {
@@ -33,6 +72,95 @@ type PostgresConfig struct {
 	Database string `env:"DATABASE" envDefault:"postgres"`
 }
 
+// RankConfig mengatur rebalancing position todo. Position milik user
+// di-rebalance ketika panjang key-nya melebihi MaxLength.
+type RankConfig struct {
+	MaxLength         int           `env:"MAX_LENGTH" envDefault:"24"`
+	RebalanceInterval time.Duration `env:"REBALANCE_INTERVAL" envDefault:"24h"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `env:"TTL" envDefault:"24h"`
+}
+
+// WebhookConfig mengatur pengiriman webhook. Delivery yang gagal dicoba
+// ulang dengan jeda BackoffBase * 2^(attempt-1) sampai MaxAttempts.
+type WebhookConfig struct {
+	DispatchInterval time.Duration `env:"DISPATCH_INTERVAL" envDefault:"5s"`
+	Timeout          time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	MaxAttempts      int           `env:"MAX_ATTEMPTS" envDefault:"8"`
+	BackoffBase      time.Duration `env:"BACKOFF_BASE" envDefault:"30s"`
+	// AllowPrivateNetworks mengizinkan webhook ke alamat internal seperti
+	// localhost, hanya untuk development.
+	AllowPrivateNetworks bool `env:"ALLOW_PRIVATE_NETWORKS" envDefault:"false"`
+}
+
+// OutboxConfig mengatur relay outbox. Message yang sudah terkirim dihapus
+// setelah Retention.
+type OutboxConfig struct {
+	RelayInterval time.Duration `env:"RELAY_INTERVAL" envDefault:"1s"`
+	Retention     time.Duration `env:"RETENTION" envDefault:"168h"`
+	PurgeInterval time.Duration `env:"PURGE_INTERVAL" envDefault:"1h"`
+}
+
+// JobConfig mengatur scheduler job. Job yang berjalan lebih lama dari Lease
+// dianggap gagal dan bisa diambil replica lain.
+type JobConfig struct {
+	PollInterval  time.Duration `env:"POLL_INTERVAL" envDefault:"5s"`
+	MaxAttempts   int           `env:"MAX_ATTEMPTS" envDefault:"5"`
+	BackoffBase   time.Duration `env:"BACKOFF_BASE" envDefault:"1m"`
+	Lease         time.Duration `env:"LEASE" envDefault:"10m"`
+	Retention     time.Duration `env:"RETENTION" envDefault:"720h"`
+	PurgeSchedule string        `env:"PURGE_SCHEDULE" envDefault:"0 3 * * *"`
+}
+
+// MailConfig memilih cara email dikirim: smtp, console (ditulis ke stdout)
+// atau file (disimpan sebagai .eml di Dir). Driver kosong, atau smtp tanpa
+// SMTP_HOST, berarti pengiriman email dimatikan.
+type MailConfig struct {
+	Driver string `env:"DRIVER" envDefault:"smtp"`
+	From   string `env:"FROM" envDefault:"todo-list <no-reply@localhost>"`
+	Dir    string `env:"DIR" envDefault:"tmp/mail"`
+}
+
+type SMTPConfig struct {
+	Host     string        `env:"HOST"`
+	Port     int           `env:"PORT" envDefault:"587"`
+	Username string        `env:"USERNAME"`
+	Password string        `env:"PASSWORD"`
+	Timeout  time.Duration `env:"TIMEOUT" envDefault:"10s"`
+}
+
+type ReminderConfig struct {
+	Interval time.Duration `env:"INTERVAL" envDefault:"1m"`
+}
+
+// DigestConfig mengatur seberapa sering jadwal digest diperiksa. Interval
+// 15 menit cukup untuk timezone dengan offset setengah jam.
+type DigestConfig struct {
+	Interval time.Duration `env:"INTERVAL" envDefault:"15m"`
+}
+
+// OIDCConfig mengatur login lewat identity provider OpenID Connect. Issuer
+// kosong berarti login OIDC dimatikan. RedirectURL kosong berarti
+// BASE_URL/api/v1/oidc/callback. Jika RoleClaim diisi, role user diatur
+// setiap login: admin jika klaim berisi salah satu AdminValues, selain itu
+// user. Identity baru hanya dihubungkan ke user dengan email terverifikasi
+// yang sama; LinkByUsername juga mencocokkan preferred_username dan hanya
+// aman untuk identity provider yang tidak mengizinkan user mengubahnya.
+type OIDCConfig struct {
+	Issuer         string        `env:"ISSUER"`
+	ClientID       string        `env:"CLIENT_ID"`
+	ClientSecret   string        `env:"CLIENT_SECRET"`
+	RedirectURL    string        `env:"REDIRECT_URL"`
+	Scopes         []string      `env:"SCOPES" envDefault:"openid,profile,email"`
+	RoleClaim      string        `env:"ROLE_CLAIM"`
+	AdminValues    []string      `env:"ADMIN_VALUES"`
+	AutoProvision  bool          `env:"AUTO_PROVISION" envDefault:"true"`
+	LinkByUsername bool          `env:"LINK_BY_USERNAME" envDefault:"false"`
+	StateTTL       time.Duration `env:"STATE_TTL" envDefault:"10m"`
+}
+
 func NewConfig(envPath string) (*Config, error) {
 	err := godotenv.Load(envPath)
 	if err != nil {
@@ -44,5 +172,21 @@ func NewConfig(envPath string) (*Config, error) {
 	if err != nil {
 		return nil, errors.New("failed to parse env")
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
+
+// Validate menolak konfigurasi yang tidak aman di luar ENV dev.
+func (cfg *Config) Validate() error {
+	if cfg.ENV != "dev" && cfg.JWT.Algorithm == "HS256" && (cfg.JWT.SecretKey == "" || cfg.JWT.SecretKey == DefaultJWTSecret) {
+		return errors.New("JWT_SECRET_KEY must be changed from the default outside the dev environment")
+	}
+	for _, cidr := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.New("TRUSTED_PROXIES must be a comma separated list of CIDR ranges")
+		}
+	}
+	return nil
+}
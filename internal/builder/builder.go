@@ -1,36 +1,265 @@
 package builder
 
 import (
+	"os"
+	"strings"
+	"time"
 	"todo-list/configs"
+	"todo-list/internal/event"
 	"todo-list/internal/http/handler"
 	"todo-list/internal/http/router"
 	"todo-list/internal/repository"
 	"todo-list/internal/service"
+	"todo-list/internal/worker"
 	"todo-list/pkg/cache"
+	"todo-list/pkg/mailer"
+	"todo-list/pkg/oidc"
 	"todo-list/pkg/route"
+	"todo-list/pkg/server"
 	"todo-list/pkg/token"
+	"todo-list/pkg/webhook"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func BuildPublicRoutes(cfg *configs.Config, db *gorm.DB, rdb *redis.Client) []route.Route {
+func BuildPublicRoutes(cfg *configs.Config, db *gorm.DB, rdb *redis.Client, keys *token.KeySet) []route.Route {
 	cacheable := cache.NewCacheable(rdb)
 	userRepository := repository.NewUserRepository(db)
-	tokenUseCase := token.NewTokenUseCase(cfg.JWT.SecretKey)
-	userService := service.NewUserService(userRepository, tokenUseCase, cacheable)
-	userHandler := handler.NewUserHandler(userService)
-	return router.PublicRoutes(userHandler)
+	tokenUseCase := token.NewTokenUseCase(keys)
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	publisher := service.NewOutboxPublisher(repository.NewOutboxRepository(db))
+	transactor := repository.NewTransactor(db)
+	mfaService := buildMFAService(db, userRepository, transactor, auditService)
+	userService := service.NewUserService(userRepository, tokenUseCase, cacheable, auditService, publisher, transactor, mfaService)
+	jobService := buildJobService(cfg, db)
+	accountService := buildAccountService(cfg, db, jobService, cacheable, auditService, publisher)
+	userHandler := handler.NewUserHandler(userService, accountService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	mfaHandler := handler.NewMFAHandler(mfaService, userService)
+	oidcHandler := handler.NewOIDCHandler(buildOIDCService(cfg, db, userRepository, userService, auditService, cacheable))
+	todoRepository := repository.NewTodoRepository(db)
+	todoEventRepository := repository.NewTodoEventRepository(db)
+	todoService := service.NewTodoService(todoRepository, todoEventRepository, transactor, tokenUseCase, cacheable, auditService, publisher)
+	feedService := service.NewFeedService(repository.NewFeedTokenRepository(db), transactor, strings.TrimSuffix(cfg.BaseURL, "/"))
+	feedHandler := handler.NewFeedHandler(feedService, todoService)
+	appPasswordService := service.NewAppPasswordService(repository.NewAppPasswordRepository(db), userRepository, auditService)
+	caldavHandler := handler.NewCalDAVHandler(todoService, appPasswordService)
+	digestHandler := handler.NewDigestHandler(buildDigestService(cfg, db, jobService))
+	return router.PublicRoutes(userHandler, feedHandler, caldavHandler, digestHandler, accountHandler, mfaHandler, oidcHandler)
 }
 
-func BuildPrivateRoutes(cfg *configs.Config, db *gorm.DB, rdb *redis.Client) []route.Route {
+func BuildPrivateRoutes(cfg *configs.Config, db *gorm.DB, rdb *redis.Client, keys *token.KeySet) []route.Route {
 	cacheable := cache.NewCacheable(rdb)
 	userRepository := repository.NewUserRepository(db)
-	tokenUseCase := token.NewTokenUseCase(cfg.JWT.SecretKey)
-	userService := service.NewUserService(userRepository, tokenUseCase, cacheable)
-	userHandler := handler.NewUserHandler(userService)
+	tokenUseCase := token.NewTokenUseCase(keys)
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	publisher := service.NewOutboxPublisher(repository.NewOutboxRepository(db))
+	transactor := repository.NewTransactor(db)
+	mfaService := buildMFAService(db, userRepository, transactor, auditService)
+	userService := service.NewUserService(userRepository, tokenUseCase, cacheable, auditService, publisher, transactor, mfaService)
+	jobService := buildJobService(cfg, db)
+	accountService := buildAccountService(cfg, db, jobService, cacheable, auditService, publisher)
+	userHandler := handler.NewUserHandler(userService, accountService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	mfaHandler := handler.NewMFAHandler(mfaService, userService)
 	todoRepository := repository.NewTodoRepository(db)
-	todoService := service.NewTodoService(todoRepository, tokenUseCase, cacheable)
+	todoEventRepository := repository.NewTodoEventRepository(db)
+	todoService := service.NewTodoService(todoRepository, todoEventRepository, transactor, tokenUseCase, cacheable, auditService, publisher)
 	todoHandler := handler.NewTodoHandler(todoService)
-	return router.PrivateRoutes(userHandler,*todoHandler)
+	auditHandler := handler.NewAuditHandler(auditService)
+	feedService := service.NewFeedService(repository.NewFeedTokenRepository(db), transactor, strings.TrimSuffix(cfg.BaseURL, "/"))
+	feedHandler := handler.NewFeedHandler(feedService, todoService)
+	appPasswordService := service.NewAppPasswordService(repository.NewAppPasswordRepository(db), userRepository, auditService)
+	appPasswordHandler := handler.NewAppPasswordHandler(appPasswordService)
+	tokenHandler := handler.NewPersonalAccessTokenHandler(service.NewPersonalAccessTokenService(repository.NewPersonalAccessTokenRepository(db), userRepository, auditService, publisher))
+	streamHandler := handler.NewStreamHandler(event.NewRedisBroker(rdb))
+	webhookHandler := handler.NewWebhookHandler(buildWebhookService(cfg, db))
+	jobHandler := handler.NewJobHandler(jobService)
+	notificationService := buildNotificationService(cfg, db, jobService)
+	reminderService := service.NewReminderService(repository.NewReminderRepository(db), todoRepository, userRepository, transactor, notificationService)
+	reminderHandler := handler.NewReminderHandler(reminderService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	digestHandler := handler.NewDigestHandler(buildDigestService(cfg, db, jobService))
+	return router.PrivateRoutes(userHandler,*todoHandler, auditHandler, feedHandler, appPasswordHandler, streamHandler, webhookHandler, jobHandler, reminderHandler, notificationHandler, digestHandler, accountHandler, mfaHandler, tokenHandler)
+}
+
+// BuildTokenAuthenticator dipakai JWTMiddleware untuk menerima personal
+// access token.
+func BuildTokenAuthenticator(db *gorm.DB) server.TokenAuthenticator {
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	publisher := service.NewOutboxPublisher(repository.NewOutboxRepository(db))
+	return service.NewPersonalAccessTokenService(repository.NewPersonalAccessTokenRepository(db), repository.NewUserRepository(db), auditService, publisher)
+}
+
+func BuildWorkers(cfg *configs.Config, db *gorm.DB, rdb *redis.Client, keys *token.KeySet) ([]*worker.Periodic, error) {
+	cacheable := cache.NewCacheable(rdb)
+	tokenUseCase := token.NewTokenUseCase(keys)
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	todoRepository := repository.NewTodoRepository(db)
+	todoEventRepository := repository.NewTodoEventRepository(db)
+	transactor := repository.NewTransactor(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	publisher := service.NewOutboxPublisher(outboxRepository)
+	todoService := service.NewTodoService(todoRepository, todoEventRepository, transactor, tokenUseCase, cacheable, auditService, publisher)
+
+	webhookService := buildWebhookService(cfg, db)
+	outboxRelay := service.NewOutboxRelay(outboxRepository, cacheable,
+		service.OutboxSink{Name: "realtime", Publisher: event.NewRedisBroker(rdb)},
+		service.OutboxSink{Name: "stream", Publisher: event.NewRedisStream(rdb)},
+		service.OutboxSink{Name: "webhook", Publisher: webhookService},
+		service.OutboxSink{Name: "cache", Publisher: service.NewCacheInvalidator(cacheable)},
+	)
+
+	// trash, rebalance dan archive dijalankan lewat scheduler agar hanya satu
+	// replica yang menjalankannya. Relay outbox dan dispatcher webhook sudah
+	// aman dijalankan di semua replica karena memakai row locking sendiri.
+	jobService := buildJobService(cfg, db)
+	scheduler := worker.NewScheduler(jobService)
+	retention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+	scheduler.Every("trash-purger", cfg.Trash.PurgeInterval, worker.TrashPurgeJob(todoService, retention))
+	scheduler.Every("rank-rebalancer", cfg.Rank.RebalanceInterval, worker.RebalanceJob(todoService, cfg.Rank.MaxLength))
+	if cfg.Archive.AfterDays > 0 {
+		after := time.Duration(cfg.Archive.AfterDays) * 24 * time.Hour
+		scheduler.Every("auto-archiver", cfg.Archive.Interval, worker.AutoArchiveJob(todoService, after))
+	}
+	notificationService := buildNotificationService(cfg, db, jobService)
+	reminderService := service.NewReminderService(repository.NewReminderRepository(db), todoRepository, repository.NewUserRepository(db), transactor, notificationService)
+	scheduler.Every("reminder-dispatcher", cfg.Reminder.Interval, worker.ReminderJob(reminderService))
+	scheduler.Every("digest-dispatcher", cfg.Digest.Interval, worker.DigestJob(buildDigestService(cfg, db, jobService)))
+	if m := buildMailer(cfg); m != nil {
+		scheduler.Handle(service.JobSendEmail, worker.SendEmailJob(m))
+		accountService := buildAccountService(cfg, db, jobService, cacheable, auditService, publisher)
+		scheduler.Handle(service.JobSendAccountEmail, worker.AccountEmailJob(accountService))
+	}
+	if err := scheduler.Cron("job-purger", cfg.Job.PurgeSchedule, worker.JobPurgeJob(jobService, cfg.Job.Retention)); err != nil {
+		return nil, err
+	}
+
+	return []*worker.Periodic{
+		scheduler.Worker(cfg.Job.PollInterval),
+		worker.NewWebhookDispatcher(webhookService, cfg.Webhook.DispatchInterval),
+		worker.NewOutboxRelay(outboxRelay, cfg.Outbox.RelayInterval),
+		worker.NewOutboxPurger(outboxRelay, cfg.Outbox.Retention, cfg.Outbox.PurgeInterval),
+	}, nil
+}
+
+func buildJobService(cfg *configs.Config, db *gorm.DB) service.JobService {
+	return service.NewJobService(
+		repository.NewJobRepository(db),
+		cfg.Job.MaxAttempts,
+		cfg.Job.BackoffBase,
+		cfg.Job.Lease,
+	)
+}
+
+// buildNotificationService hanya mengaktifkan channel email jika pengiriman
+// email dikonfigurasi.
+func buildNotificationService(cfg *configs.Config, db *gorm.DB, jobService service.JobService) service.NotificationService {
+	notificationRepository := repository.NewNotificationRepository(db)
+	channels := []service.NotificationChannel{
+		service.NewInAppChannel(notificationRepository),
+		service.NewWebhookChannel(buildWebhookService(cfg, db)),
+	}
+	if buildMailer(cfg) != nil {
+		channels = append(channels, service.NewEmailChannel(jobService))
+	}
+	return service.NewNotificationService(notificationRepository, channels...)
+}
+
+func buildDigestService(cfg *configs.Config, db *gorm.DB, jobService service.JobService) service.DigestService {
+	return service.NewDigestService(
+		repository.NewDigestRepository(db),
+		repository.NewTodoRepository(db),
+		repository.NewUserRepository(db),
+		jobService,
+		repository.NewTransactor(db),
+		strings.TrimSuffix(cfg.BaseURL, "/"),
+		buildMailer(cfg) != nil,
+	)
+}
+
+func buildMFAService(db *gorm.DB, userRepository repository.UserRepository, transactor repository.Transactor, auditService service.AuditService) service.MFAService {
+	return service.NewMFAService(
+		repository.NewMFARepository(db),
+		repository.NewSettingRepository(db),
+		userRepository,
+		transactor,
+		auditService,
+		"todo-list",
+	)
+}
+
+// buildOIDCService mematikan login OIDC jika OIDC_ISSUER kosong.
+func buildOIDCService(cfg *configs.Config, db *gorm.DB, userRepository repository.UserRepository, userService service.UserService, auditService service.AuditService, cacheable cache.Cacheable) service.OIDCService {
+	var provider *oidc.Provider
+	if cfg.OIDC.Issuer != "" {
+		redirectURL := cfg.OIDC.RedirectURL
+		if redirectURL == "" {
+			redirectURL = strings.TrimSuffix(cfg.BaseURL, "/") + "/api/v1/oidc/callback"
+		}
+		provider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		})
+	}
+	return service.NewOIDCService(
+		provider,
+		repository.NewUserIdentityRepository(db),
+		userRepository,
+		userService,
+		auditService,
+		cacheable,
+		cfg.OIDC.RoleClaim,
+		cfg.OIDC.AdminValues,
+		cfg.OIDC.AutoProvision,
+		cfg.OIDC.LinkByUsername,
+		cfg.OIDC.StateTTL,
+	)
+}
+
+func buildAccountService(cfg *configs.Config, db *gorm.DB, jobService service.JobService, cacheable cache.Cacheable, auditService service.AuditService, publisher event.Publisher) service.AccountService {
+	return service.NewAccountService(
+		repository.NewUserRepository(db),
+		repository.NewUserTokenRepository(db),
+		repository.NewPersonalAccessTokenRepository(db),
+		repository.NewAppPasswordRepository(db),
+		repository.NewFeedTokenRepository(db),
+		jobService,
+		repository.NewTransactor(db),
+		cacheable,
+		auditService,
+		publisher,
+		buildMailer(cfg),
+		strings.TrimSuffix(cfg.BaseURL, "/"),
+	)
+}
+
+// buildMailer mengembalikan nil jika pengiriman email dimatikan.
+func buildMailer(cfg *configs.Config) mailer.Mailer {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return nil
+		}
+		return mailer.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.Mail.From, cfg.SMTP.Timeout)
+	case "console":
+		return mailer.NewConsoleMailer(os.Stdout, cfg.Mail.From)
+	case "file":
+		return mailer.NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	}
+	return nil
+}
+
+func buildWebhookService(cfg *configs.Config, db *gorm.DB) service.WebhookService {
+	return service.NewWebhookService(
+		repository.NewWebhookRepository(db),
+		repository.NewWebhookDeliveryRepository(db),
+		webhook.NewSender(cfg.Webhook.Timeout, cfg.Webhook.AllowPrivateNetworks),
+		cfg.Webhook.MaxAttempts,
+		cfg.Webhook.BackoffBase,
+	)
 }
@@ -0,0 +1,80 @@
+// Package digest merender email ringkasan todo harian dan mingguan.
+package digest
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/pkg/mailer"
+
+	// timezone user dimuat dari tzdata yang di-embed agar tidak bergantung
+	// pada sistem operasi
+	_ "time/tzdata"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var funcs = map[string]interface{}{
+	"date": func(t *time.Time, loc *time.Location) string {
+		if t == nil {
+			return ""
+		}
+		return t.In(loc).Format("Mon, 02 Jan 15:04")
+	},
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).ParseFS(templateFS, "templates/digest.html"))
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt").Funcs(funcs).ParseFS(templateFS, "templates/digest.txt"))
+)
+
+// Digest berisi todo yang masuk ringkasan. Waktu ditampilkan dalam
+// Location milik user.
+type Digest struct {
+	Name           string
+	Frequency      string
+	Date           time.Time
+	Location       *time.Location
+	Overdue        []entity.Todo
+	DueToday       []entity.Todo
+	Completed      []entity.Todo
+	UnsubscribeURL string
+}
+
+// Empty bernilai true jika tidak ada yang perlu dilaporkan.
+func (d *Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.DueToday) == 0 && len(d.Completed) == 0
+}
+
+func (d *Digest) Subject() string {
+	return "Your " + d.Frequency + " todo digest for " + d.Date.In(d.Location).Format("Mon, 02 Jan 2006")
+}
+
+// Render menghasilkan email versi plain text dan HTML. To belum diisi.
+func Render(d *Digest) (*mailer.Message, error) {
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return nil, err
+	}
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return nil, err
+	}
+	msg := &mailer.Message{
+		Subject: d.Subject(),
+		Body:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}
+	if d.UnsubscribeURL != "" {
+		// RFC 8058, client email bisa unsubscribe dengan satu klik
+		msg.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + d.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+	return msg, nil
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// AppPassword adalah password khusus per aplikasi (misalnya client CalDAV)
+// yang bisa dicabut tanpa mengganti password akun. Yang disimpan hanya
+// hash SHA-256-nya.
+type AppPassword struct {
+	ID           uint       `json:"id"`
+	UserID       uint       `json:"user_id" gorm:"index"`
+	Name         string     `json:"name" gorm:"size:100"`
+	PasswordHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+}
+
+func (AppPassword) TableName() string {
+	return "public.app_passwords"
+}
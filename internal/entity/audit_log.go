@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuditLoginSucceeded              = "auth.login_succeeded"
+	AuditLoginFailed                 = "auth.login_failed"
+	AuditUserRegistered              = "user.registered"
+	AuditRoleChanged                 = "user.role_changed"
+	AuditAppPasswordCreated          = "user.app_password_created"
+	AuditAppPasswordRevoked          = "user.app_password_revoked"
+	AuditEmailChanged                = "user.email_changed"
+	AuditEmailVerified               = "user.email_verified"
+	AuditPasswordResetRequested      = "user.password_reset_requested"
+	AuditPasswordReset               = "user.password_reset"
+	AuditMFAEnabled                  = "user.mfa_enabled"
+	AuditMFADisabled                 = "user.mfa_disabled"
+	AuditMFARecoveryCodeUsed         = "user.mfa_recovery_code_used"
+	AuditMFARecoveryCodesRegenerated = "user.mfa_recovery_codes_regenerated"
+	AuditMFAPolicyChanged            = "admin.mfa_policy_changed"
+	AuditAccessTokenCreated          = "user.access_token_created"
+	AuditAccessTokenRevoked          = "user.access_token_revoked"
+	AuditIdentityLinked              = "user.identity_linked"
+	AuditAdminTodo                   = "admin.todo_"
+)
+
+// AuditLog adalah catatan append-only untuk aksi yang relevan secara
+// keamanan. Setiap baris menyimpan hash baris sebelumnya (hash chain)
+// sehingga penghapusan atau perubahan baris bisa dideteksi.
+type AuditLog struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Action     string    `json:"action" gorm:"index"`
+	ActorID    *uint     `json:"actor_id" gorm:"index"`
+	ActorRole  string    `json:"actor_role"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id"`
+	Metadata   RawJSON   `json:"metadata" gorm:"type:text"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash" gorm:"uniqueIndex"`
+}
+
+func (AuditLog) TableName() string {
+	return "public.audit_logs"
+}
+
+// ComputeHash menghitung hash baris ini berdasarkan PrevHash dan isi baris.
+func (l *AuditLog) ComputeHash() string {
+	actorID := ""
+	if l.ActorID != nil {
+		actorID = strconv.FormatUint(uint64(*l.ActorID), 10)
+	}
+	fields := []string{
+		l.PrevHash,
+		l.CreatedAt.UTC().Format(time.RFC3339Nano),
+		l.Action,
+		actorID,
+		l.ActorRole,
+		l.TargetType,
+		l.TargetID,
+		l.IP,
+		l.UserAgent,
+		l.RequestID,
+		string(l.Metadata),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// RawJSON adalah JSON yang disimpan apa adanya sebagai text sehingga
+// byte-nya tidak berubah saat dibaca kembali.
+type RawJSON string
+
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if r == "" {
+		return []byte("null"), nil
+	}
+	return []byte(r), nil
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSubscription adalah opt-in user untuk email ringkasan. Hour dan
+// Weekday (0 = Minggu, hanya untuk weekly) dihitung dalam Timezone user.
+// Token unsubscribe hanya disimpan hash-nya dan diganti setiap kali digest
+// dikirim.
+type DigestSubscription struct {
+	ID                   uint       `json:"id"`
+	UserID               uint       `json:"user_id" gorm:"uniqueIndex"`
+	Frequency            string     `json:"frequency" gorm:"size:10"`
+	Timezone             string     `json:"timezone" gorm:"size:64"`
+	Hour                 int        `json:"hour"`
+	Weekday              int        `json:"weekday"`
+	UnsubscribeTokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	NextSendAt           time.Time  `json:"next_send_at" gorm:"index"`
+	LastSentAt           *time.Time `json:"last_sent_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+func (DigestSubscription) TableName() string {
+	return "public.digest_subscriptions"
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// FeedToken memberi akses baca ke feed kalender tanpa JWT. Yang disimpan
+// hanya hash SHA-256 dari token.
+type FeedToken struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+func (FeedToken) TableName() string {
+	return "public.feed_tokens"
+}
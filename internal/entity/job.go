@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job adalah satu eksekusi job, baik dari jadwal cron maupun job tertunda
+// yang di-enqueue. UniqueKey mencegah jadwal yang sama di-enqueue dua kali
+// oleh replica yang berbeda.
+type Job struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name" gorm:"size:100;index"`
+	UniqueKey   *string    `json:"unique_key,omitempty" gorm:"size:200;uniqueIndex"`
+	Payload     RawJSON    `json:"payload" gorm:"type:text"`
+	Status      string     `json:"status" gorm:"size:20;index"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	LastError   string     `json:"last_error" gorm:"size:1000"`
+	RunAt       time.Time  `json:"run_at" gorm:"index"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "public.jobs"
+}
@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// UserTOTP adalah secret TOTP milik user. Secret disimpan apa adanya karena
+// dibutuhkan untuk menghitung kode. 2FA baru aktif setelah ConfirmedAt
+// diisi. LastUsedStep mencegah kode yang sama dipakai dua kali.
+type UserTOTP struct {
+	UserID       uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Secret       string     `json:"-" gorm:"size:64"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (UserTOTP) TableName() string {
+	return "public.user_totps"
+}
+
+// RecoveryCode adalah kode cadangan sekali pakai jika authenticator hilang.
+// Yang disimpan hanya hash SHA-256-nya.
+type RecoveryCode struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	CodeHash  string     `json:"-" gorm:"size:64;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "public.user_recovery_codes"
+}
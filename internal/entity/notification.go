@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+const (
+	NotificationChannelInApp   = "in_app"
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook"
+
+	NotificationTodoReminder = "todo.reminder"
+)
+
+// Notification adalah notifikasi in-app milik user. ReadAt nil berarti
+// belum dibaca.
+type Notification struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	TodoID    *uint      `json:"todo_id"`
+	Type      string     `json:"type" gorm:"size:50"`
+	Title     string     `json:"title" gorm:"size:300"`
+	Body      string     `json:"body" gorm:"type:text"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "public.notifications"
+}
+
+// Reminder dikirim pada RemindAt, atau MinutesBefore menit sebelum due_at
+// todo. Reminder relatif ikut bergeser jika due_at todo diubah sebelum
+// reminder terkirim.
+type Reminder struct {
+	ID            uint       `json:"id"`
+	TodoID        uint       `json:"todo_id" gorm:"index"`
+	UserID        uint       `json:"user_id" gorm:"index"`
+	RemindAt      *time.Time `json:"remind_at"`
+	MinutesBefore *int       `json:"minutes_before"`
+	Channels      StringList `json:"channels" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (Reminder) TableName() string {
+	return "public.todo_reminders"
+}
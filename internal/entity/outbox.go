@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// OutboxMessage adalah domain event yang ditulis pada transaksi yang sama
+// dengan perubahan datanya, lalu dikirim oleh relay. PublishedAt nil berarti
+// belum terkirim ke semua tujuan.
+type OutboxMessage struct {
+	ID          uint       `json:"id"`
+	EventType   string     `json:"event_type" gorm:"size:50"`
+	Payload     RawJSON    `json:"payload" gorm:"type:text"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error" gorm:"size:1000"`
+	AvailableAt time.Time  `json:"available_at" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PublishedAt *time.Time `json:"published_at" gorm:"index"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "public.outbox_messages"
+}
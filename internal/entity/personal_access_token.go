@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Scope yang bisa diberikan ke personal access token.
+const (
+	ScopeTodosRead          = "todos:read"
+	ScopeTodosWrite         = "todos:write"
+	ScopeNotificationsRead  = "notifications:read"
+	ScopeNotificationsWrite = "notifications:write"
+	ScopeWebhooksRead       = "webhooks:read"
+	ScopeWebhooksWrite      = "webhooks:write"
+	ScopeAdminRead          = "admin:read"
+	ScopeAdminWrite         = "admin:write"
+)
+
+// PersonalAccessToken adalah token untuk script dan CI yang diterima
+// di header Authorization seperti JWT. Yang disimpan hanya hash SHA-256-nya,
+// Hint berisi beberapa karakter awal token agar user bisa mengenalinya.
+type PersonalAccessToken struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name" gorm:"size:100"`
+	Hint       string     `json:"hint" gorm:"size:20"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Scopes     StringList `json:"scopes" gorm:"type:text"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:45"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "public.personal_access_tokens"
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Setting adalah nilai key-value aplikasi yang bisa berubah saat runtime.
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey;size:100"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Setting) TableName() string {
+	return "public.settings"
+}
+
+const (
+	// SettingRequireAdminMFA bernilai "true" jika user dengan role admin
+	// wajib memakai 2FA.
+	SettingRequireAdminMFA = "security.require_admin_mfa"
+	// SettingAuditHead berisi jumlah audit log dan hash terakhirnya dengan
+	// format "<jumlah>:<hash>", dipakai verify untuk mendeteksi baris
+	// terbaru yang dihapus.
+	SettingAuditHead = "audit.head"
+)
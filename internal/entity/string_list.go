@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+)
+
+// StringList disimpan sebagai text yang dipisahkan koma.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+// Equal membandingkan isi dan urutan dua list. nil sama dengan list kosong.
+func (l StringList) Equal(other StringList) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for i := range l {
+		if l[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return errors.New("unsupported type for StringList")
+	}
+	*l = nil
+	if s != "" {
+		*l = strings.Split(s, ",")
+	}
+	return nil
+}
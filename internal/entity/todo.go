@@ -1,11 +1,172 @@
 package entity
 
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"todo-list/pkg/markdown"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MaxTitleLength       = 255
+	MaxDescriptionLength = 10000
+	MaxProjectLength     = 100
+	MaxTagLength         = 50
+)
+
+// ValidPriority mengecek priority gaya todo.txt: kosong atau satu huruf A-Z.
+func ValidPriority(priority string) bool {
+	return priority == "" || (len(priority) == 1 && priority[0] >= 'A' && priority[0] <= 'Z')
+}
+
+// ValidProject mengecek nama project gaya todo.txt (+project): kosong atau
+// satu kata tanpa spasi.
+func ValidProject(project string) bool {
+	return utf8.RuneCountInString(project) <= MaxProjectLength && !strings.ContainsFunc(project, unicode.IsSpace)
+}
+
+// ValidTag mengecek tag gaya todo.txt (@context). Koma tidak diperbolehkan
+// karena tags disimpan sebagai text yang dipisahkan koma.
+func ValidTag(tag string) bool {
+	return tag != "" && utf8.RuneCountInString(tag) <= MaxTagLength &&
+		!strings.ContainsFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == ',' })
+}
+
+// HasTag mengecek apakah todo sudah memiliki tag tersebut.
+func (t *Todo) HasTag(tag string) bool {
+	for _, existing := range t.Tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
 type Todo struct {
-	ID     uint   `json:"id"`
-	UserID uint   `json:"user_id"`
-	Title  string `json:"title"`
-	Done   bool   `json:"done"`
+	ID              uint   `json:"id"`
+	UserID          uint   `json:"user_id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	DescriptionHTML string `json:"description_html" gorm:"-"`
+	Done            bool   `json:"done"`
+	Priority        string `json:"priority" gorm:"size:1"`
+	DueAt           *time.Time `json:"due_at"`
+	// DueAllDay berarti DueAt hanya tanggal (disimpan tengah malam UTC)
+	// tanpa jam, misalnya DUE;VALUE=DATE di iCalendar
+	DueAllDay       bool       `json:"due_all_day" gorm:"not null;default:false"`
+	Project         string     `json:"project" gorm:"size:100;index"`
+	Tags            StringList `json:"tags" gorm:"type:text"`
+	Position        string `json:"position" gorm:"index"`
+	Version         uint   `json:"version" gorm:"not null;default:1"`
+	CreatedAt       time.Time      `json:"created_at"`
+	CompletedAt     *time.Time     `json:"completed_at"`
+	ArchivedAt      *time.Time     `json:"archived_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at"`
+	// diisi jika todo dibuat lewat CalDAV, agar UID dan nama resource
+	// pilihan client tetap sama
+	ICalUID    string `json:"-" gorm:"column:ical_uid;size:255"`
+	CalDAVName string `json:"-" gorm:"column:caldav_name;size:255;index"`
 }
 func (Todo) TableName() string {
 	return "public.todos"
-}
\ No newline at end of file
+}
+
+// ResourceName adalah nama calendar object todo di collection CalDAV.
+func (t *Todo) ResourceName() string {
+	if t.CalDAVName != "" {
+		return t.CalDAVName
+	}
+	return strconv.FormatUint(uint64(t.ID), 10) + ".ics"
+}
+
+func (t *Todo) AfterFind(tx *gorm.DB) error {
+	return t.RenderDescription()
+}
+
+func (t *Todo) AfterSave(tx *gorm.DB) error {
+	return t.RenderDescription()
+}
+
+// RenderDescription mengisi DescriptionHTML dari Description (markdown).
+func (t *Todo) RenderDescription() (err error) {
+	t.DescriptionHTML, err = markdown.Render(t.Description)
+	return err
+}
+
+// TodoPatch berisi field todo yang bisa diubah lewat PATCH. Field nil
+// berarti tidak diubah.
+type TodoPatch struct {
+	Title       *string     `json:"title"`
+	Description *string     `json:"description"`
+	Done        *bool       `json:"done"`
+	Priority    *string     `json:"priority"`
+	DueAt       *time.Time  `json:"due_at"`
+	DueAllDay   *bool       `json:"due_all_day"`
+	Project     *string     `json:"project"`
+	Tags        *StringList `json:"tags"`
+}
+
+// NewTodoPatch membuat dokumen patch lengkap dari kondisi todo saat ini.
+// Tags selalu berupa array agar operasi JSON Patch seperti add /tags/-
+// bisa dipakai.
+func NewTodoPatch(t *Todo) TodoPatch {
+	tags := append(StringList{}, t.Tags...)
+	return TodoPatch{
+		Title:       &t.Title,
+		Description: &t.Description,
+		Done:        &t.Done,
+		Priority:    &t.Priority,
+		DueAt:       t.DueAt,
+		DueAllDay:   &t.DueAllDay,
+		Project:     &t.Project,
+		Tags:        &tags,
+	}
+}
+
+// Changes mengembalikan patch yang hanya berisi field yang berbeda dari t.
+// Field yang dihapus dari dokumen (nil) dianggap bernilai kosong.
+func (p TodoPatch) Changes(t *Todo) TodoPatch {
+	var changes TodoPatch
+	if v := valueOf(p.Title); v != t.Title {
+		changes.Title = &v
+	}
+	if v := valueOf(p.Description); v != t.Description {
+		changes.Description = &v
+	}
+	if v := valueOf(p.Done); v != t.Done {
+		changes.Done = &v
+	}
+	if v := valueOf(p.Priority); v != t.Priority {
+		changes.Priority = &v
+	}
+	if v := valueOf(p.DueAllDay); v != t.DueAllDay {
+		changes.DueAllDay = &v
+	}
+	if v := valueOf(p.Project); v != t.Project {
+		changes.Project = &v
+	}
+	if v := valueOf(p.Tags); !v.Equal(t.Tags) {
+		changes.Tags = &v
+	}
+	// due_at yang dihapus tidak bisa dibedakan dari nil, sehingga dikirim
+	// sebagai zero time untuk menandai pengosongan.
+	switch {
+	case p.DueAt == nil && t.DueAt != nil:
+		changes.DueAt = &time.Time{}
+	case p.DueAt != nil && (t.DueAt == nil || !p.DueAt.Equal(*t.DueAt)):
+		changes.DueAt = p.DueAt
+	}
+	return changes
+}
+
+func valueOf[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	TodoEventCreated    = "created"
+	TodoEventUpdated    = "updated"
+	TodoEventDeleted    = "deleted"
+	TodoEventRestored   = "restored"
+	TodoEventReverted   = "reverted"
+	TodoEventArchived   = "archived"
+	TodoEventUnarchived = "unarchived"
+	TodoEventMoved      = "moved"
+	TodoEventPurged     = "purged"
+)
+
+// TodoEvent adalah catatan append-only atas perubahan sebuah todo.
+type TodoEvent struct {
+	ID        uint         `json:"id"`
+	TodoID    uint         `json:"todo_id" gorm:"index"`
+	UserID    uint         `json:"user_id"`
+	ActorID   uint         `json:"actor_id"`
+	AsAdmin   bool         `json:"as_admin"`
+	Action    string       `json:"action"`
+	Changes   FieldChanges `json:"changes" gorm:"type:jsonb"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (TodoEvent) TableName() string {
+	return "public.todo_events"
+}
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// FieldChanges berisi diff per field, disimpan sebagai jsonb.
+type FieldChanges map[string]FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (c *FieldChanges) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return errors.New("unsupported type for FieldChanges")
+	}
+	return json.Unmarshal(b, c)
+}
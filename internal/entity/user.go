@@ -1,11 +1,16 @@
 package entity
 
+import "time"
+
 type User struct {
-	ID       int64  `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"-"`
-	Role     string `json:"role"`
-	FullName string `json:"full_name"`
+	ID              int64      `json:"id"`
+	Username        string     `json:"username"`
+	Password        string     `json:"-"`
+	Role            string     `json:"role"`
+	FullName        string     `json:"full_name"`
+	Email           string     `json:"email" gorm:"size:255"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Version         uint       `json:"version" gorm:"not null;default:1"`
 }
 
 func (User) TableName() string {
@@ -18,9 +23,9 @@ type UserReg struct {
 	Password string `json:"password"`
 	Role     string `json:"role"`
 	FullName string `json:"full_name"`
+	Email    string `json:"email"`
 }
 
 func (UserReg) TableName() string {
 	return "public.users"
 }
-
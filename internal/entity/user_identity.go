@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// UserIdentity menghubungkan user dengan akun di identity provider OIDC,
+// diidentifikasi dengan pasangan issuer dan subject.
+type UserIdentity struct {
+	ID          uint       `json:"id"`
+	UserID      uint       `json:"user_id" gorm:"index"`
+	Issuer      string     `json:"issuer" gorm:"size:255;uniqueIndex:idx_user_identities_subject"`
+	Subject     string     `json:"subject" gorm:"size:255;uniqueIndex:idx_user_identities_subject"`
+	Email       string     `json:"email" gorm:"size:255"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "public.user_identities"
+}
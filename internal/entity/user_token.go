@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+const (
+	UserTokenEmailVerification = "email_verification"
+	UserTokenPasswordReset     = "password_reset"
+)
+
+// UserToken adalah token sekali pakai yang dikirim lewat email. Yang
+// disimpan hanya hash-nya. Email menyimpan alamat yang diverifikasi agar
+// token tidak berlaku lagi jika user mengganti email.
+type UserToken struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	Purpose   string     `json:"purpose" gorm:"size:30"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Email     string     `json:"email" gorm:"size:255"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (UserToken) TableName() string {
+	return "public.user_tokens"
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook adalah endpoint yang menerima event. Webhook milik user hanya
+// menerima event todo miliknya, webhook Global (dibuat admin) menerima
+// event semua user.
+type Webhook struct {
+	ID        uint           `json:"id"`
+	UserID    uint           `json:"user_id" gorm:"index"`
+	Global    bool           `json:"global"`
+	URL       string         `json:"url" gorm:"size:2048"`
+	Secret    string         `json:"-" gorm:"size:64"`
+	Events    StringList     `json:"events" gorm:"type:text"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `json:"-"`
+}
+
+func (Webhook) TableName() string {
+	return "public.webhooks"
+}
+
+// Subscribed mengecek apakah webhook berlangganan tipe event tersebut.
+func (w *Webhook) Subscribed(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery adalah satu pengiriman event ke sebuah webhook beserta
+// hasil percobaan terakhirnya.
+type WebhookDelivery struct {
+	ID             uint       `json:"id"`
+	WebhookID      uint       `json:"webhook_id" gorm:"index"`
+	EventID        string     `json:"event_id" gorm:"size:100"`
+	EventType      string     `json:"event_type" gorm:"size:50"`
+	Payload        RawJSON    `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"size:20;index"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error" gorm:"size:1000"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "public.webhook_deliveries"
+}
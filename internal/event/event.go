@@ -0,0 +1,100 @@
+// Package event berisi domain event (perubahan todo dan user) yang dikirim
+// ke client real-time dan webhook.
+package event
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo-list/internal/entity"
+)
+
+const (
+	TypeTodoCreated    = "todo.created"
+	TypeTodoUpdated    = "todo.updated"
+	TypeTodoDeleted    = "todo.deleted"
+	TypeTodoRestored   = "todo.restored"
+	TypeTodoReverted   = "todo.reverted"
+	TypeTodoArchived   = "todo.archived"
+	TypeTodoUnarchived = "todo.unarchived"
+	TypeTodoMoved      = "todo.moved"
+	TypeTodoPurged     = "todo.purged"
+	// TypeTodoCompleted tidak pernah di-publish, diturunkan dari
+	// todo.updated yang mengubah done menjadi true.
+	TypeTodoCompleted   = "todo.completed"
+	TypeTodoReminder    = "todo.reminder"
+	TypeUserRegistered  = "user.registered"
+	TypeUserRoleChanged = "user.role_changed"
+	// TypeUserTokensRevoked hanya dipakai untuk menutup stream real-time
+	// milik user, tidak dikirim ke webhook.
+	TypeUserTokensRevoked = "user.tokens_revoked"
+)
+
+// Event adalah perubahan sebuah todo atau user. Untuk todo, ID sama dengan
+// ID todo_events sehingga bisa dipakai client untuk mengabaikan event
+// duplikat.
+type Event struct {
+	ID           uint                 `json:"id"`
+	Type         string               `json:"type"`
+	UserID       uint                 `json:"user_id"`
+	TodoID       uint                 `json:"todo_id,omitempty"`
+	ActorID      uint                 `json:"actor_id"`
+	Todo         *entity.Todo         `json:"todo,omitempty"`
+	Changes      entity.FieldChanges  `json:"changes,omitempty"`
+	User         *entity.User         `json:"user,omitempty"`
+	Notification *entity.Notification `json:"notification,omitempty"`
+	OccurredAt   time.Time            `json:"occurred_at"`
+}
+
+// Completed mengecek apakah event menandai todo sebagai selesai.
+func (e Event) Completed() bool {
+	change, ok := e.Changes["done"]
+	return e.Type == TypeTodoUpdated && ok && change.New == true
+}
+
+// EndsSession mengecek apakah event membuat token user yang sedang dipakai
+// tidak lagi berlaku (role berubah atau token dicabut).
+func (e Event) EndsSession() bool {
+	return e.Type == TypeUserRoleChanged || e.Type == TypeUserTokensRevoked
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Subscriber mengirim event milik userID ke channel sampai ctx dibatalkan.
+type Subscriber interface {
+	Subscribe(ctx context.Context, userID uint) <-chan Event
+}
+
+// FromTodoEvent membuat Event dari catatan todo_events.
+func FromTodoEvent(e *entity.TodoEvent, todo entity.Todo) Event {
+	return Event{
+		ID:         e.ID,
+		Type:       "todo." + e.Action,
+		UserID:     e.UserID,
+		TodoID:     e.TodoID,
+		ActorID:    e.ActorID,
+		Todo:       &todo,
+		Changes:    e.Changes,
+		OccurredAt: e.CreatedAt,
+	}
+}
+
+type multiPublisher []Publisher
+
+// Multi meneruskan event ke semua publisher. Kegagalan satu publisher tidak
+// menghentikan publisher lain.
+func Multi(publishers ...Publisher) Publisher {
+	return multiPublisher(publishers)
+}
+
+func (m multiPublisher) Publish(ctx context.Context, e Event) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
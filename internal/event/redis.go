@@ -0,0 +1,118 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisChannel     = "todo-list:todo-events"
+	subscriberBuffer = 32
+)
+
+// RedisBroker menyebarkan event ke semua replica lewat Redis pub/sub. Setiap
+// replica hanya membuka satu subscription, lalu membagikan event ke client
+// yang terhubung ke replica tersebut.
+type RedisBroker struct {
+	rdb  *redis.Client
+	once sync.Once
+
+	mu          sync.Mutex
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+func NewRedisBroker(rdb *redis.Client) *RedisBroker {
+	return &RedisBroker{rdb: rdb, subscribers: map[uint]map[chan Event]struct{}{}}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return b.rdb.Publish(ctx, redisChannel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, userID uint) <-chan Event {
+	b.once.Do(func() { go b.listen() })
+
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = map[chan Event]struct{}{}
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers[userID], ch)
+		if len(b.subscribers[userID]) == 0 {
+			delete(b.subscribers, userID)
+		}
+		b.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+// listen berjalan selama proses hidup. go-redis otomatis menyambung ulang
+// subscription jika koneksi ke Redis terputus.
+func (b *RedisBroker) listen() {
+	pubsub := b.rdb.Subscribe(context.Background(), redisChannel)
+	for msg := range pubsub.Channel() {
+		var e Event
+		if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+			log.Printf("invalid todo event payload: %v", err)
+			continue
+		}
+		b.dispatch(e)
+	}
+}
+
+// dispatch tidak pernah menunggu client yang lambat, event untuk client
+// yang buffer-nya penuh dibuang.
+func (b *RedisBroker) dispatch(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[e.UserID] {
+		select {
+		case ch <- e:
+		default:
+			log.Printf("dropping todo event %d for user %d: subscriber is too slow", e.ID, e.UserID)
+		}
+	}
+}
+
+const (
+	redisStream       = "todo-list:events"
+	redisStreamMaxLen = 100000
+)
+
+// RedisStream menambahkan event ke Redis stream untuk consumer lain yang
+// membutuhkan riwayat event (XREAD atau consumer group).
+type RedisStream struct {
+	rdb *redis.Client
+}
+
+func NewRedisStream(rdb *redis.Client) *RedisStream {
+	return &RedisStream{rdb}
+}
+
+func (s *RedisStream) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: redisStream,
+		MaxLen: redisStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"type": e.Type, "payload": payload},
+	}).Err()
+}
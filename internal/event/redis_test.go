@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+	"testing"
+)
+
+// newTestBroker tidak menjalankan listen sehingga event dikirim langsung
+// lewat dispatch tanpa Redis.
+func newTestBroker() *RedisBroker {
+	b := NewRedisBroker(nil)
+	b.once.Do(func() {})
+	return b
+}
+
+func receive(ch <-chan Event) (Event, bool) {
+	select {
+	case e, ok := <-ch:
+		return e, ok
+	default:
+		return Event{}, false
+	}
+}
+
+func TestRedisBrokerFansOutPerUser(t *testing.T) {
+	b := newTestBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := b.Subscribe(ctx, 1)
+	second := b.Subscribe(ctx, 1)
+	other := b.Subscribe(ctx, 2)
+
+	b.dispatch(Event{ID: 7, Type: TypeTodoCreated, UserID: 1})
+	for i, ch := range []<-chan Event{first, second} {
+		if e, ok := receive(ch); !ok || e.ID != 7 {
+			t.Errorf("subscriber %d received %+v, %v", i, e, ok)
+		}
+	}
+	if e, ok := receive(other); ok {
+		t.Errorf("subscriber of another user received %+v", e)
+	}
+}
+
+func TestRedisBrokerUnsubscribesOnCancel(t *testing.T) {
+	b := newTestBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.Subscribe(ctx, 1)
+
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Fatal("channel not closed after cancel")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subscribers) != 0 {
+		t.Errorf("subscribers = %v, want none", b.subscribers)
+	}
+}
+
+func TestRedisBrokerDropsEventsForSlowSubscriber(t *testing.T) {
+	b := newTestBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := b.Subscribe(ctx, 1)
+
+	// dispatch tidak boleh menunggu walaupun buffer penuh
+	for i := 0; i < subscriberBuffer+5; i++ {
+		b.dispatch(Event{ID: uint(i + 1), UserID: 1})
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("buffered %d events, want %d", len(ch), subscriberBuffer)
+	}
+}
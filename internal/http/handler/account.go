@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+}
+
+func NewAccountHandler(accountService service.AccountService) AccountHandler {
+	return AccountHandler{accountService}
+}
+
+// ResendVerification mengirim ulang link verifikasi email.
+func (h *AccountHandler) ResendVerification(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	if err := h.accountService.SendEmailVerification(ctx.Request().Context(), int64(userID)); err != nil {
+		return accountError(ctx, err)
+	}
+	return ctx.JSON(http.StatusAccepted, response.SuccessResponse("verification email sent", nil))
+}
+
+func (h *AccountHandler) ChangeEmail(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if err := h.accountService.ChangeEmail(ctx.Request().Context(), int64(userID), req.Email); err != nil {
+		return accountError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("email updated, check your inbox to verify it", nil))
+}
+
+// VerifyEmail menerima token dari query string (link di email) atau body.
+func (h *AccountHandler) VerifyEmail(ctx echo.Context) error {
+	var req struct {
+		Token string `json:"token" query:"token"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if err := h.accountService.VerifyEmail(ctx.Request().Context(), req.Token); err != nil {
+		return accountError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("email verified successfully", nil))
+}
+
+// ForgotPassword selalu mengembalikan 202 agar tidak bisa dipakai untuk
+// mengecek apakah sebuah email terdaftar.
+func (h *AccountHandler) ForgotPassword(ctx echo.Context) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if err := h.accountService.ForgotPassword(ctx.Request().Context(), req.Email); err != nil {
+		return accountError(ctx, err)
+	}
+	return ctx.JSON(http.StatusAccepted, response.SuccessResponse("if the email is registered, a reset token has been sent to it", nil))
+}
+
+func (h *AccountHandler) ResetPassword(ctx echo.Context) error {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if err := h.accountService.ResetPassword(ctx.Request().Context(), req.Token, req.Password); err != nil {
+		return accountError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("password reset successfully", nil))
+}
+
+func accountError(ctx echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail), errors.Is(err, service.ErrNoEmail),
+		errors.Is(err, service.ErrInvalidEmailToken), errors.Is(err, service.ErrInvalidResetToken),
+		errors.Is(err, service.ErrPasswordTooShort):
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	case errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrEmailVerified):
+		return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, err.Error()))
+	case errors.Is(err, service.ErrEmailUnavailable):
+		return ctx.JSON(http.StatusServiceUnavailable, response.ErrorResponse(http.StatusServiceUnavailable, err.Error()))
+	}
+	return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+}
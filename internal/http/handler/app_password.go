@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AppPasswordHandler struct {
+	appPasswordService service.AppPasswordService
+}
+
+func NewAppPasswordHandler(appPasswordService service.AppPasswordService) AppPasswordHandler {
+	return AppPasswordHandler{appPasswordService}
+}
+
+func (h *AppPasswordHandler) Create(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	password, appPassword, err := h.appPasswordService.CreateAppPassword(ctx.Request().Context(), userID, req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrAppPasswordName) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusCreated, response.SuccessResponse("app password created, it will not be shown again", map[string]interface{}{
+		"password":     password,
+		"app_password": appPassword,
+	}))
+}
+
+func (h *AppPasswordHandler) FindAll(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	passwords, err := h.appPasswordService.GetAppPasswords(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch app passwords", passwords))
+}
+
+func (h *AppPasswordHandler) Revoke(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid app password ID"))
+	}
+	if err := h.appPasswordService.RevokeAppPassword(ctx.Request().Context(), userID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrAppPasswordNotFound) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("app password revoked successfully", nil))
+}
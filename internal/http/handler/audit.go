@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) AuditHandler {
+	return AuditHandler{auditService}
+}
+
+func (h *AuditHandler) FindAll(ctx echo.Context) error {
+	filter := repository.AuditLogFilter{
+		Action:     ctx.QueryParam("action"),
+		TargetType: ctx.QueryParam("target_type"),
+		TargetID:   ctx.QueryParam("target_id"),
+	}
+	if v := ctx.QueryParam("actor_id"); v != "" {
+		actorID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid actor ID"))
+		}
+		id := uint(actorID)
+		filter.ActorID = &id
+	}
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := ctx.QueryParam(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid "+param+", use RFC3339 format"))
+			}
+			*dst = &t
+		}
+	}
+	for param, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if v := ctx.QueryParam(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid "+param))
+			}
+			*dst = n
+		}
+	}
+
+	logs, err := h.auditService.Find(ctx.Request().Context(), filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError,
+			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch audit logs", logs))
+}
+
+func (h *AuditHandler) Verify(ctx echo.Context) error {
+	result, err := h.auditService.Verify(ctx.Request().Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError,
+			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("audit log verified", result))
+}
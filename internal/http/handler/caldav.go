@@ -0,0 +1,446 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"todo-list/internal/entity"
+	"todo-list/internal/service"
+	"todo-list/internal/todofmt"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CalDAVHandler melayani collection VTODO minimal (RFC 4791) per user:
+//
+//	/caldav/                                 current-user-principal
+//	/caldav/principals/:username/            calendar-home-set
+//	/caldav/calendars/:username/             calendar home
+//	/caldav/calendars/:username/todos/       collection VTODO
+//	/caldav/calendars/:username/todos/:name  calendar object
+//
+// Autentikasi memakai HTTP Basic dengan app password.
+type CalDAVHandler struct {
+	todoService        service.TodoService
+	appPasswordService service.AppPasswordService
+}
+
+func NewCalDAVHandler(todoService service.TodoService, appPasswordService service.AppPasswordService) CalDAVHandler {
+	return CalDAVHandler{todoService, appPasswordService}
+}
+
+const (
+	caldavRealm       = "todo-list CalDAV"
+	caldavMethods     = "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT"
+	caldavSyncPrefix  = "urn:todo-list:sync:"
+	caldavContentType = "text/calendar; charset=utf-8; component=VTODO"
+)
+
+var errInvalidSyncToken = xml.Name{Space: nsDAV, Local: "valid-sync-token"}
+
+// Authenticate memeriksa Basic auth dengan app password dan memastikan
+// user hanya mengakses path miliknya sendiri.
+func (h *CalDAVHandler) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		ctx.Response().Header().Set("DAV", "1, 3, calendar-access")
+		username, password, ok := ctx.Request().BasicAuth()
+		if !ok {
+			return caldavUnauthorized(ctx)
+		}
+		user, err := h.appPasswordService.Authenticate(ctx.Request().Context(), username, password)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAppPassword) {
+				return caldavUnauthorized(ctx)
+			}
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		if p := ctx.Param("username"); p != "" && p != user.Username {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, "anda tidak diizinkan untuk mengakses resource ini."))
+		}
+
+		ctx.Set("user_id", uint(user.ID))
+		ctx.Set("caldav_username", user.Username)
+		req := ctx.Request()
+		a, _ := actor.FromContext(req.Context())
+		a.UserID = uint(user.ID)
+		a.Role = user.Role
+		ctx.SetRequest(req.WithContext(actor.NewContext(req.Context(), a)))
+		return next(ctx)
+	}
+}
+
+func caldavUnauthorized(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="`+caldavRealm+`"`)
+	return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "anda harus login untuk megakses resource ini."))
+}
+
+type caldavPaths struct {
+	root, principal, home, collection string
+}
+
+// caldavPathsOf menyusun href berdasarkan prefix path request, sehingga
+// tetap benar di bawah /api/v1.
+func caldavPathsOf(ctx echo.Context) caldavPaths {
+	p := ctx.Request().URL.Path
+	base := p[:strings.Index(p, "/caldav")] + "/caldav/"
+	username, _ := ctx.Get("caldav_username").(string)
+	escaped := url.PathEscape(username)
+	return caldavPaths{
+		root:       base,
+		principal:  base + "principals/" + escaped + "/",
+		home:       base + "calendars/" + escaped + "/",
+		collection: base + "calendars/" + escaped + "/todos/",
+	}
+}
+
+func (h *CalDAVHandler) Options(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderAllow, caldavMethods)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// readPropfind mengembalikan property yang diminta, nil untuk allprop.
+func readPropfind(ctx echo.Context) ([]xml.Name, error) {
+	var propfind davPropfind
+	ok, err := readDAVBody(ctx, &propfind)
+	if err != nil || !ok || propfind.Prop == nil {
+		return nil, err
+	}
+	return *propfind.Prop, nil
+}
+
+// depth membaca header Depth. Depth infinity diperlakukan sebagai 1.
+func depth(ctx echo.Context) int {
+	if ctx.Request().Header.Get("Depth") == "0" {
+		return 0
+	}
+	return 1
+}
+
+func (h *CalDAVHandler) PropfindRoot(ctx echo.Context) error {
+	names, err := readPropfind(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	paths := caldavPathsOf(ctx)
+	return multistatus(ctx, []davResponse{{
+		Href: paths.root,
+		Props: davProps{
+			propResourceType:         "<collection/>",
+			propCurrentUserPrincipal: davHref(paths.principal),
+		},
+	}}, names, "")
+}
+
+func (h *CalDAVHandler) PropfindPrincipal(ctx echo.Context) error {
+	names, err := readPropfind(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	paths := caldavPathsOf(ctx)
+	username, _ := ctx.Get("caldav_username").(string)
+	return multistatus(ctx, []davResponse{{
+		Href: paths.principal,
+		Props: davProps{
+			propResourceType:         "<principal/>",
+			propDisplayName:          xmlEscape(username),
+			propCurrentUserPrincipal: davHref(paths.principal),
+			propPrincipalURL:         davHref(paths.principal),
+			propCalendarHomeSet:      davHref(paths.home),
+		},
+	}}, names, "")
+}
+
+func (h *CalDAVHandler) PropfindHome(ctx echo.Context) error {
+	names, err := readPropfind(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	paths := caldavPathsOf(ctx)
+	responses := []davResponse{{
+		Href: paths.home,
+		Props: davProps{
+			propResourceType:         "<collection/>",
+			propCurrentUserPrincipal: davHref(paths.principal),
+			propOwner:                davHref(paths.principal),
+		},
+	}}
+	if depth(ctx) > 0 {
+		collection, err := h.collectionResponse(ctx, paths)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		responses = append(responses, collection)
+	}
+	return multistatus(ctx, responses, names, "")
+}
+
+func (h *CalDAVHandler) PropfindCollection(ctx echo.Context) error {
+	names, err := readPropfind(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	paths := caldavPathsOf(ctx)
+	collection, err := h.collectionResponse(ctx, paths)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	responses := []davResponse{collection}
+	if depth(ctx) > 0 {
+		objects, err := h.allObjects(ctx, paths)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		responses = append(responses, objects...)
+	}
+	return multistatus(ctx, responses, names, "")
+}
+
+func (h *CalDAVHandler) collectionResponse(ctx echo.Context, paths caldavPaths) (davResponse, error) {
+	userID, _ := ctx.Get("user_id").(uint)
+	syncToken, err := h.todoService.GetSyncToken(ctx.Request().Context(), userID)
+	if err != nil {
+		return davResponse{}, err
+	}
+	token := xmlEscape(caldavSyncPrefix + strconv.FormatUint(uint64(syncToken), 10))
+	return davResponse{
+		Href: paths.collection,
+		Props: davProps{
+			propResourceType:          `<collection/><calendar xmlns="` + nsCalDAV + `"/>`,
+			propDisplayName:           "Todos",
+			propCurrentUserPrincipal:  davHref(paths.principal),
+			propOwner:                 davHref(paths.principal),
+			propSupportedComponents:   `<comp name="VTODO"/>`,
+			propGetCTag:               token,
+			propSyncToken:             token,
+			propCurrentUserPrivileges: "<privilege><read/></privilege><privilege><write/></privilege>",
+			propSupportedReportSet: `<supported-report><report><calendar-query xmlns="` + nsCalDAV + `"/></report></supported-report>` +
+				`<supported-report><report><calendar-multiget xmlns="` + nsCalDAV + `"/></report></supported-report>` +
+				`<supported-report><report><sync-collection/></report></supported-report>`,
+		},
+	}, nil
+}
+
+func (h *CalDAVHandler) allObjects(ctx echo.Context, paths caldavPaths) ([]davResponse, error) {
+	userID, _ := ctx.Get("user_id").(uint)
+	var responses []davResponse
+	err := h.todoService.ExportTodos(ctx.Request().Context(), userID, func(todo *entity.Todo) error {
+		res, err := objectResponse(paths, todo)
+		if err != nil {
+			return err
+		}
+		responses = append(responses, res)
+		return nil
+	})
+	return responses, err
+}
+
+func objectResponse(paths caldavPaths, todo *entity.Todo) (davResponse, error) {
+	data, err := renderVTODO(todo)
+	if err != nil {
+		return davResponse{}, err
+	}
+	return davResponse{
+		Href: paths.collection + url.PathEscape(todo.ResourceName()),
+		Props: davProps{
+			propResourceType:   "",
+			propGetETag:        xmlEscape(versionETag(todo.Version)),
+			propGetContentType: caldavContentType,
+			propCalendarData:   xmlEscape(string(data)),
+		},
+	}, nil
+}
+
+func renderVTODO(todo *entity.Todo) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder, err := todofmt.NewEncoder(todofmt.FormatICS, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := encoder.Encode(todo); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Report menangani calendar-query, calendar-multiget dan sync-collection.
+// Filter calendar-query tidak dievaluasi karena collection hanya berisi
+// VTODO, sehingga semua object dikembalikan.
+func (h *CalDAVHandler) Report(ctx echo.Context) error {
+	var report davReport
+	if ok, err := readDAVBody(ctx, &report); err != nil || !ok {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "invalid REPORT body"))
+	}
+	var names []xml.Name
+	if report.Prop != nil {
+		names = *report.Prop
+	}
+	paths := caldavPathsOf(ctx)
+	userID, _ := ctx.Get("user_id").(uint)
+
+	switch report.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		objects, err := h.allObjects(ctx, paths)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		return multistatus(ctx, objects, names, "")
+
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		responses := make([]davResponse, 0, len(report.Hrefs))
+		for _, href := range report.Hrefs {
+			name, _ := url.PathUnescape(path.Base(href))
+			todo, err := h.todoService.GetTodoByResourceName(ctx.Request().Context(), userID, name)
+			if err != nil {
+				responses = append(responses, davResponse{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			res, err := objectResponse(paths, todo)
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+			}
+			responses = append(responses, res)
+		}
+		return multistatus(ctx, responses, names, "")
+
+	case xml.Name{Space: nsDAV, Local: "sync-collection"}:
+		return h.syncCollection(ctx, paths, userID, report.SyncToken, names)
+	}
+	return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "unsupported REPORT "+report.XMLName.Local))
+}
+
+// syncCollection (RFC 6578). Sync token kosong berarti sinkronisasi awal.
+func (h *CalDAVHandler) syncCollection(ctx echo.Context, paths caldavPaths, userID uint, token string, names []xml.Name) error {
+	if token == "" {
+		objects, err := h.allObjects(ctx, paths)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		latest, err := h.todoService.GetSyncToken(ctx.Request().Context(), userID)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		return multistatus(ctx, objects, names, caldavSyncPrefix+strconv.FormatUint(uint64(latest), 10))
+	}
+
+	since, err := strconv.ParseUint(strings.TrimPrefix(token, caldavSyncPrefix), 10, 32)
+	if err != nil || !strings.HasPrefix(token, caldavSyncPrefix) {
+		return davError(ctx, http.StatusForbidden, errInvalidSyncToken)
+	}
+	changes, latest, err := h.todoService.GetTodoChanges(ctx.Request().Context(), userID, uint(since))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	responses := make([]davResponse, 0, len(changes))
+	for _, change := range changes {
+		if change.Todo == nil {
+			responses = append(responses, davResponse{
+				Href:   paths.collection + url.PathEscape(change.ResourceName),
+				Status: http.StatusNotFound,
+			})
+			continue
+		}
+		res, err := objectResponse(paths, change.Todo)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		responses = append(responses, res)
+	}
+	return multistatus(ctx, responses, names, caldavSyncPrefix+strconv.FormatUint(uint64(latest), 10))
+}
+
+func resourceName(ctx echo.Context) string {
+	name, err := url.PathUnescape(ctx.Param("name"))
+	if err != nil {
+		return ctx.Param("name")
+	}
+	return name
+}
+
+func (h *CalDAVHandler) GetObject(ctx echo.Context) error {
+	userID, _ := ctx.Get("user_id").(uint)
+	todo, err := h.todoService.GetTodoByResourceName(ctx.Request().Context(), userID, resourceName(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrResourceNotFound) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	data, err := renderVTODO(todo)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	etag := versionETag(todo.Version)
+	ctx.Response().Header().Set("ETag", etag)
+	if notModified(ctx, etag) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.Blob(http.StatusOK, caldavContentType, data)
+}
+
+// PutObject membuat atau mengganti todo. If-None-Match: * hanya mengizinkan
+// pembuatan, If-Match hanya mengizinkan update pada version tersebut.
+func (h *CalDAVHandler) PutObject(ctx echo.Context) error {
+	userID, _ := ctx.Get("user_id").(uint)
+	name := resourceName(ctx)
+	if len(name) > 255 {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "resource name must not exceed 255 characters"))
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	createOnly := strings.TrimSpace(ctx.Request().Header.Get("If-None-Match")) == "*"
+
+	todo, uid, err := todofmt.ParseVTODO(io.LimitReader(ctx.Request().Body, maxImportSize))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	saved, created, err := h.todoService.PutTodoResource(ctx.Request().Context(), userID, name, uid, todo, version, createOnly)
+	if err != nil {
+		if isValidationError(err) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	ctx.Response().Header().Set("ETag", versionETag(saved.Version))
+	if created {
+		return ctx.NoContent(http.StatusCreated)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (h *CalDAVHandler) DeleteObject(ctx echo.Context) error {
+	userID, _ := ctx.Get("user_id").(uint)
+	todo, err := h.todoService.GetTodoByResourceName(ctx.Request().Context(), userID, resourceName(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrResourceNotFound) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if err := h.todoService.DeleteTodo(ctx.Request().Context(), userID, todo.ID, version); err != nil {
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
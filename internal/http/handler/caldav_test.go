@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+	"todo-list/pkg/cache"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// fakeTodoRepository menyimpan todo di memory. Hanya method yang dipakai
+// alur CalDAV yang diimplementasikan.
+type fakeTodoRepository struct {
+	repository.TodoRepository
+	todos  map[uint]*entity.Todo
+	nextID uint
+}
+
+func (r *fakeTodoRepository) Create(ctx context.Context, todo *entity.Todo) error {
+	r.nextID++
+	todo.ID = r.nextID
+	if todo.Version == 0 {
+		todo.Version = 1
+	}
+	if todo.CreatedAt.IsZero() {
+		todo.CreatedAt = time.Now()
+	}
+	stored := *todo
+	r.todos[todo.ID] = &stored
+	return nil
+}
+
+func (r *fakeTodoRepository) GetByID(ctx context.Context, id uint) (*entity.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *todo
+	return &found, nil
+}
+
+func (r *fakeTodoRepository) GetTrashedByID(ctx context.Context, id uint) (*entity.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *todo
+	return &found, nil
+}
+
+func (r *fakeTodoRepository) GetByCalDAVName(ctx context.Context, userID uint, name string) (*entity.Todo, error) {
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.CalDAVName == name && !todo.DeletedAt.Valid {
+			found := *todo
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeTodoRepository) GetLastPosition(ctx context.Context, userID uint) (string, error) {
+	last := ""
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.Position > last {
+			last = todo.Position
+		}
+	}
+	return last, nil
+}
+
+func (r *fakeTodoRepository) Update(ctx context.Context, id, version uint, columns map[string]interface{}) error {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid || todo.Version != version {
+		return repository.ErrVersionConflict
+	}
+	for column, value := range columns {
+		switch column {
+		case "title":
+			todo.Title = value.(string)
+		case "description":
+			todo.Description = value.(string)
+		case "done":
+			todo.Done = value.(bool)
+		case "completed_at":
+			todo.CompletedAt = value.(*time.Time)
+		}
+	}
+	todo.Version++
+	return nil
+}
+
+func (r *fakeTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid || todo.Version != version {
+		return repository.ErrVersionConflict
+	}
+	todo.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *fakeTodoRepository) StreamByUserID(ctx context.Context, userID uint, fn func(todo *entity.Todo) error) error {
+	ids := make([]uint, 0, len(r.todos))
+	for id, todo := range r.todos {
+		if todo.UserID == userID && !todo.DeletedAt.Valid {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		todo := *r.todos[id]
+		if err := fn(&todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeTodoEventRepository struct {
+	repository.TodoEventRepository
+	events []entity.TodoEvent
+}
+
+func (r *fakeTodoEventRepository) Create(ctx context.Context, e *entity.TodoEvent) error {
+	e.ID = uint(len(r.events) + 1)
+	r.events = append(r.events, *e)
+	return nil
+}
+
+func (r *fakeTodoEventRepository) GetByUserIDAfter(ctx context.Context, userID, eventID uint) ([]entity.TodoEvent, error) {
+	var events []entity.TodoEvent
+	for _, e := range r.events {
+		if e.UserID == userID && e.ID > eventID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeTodoEventRepository) GetLatestIDByUserID(ctx context.Context, userID uint) (uint, error) {
+	var latest uint
+	for _, e := range r.events {
+		if e.UserID == userID {
+			latest = e.ID
+		}
+	}
+	return latest, nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeCache struct {
+	cache.Cacheable
+}
+
+func (fakeCache) Delete(key string) error { return nil }
+
+type fakePublisher struct{}
+
+func (fakePublisher) Publish(ctx context.Context, e event.Event) error { return nil }
+
+type fakeAppPasswordService struct {
+	service.AppPasswordService
+	user entity.User
+}
+
+func (s *fakeAppPasswordService) Authenticate(ctx context.Context, username, password string) (*entity.User, error) {
+	if username != s.user.Username || password != "app-password" {
+		return nil, service.ErrInvalidAppPassword
+	}
+	return &s.user, nil
+}
+
+type caldavClient struct {
+	t      *testing.T
+	server *httptest.Server
+}
+
+const caldavCollection = "/api/v1/caldav/calendars/alice/todos/"
+
+func newCalDAVClient(t *testing.T) (*caldavClient, *fakeTodoRepository) {
+	todos := &fakeTodoRepository{todos: map[uint]*entity.Todo{}}
+	todoService := service.NewTodoService(todos, &fakeTodoEventRepository{}, fakeTransactor{}, nil, fakeCache{}, nil, fakePublisher{})
+	h := NewCalDAVHandler(todoService, &fakeAppPasswordService{user: entity.User{ID: 7, Username: "alice", Role: "user"}})
+
+	e := echo.New()
+	auth := h.Authenticate
+	v1 := e.Group("/api/v1")
+	v1.Add("REPORT", "/caldav/calendars/:username/todos/", h.Report, auth)
+	v1.Add(http.MethodGet, "/caldav/calendars/:username/todos/:name", h.GetObject, auth)
+	v1.Add(http.MethodPut, "/caldav/calendars/:username/todos/:name", h.PutObject, auth)
+	v1.Add(http.MethodDelete, "/caldav/calendars/:username/todos/:name", h.DeleteObject, auth)
+
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return &caldavClient{t, server}, todos
+}
+
+func (c *caldavClient) do(method, path, body string, header http.Header) *http.Response {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.server.URL+path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.SetBasicAuth("alice", "app-password")
+	res, err := c.server.Client().Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func (c *caldavClient) put(name, summary string, header http.Header) *http.Response {
+	c.t.Helper()
+	body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + name + "@client\r\nSUMMARY:" + summary + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(echo.HeaderContentType, "text/calendar")
+	return c.do(http.MethodPut, caldavCollection+name, body, header)
+}
+
+type syncResult struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Status   string `xml:"status"`
+		Propstat []struct {
+			Prop struct {
+				ETag string `xml:"getetag"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+	SyncToken string `xml:"sync-token"`
+}
+
+// statuses memetakan href ke status response, atau ETag untuk resource
+// yang masih ada.
+func (r syncResult) statuses() map[string]string {
+	statuses := map[string]string{}
+	for _, res := range r.Responses {
+		if res.Status != "" {
+			statuses[res.Href] = res.Status
+			continue
+		}
+		for _, propstat := range res.Propstat {
+			if propstat.Prop.ETag != "" {
+				statuses[res.Href] = propstat.Prop.ETag
+			}
+		}
+	}
+	return statuses
+}
+
+func (c *caldavClient) sync(token string) syncResult {
+	c.t.Helper()
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<sync-collection xmlns="DAV:">
+  <sync-token>` + token + `</sync-token>
+  <sync-level>1</sync-level>
+  <prop><getetag/></prop>
+</sync-collection>`
+	res := c.do("REPORT", caldavCollection, body, http.Header{echo.HeaderContentType: {"application/xml"}})
+	data, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusMultiStatus {
+		c.t.Fatalf("sync-collection returned %d: %s", res.StatusCode, data)
+	}
+	var result syncResult
+	if err := xml.Unmarshal(data, &result); err != nil {
+		c.t.Fatalf("invalid multistatus %s: %v", data, err)
+	}
+	if result.SyncToken == "" {
+		c.t.Fatalf("multistatus without sync-token: %s", data)
+	}
+	return result
+}
+
+func expectStatuses(t *testing.T, result syncResult, want map[string]string) {
+	t.Helper()
+	got := result.statuses()
+	if len(got) != len(want) {
+		t.Fatalf("sync returned %v, want %v", got, want)
+	}
+	for href, status := range want {
+		if got[href] != status {
+			t.Errorf("%s: got %q, want %q", href, got[href], status)
+		}
+	}
+}
+
+func TestCalDAVSyncCollection(t *testing.T) {
+	client, _ := newCalDAVClient(t)
+
+	initial := client.sync("")
+	expectStatuses(t, initial, map[string]string{})
+
+	if res := client.put("a.ics", "First", nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT a.ics returned %d", res.StatusCode)
+	}
+	if res := client.put("b.ics", "Second", nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT b.ics returned %d", res.StatusCode)
+	}
+
+	// sinkronisasi awal mengembalikan semua object
+	full := client.sync("")
+	expectStatuses(t, full, map[string]string{
+		caldavCollection + "a.ics": `"1"`,
+		caldavCollection + "b.ics": `"1"`,
+	})
+
+	// sinkronisasi incremental hanya mengembalikan yang berubah
+	if res := client.put("a.ics", "First, renamed", http.Header{"If-Match": {`"1"`}}); res.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT a.ics update returned %d", res.StatusCode)
+	}
+	incremental := client.sync(full.SyncToken)
+	expectStatuses(t, incremental, map[string]string{
+		caldavCollection + "a.ics": `"2"`,
+	})
+
+	// todo yang dihapus muncul sebagai 404
+	if res := client.do(http.MethodDelete, caldavCollection+"b.ics", "", http.Header{"If-Match": {`"1"`}}); res.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE b.ics returned %d", res.StatusCode)
+	}
+	deleted := client.sync(incremental.SyncToken)
+	expectStatuses(t, deleted, map[string]string{
+		caldavCollection + "b.ics": "HTTP/1.1 404 Not Found",
+	})
+	if res := client.do(http.MethodGet, caldavCollection+"b.ics", "", nil); res.StatusCode != http.StatusNotFound {
+		t.Errorf("GET deleted b.ics returned %d", res.StatusCode)
+	}
+
+	// tanpa perubahan, sync token tetap sama
+	unchanged := client.sync(deleted.SyncToken)
+	expectStatuses(t, unchanged, map[string]string{})
+	if unchanged.SyncToken != deleted.SyncToken {
+		t.Errorf("sync token changed from %q to %q without changes", deleted.SyncToken, unchanged.SyncToken)
+	}
+}
+
+func TestCalDAVInvalidSyncToken(t *testing.T) {
+	client, _ := newCalDAVClient(t)
+	body := `<sync-collection xmlns="DAV:"><sync-token>bogus</sync-token><prop><getetag/></prop></sync-collection>`
+	res := client.do("REPORT", caldavCollection, body, nil)
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("invalid sync token returned %d", res.StatusCode)
+	}
+}
+
+func TestCalDAVStaleIfMatch(t *testing.T) {
+	client, todos := newCalDAVClient(t)
+	if res := client.put("a.ics", "First", nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT a.ics returned %d", res.StatusCode)
+	}
+	if res := client.put("a.ics", "Second", http.Header{"If-Match": {`"1"`}}); res.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT a.ics update returned %d", res.StatusCode)
+	}
+
+	if res := client.put("a.ics", "Lost update", http.Header{"If-Match": {`"1"`}}); res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with stale If-Match returned %d", res.StatusCode)
+	}
+	if res := client.do(http.MethodDelete, caldavCollection+"a.ics", "", http.Header{"If-Match": {`"1"`}}); res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with stale If-Match returned %d", res.StatusCode)
+	}
+	if res := client.put("a.ics", "Duplicate", http.Header{"If-None-Match": {"*"}}); res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with If-None-Match on an existing object returned %d", res.StatusCode)
+	}
+
+	todo, err := todos.GetByCalDAVName(context.Background(), 7, "a.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.Title != "Second" || todo.Version != 2 {
+		t.Errorf("todo changed by a rejected request: %+v", todo)
+	}
+	res := client.do(http.MethodGet, caldavCollection+"a.ics", "", nil)
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") != `"2"` {
+		t.Errorf("GET returned %d with ETag %q", res.StatusCode, res.Header.Get("ETag"))
+	}
+}
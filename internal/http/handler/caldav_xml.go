@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Namespace WebDAV (RFC 4918), CalDAV (RFC 4791) dan CalendarServer untuk
+// getctag.
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+var (
+	propResourceType          = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName           = xml.Name{Space: nsDAV, Local: "displayname"}
+	propCurrentUserPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL          = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propOwner                 = xml.Name{Space: nsDAV, Local: "owner"}
+	propGetETag               = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType        = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propSyncToken             = xml.Name{Space: nsDAV, Local: "sync-token"}
+	propSupportedReportSet    = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propCurrentUserPrivileges = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propCalendarHomeSet       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propSupportedComponents   = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData          = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propGetCTag               = xml.Name{Space: nsCS, Local: "getctag"}
+)
+
+// davProps berisi nilai property dalam bentuk inner XML yang sudah di-escape.
+type davProps map[xml.Name]string
+
+// davResponse adalah satu elemen <response> di dalam multistatus. Status
+// diisi (misalnya 404) untuk resource yang tidak punya property.
+type davResponse struct {
+	Href   string
+	Props  davProps
+	Status int
+}
+
+// davPropNames membaca nama-nama elemen anak langsung dari <prop>.
+type davPropNames []xml.Name
+
+func (p *davPropNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	depth := 0
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				*p = append(*p, t.Name)
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+type davPropfind struct {
+	XMLName  xml.Name      `xml:"DAV: propfind"`
+	AllProp  *struct{}     `xml:"DAV: allprop"`
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+}
+
+// davReport mencakup calendar-query, calendar-multiget dan sync-collection.
+type davReport struct {
+	XMLName   xml.Name
+	Prop      *davPropNames `xml:"DAV: prop"`
+	Hrefs     []string      `xml:"DAV: href"`
+	SyncToken string        `xml:"DAV: sync-token"`
+}
+
+// readDAVBody membaca body XML. Body kosong dianggap valid dan v tidak diisi.
+func readDAVBody(ctx echo.Context, v interface{}) (bool, error) {
+	b, err := io.ReadAll(io.LimitReader(ctx.Request().Body, maxImportSize))
+	if err != nil {
+		return false, err
+	}
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return false, nil
+	}
+	return true, xml.Unmarshal(b, v)
+}
+
+// selectProps memilih property yang diminta. names nil berarti allprop,
+// calendar-data hanya dikirim jika diminta secara eksplisit.
+func selectProps(all davProps, names []xml.Name) (found davProps, missing []xml.Name) {
+	found = davProps{}
+	if names == nil {
+		for name, value := range all {
+			if name != propCalendarData {
+				found[name] = value
+			}
+		}
+		return found, nil
+	}
+	for _, name := range names {
+		if value, ok := all[name]; ok {
+			found[name] = value
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	return found, missing
+}
+
+func davHref(href string) string {
+	return "<href>" + xmlEscape(href) + "</href>"
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeDAVProp(b *strings.Builder, name xml.Name, value string) {
+	if value == "" {
+		fmt.Fprintf(b, `<%s xmlns="%s"/>`, name.Local, name.Space)
+		return
+	}
+	fmt.Fprintf(b, `<%s xmlns="%s">%s</%s>`, name.Local, name.Space, value, name.Local)
+}
+
+func writePropstat(b *strings.Builder, props davProps, names []xml.Name, status int) {
+	b.WriteString("<propstat><prop>")
+	for _, name := range names {
+		writeDAVProp(b, name, props[name])
+	}
+	fmt.Fprintf(b, "</prop><status>HTTP/1.1 %d %s</status></propstat>", status, http.StatusText(status))
+}
+
+// multistatus menulis response 207. requested nil berarti allprop.
+func multistatus(ctx echo.Context, responses []davResponse, requested []xml.Name, syncToken string) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<multistatus xmlns="DAV:">`)
+	for _, res := range responses {
+		b.WriteString("<response>")
+		b.WriteString(davHref(res.Href))
+		if res.Status != 0 {
+			fmt.Fprintf(&b, "<status>HTTP/1.1 %d %s</status>", res.Status, http.StatusText(res.Status))
+		} else {
+			found, missing := selectProps(res.Props, requested)
+			names := make([]xml.Name, 0, len(found))
+			for name := range found {
+				names = append(names, name)
+			}
+			sort.Slice(names, func(i, j int) bool {
+				return names[i].Space+names[i].Local < names[j].Space+names[j].Local
+			})
+			if len(names) > 0 || len(missing) == 0 {
+				writePropstat(&b, found, names, http.StatusOK)
+			}
+			if len(missing) > 0 {
+				writePropstat(&b, nil, missing, http.StatusNotFound)
+			}
+		}
+		b.WriteString("</response>")
+	}
+	if syncToken != "" {
+		b.WriteString("<sync-token>" + xmlEscape(syncToken) + "</sync-token>")
+	}
+	b.WriteString("</multistatus>")
+	return ctx.Blob(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// davError mengirim body <error> untuk precondition WebDAV yang gagal,
+// misalnya valid-sync-token.
+func davError(ctx echo.Context, status int, condition xml.Name) error {
+	body := fmt.Sprintf(`%s<error xmlns="DAV:"><%s xmlns="%s"/></error>`, xml.Header, condition.Local, condition.Space)
+	return ctx.Blob(status, "application/xml; charset=utf-8", []byte(body))
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"todo-list/internal/entity"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type DigestHandler struct {
+	digestService service.DigestService
+}
+
+func NewDigestHandler(digestService service.DigestService) DigestHandler {
+	return DigestHandler{digestService}
+}
+
+func (h *DigestHandler) Get(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	subscription, err := h.digestService.GetSubscription(ctx.Request().Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrDigestNotSubscribed) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch digest subscription", subscription))
+}
+
+func (h *DigestHandler) Subscribe(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	req := struct {
+		Frequency string `json:"frequency"`
+		Timezone  string `json:"timezone"`
+		Hour      *int   `json:"hour"`
+		Weekday   *int   `json:"weekday"`
+	}{Frequency: entity.DigestDaily}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	// default: jam 7 pagi, weekly dikirim hari Senin
+	hour, weekday := 7, 1
+	if req.Hour != nil {
+		hour = *req.Hour
+	}
+	if req.Weekday != nil {
+		weekday = *req.Weekday
+	}
+
+	subscription, err := h.digestService.Subscribe(ctx.Request().Context(), userID, req.Frequency, req.Timezone, hour, weekday)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidDigest), errors.Is(err, service.ErrInvalidTimezone), errors.Is(err, service.ErrDigestNoEmail),
+			errors.Is(err, service.ErrDigestEmailUnverified):
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		case errors.Is(err, service.ErrDigestUnavailable):
+			return ctx.JSON(http.StatusServiceUnavailable, response.ErrorResponse(http.StatusServiceUnavailable, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("subscribed to the digest successfully", subscription))
+}
+
+func (h *DigestHandler) Unsubscribe(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	if err := h.digestService.Unsubscribe(ctx.Request().Context(), userID); err != nil {
+		if errors.Is(err, service.ErrDigestNotSubscribed) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("unsubscribed from the digest successfully", nil))
+}
+
+// Preview mengembalikan email digest tanpa mengirimnya. format=html atau
+// format=text mengembalikan body-nya saja.
+func (h *DigestHandler) Preview(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	msg, err := h.digestService.Preview(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	switch ctx.QueryParam("format") {
+	case "html":
+		return ctx.HTML(http.StatusOK, msg.HTML)
+	case "text":
+		return ctx.Blob(http.StatusOK, "text/plain; charset=utf-8", []byte(msg.Body))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully render digest preview", msg))
+}
+
+// UnsubscribeByToken dipakai oleh link unsubscribe di email, termasuk POST
+// one-click dari client email.
+func (h *DigestHandler) UnsubscribeByToken(ctx echo.Context) error {
+	if err := h.digestService.UnsubscribeByToken(ctx.Request().Context(), ctx.QueryParam("token")); err != nil {
+		if errors.Is(err, service.ErrInvalidUnsubscribeURL) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("unsubscribed from the digest successfully", nil))
+}
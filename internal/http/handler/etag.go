@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be a single strong ETag returned by this API")
+
+// versionETag membuat strong ETag dari kolom version.
+func versionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// ifMatchVersion membaca header If-Match. Mengembalikan 0 jika header tidak
+// dikirim atau bernilai "*".
+func ifMatchVersion(ctx echo.Context) (uint, error) {
+	header := strings.TrimSpace(ctx.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 3 {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseUint(header[1:len(header)-1], 10, 32)
+	if err != nil || version == 0 {
+		return 0, errInvalidIfMatch
+	}
+	return uint(version), nil
+}
+
+func isPreconditionFailed(err error) bool {
+	return errors.Is(err, service.ErrPreconditionFailed) ||
+		errors.Is(err, service.ErrUserPreconditionFailed) ||
+		errors.Is(err, repository.ErrVersionConflict)
+}
+
+// notModified mengecek If-None-Match terhadap etag.
+func notModified(ctx echo.Context, etag string) bool {
+	for _, candidate := range strings.Split(ctx.Request().Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonWithETag mengirim response sukses beserta ETag, atau 304 jika client
+// sudah memiliki representasi yang sama.
+func jsonWithETag(ctx echo.Context, etag string, body response.Response) error {
+	ctx.Response().Header().Set("ETag", etag)
+	if notModified(ctx, etag) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.JSON(http.StatusOK, body)
+}
+
+// jsonWithContentETag sama seperti jsonWithETag dengan ETag lemah yang
+// dihitung dari isi data, dipakai untuk response berupa list.
+func jsonWithContentETag(ctx echo.Context, body response.Response) error {
+	b, err := json.Marshal(body.Data)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	sum := sha256.Sum256(b)
+	return jsonWithETag(ctx, `W/"`+hex.EncodeToString(sum[:16])+`"`, body)
+}
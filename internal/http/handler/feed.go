@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"todo-list/internal/entity"
+	"todo-list/internal/service"
+	"todo-list/internal/todofmt"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FeedHandler struct {
+	feedService service.FeedService
+	todoService service.TodoService
+}
+
+func NewFeedHandler(feedService service.FeedService, todoService service.TodoService) FeedHandler {
+	return FeedHandler{feedService, todoService}
+}
+
+// CreateFeedToken membuat URL feed baru. URL lama langsung tidak berlaku.
+func (h *FeedHandler) CreateFeedToken(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	token, err := h.feedService.CreateFeedToken(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusCreated, response.SuccessResponse("feed token created successfully", map[string]interface{}{
+		"token": token,
+		"url":   h.feedService.FeedURL(token),
+	}))
+}
+
+func (h *FeedHandler) RevokeFeedToken(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	if err := h.feedService.RevokeFeedToken(ctx.Request().Context(), userID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("feed token revoked successfully", nil))
+}
+
+// Feed mengirim todo user sebagai VCALENDAR. Endpoint ini publik,
+// otorisasinya hanya dari token di path.
+func (h *FeedHandler) Feed(ctx echo.Context) error {
+	userID, err := h.feedService.UserIDByFeedToken(ctx.Request().Context(), ctx.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFeedToken) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+
+	res := ctx.Response()
+	encoder, err := todofmt.NewEncoder(todofmt.FormatICS, res)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	res.Header().Set(echo.HeaderContentType, todofmt.ContentType(todofmt.FormatICS))
+	res.Header().Set("Cache-Control", "private, max-age=300")
+
+	err = h.todoService.ExportTodos(ctx.Request().Context(), userID, func(todo *entity.Todo) error {
+		return encoder.Encode(todo)
+	})
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		ctx.Logger().Errorf("calendar feed of user %d: %v", userID, err)
+		if !res.Committed {
+			res.Header().Del("Cache-Control")
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		// feed yang terpotong tidak boleh di-cache client sebagai feed lengkap
+		abortResponse()
+	}
+	return nil
+}
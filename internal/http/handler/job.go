@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type JobHandler struct {
+	jobService service.JobService
+}
+
+func NewJobHandler(jobService service.JobService) JobHandler {
+	return JobHandler{jobService}
+}
+
+func (h *JobHandler) FindAll(ctx echo.Context) error {
+	filter := repository.JobFilter{
+		Name:   ctx.QueryParam("name"),
+		Status: ctx.QueryParam("status"),
+	}
+	for param, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if v := ctx.QueryParam(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid "+param))
+			}
+			*dst = n
+		}
+	}
+
+	jobs, err := h.jobService.GetJobs(ctx.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJobStatus) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError,
+			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch job runs", jobs))
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"todo-list/internal/service"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MFAHandler struct {
+	mfaService  service.MFAService
+	userService service.UserService
+}
+
+func NewMFAHandler(mfaService service.MFAService, userService service.UserService) MFAHandler {
+	return MFAHandler{mfaService, userService}
+}
+
+// actorUserID mengambil user yang login dari actor, untuk route yang juga
+// dipakai admin.
+func actorUserID(ctx echo.Context) (uint, bool) {
+	a, ok := actor.FromContext(ctx.Request().Context())
+	if !ok || a.UserID == 0 {
+		return 0, false
+	}
+	return a.UserID, true
+}
+
+func mfaError(ctx echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode), errors.Is(err, service.ErrInvalidMFAChallenge):
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, err.Error()))
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFANotEnrolled):
+		return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, err.Error()))
+	case errors.Is(err, service.ErrMFARequired):
+		return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+	}
+	return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+}
+
+type mfaCodeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+// VerifyLogin adalah langkah kedua login.
+func (h *MFAHandler) VerifyLogin(ctx echo.Context) error {
+	var req mfaCodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	result, err := h.userService.VerifyMFA(ctx.Request().Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully login", result))
+}
+
+// EnrollLogin memulai pendaftaran 2FA untuk admin yang wajib 2FA tetapi
+// belum mendaftar.
+func (h *MFAHandler) EnrollLogin(ctx echo.Context) error {
+	var req mfaCodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	enrollment, err := h.userService.EnrollMFA(ctx.Request().Context(), req.ChallengeToken)
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("scan the QR code, then log in with a code from your authenticator app", enrollment))
+}
+
+func (h *MFAHandler) Status(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	status, err := h.mfaService.Status(ctx.Request().Context(), userID)
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch two-factor authentication status", status))
+}
+
+func (h *MFAHandler) Enroll(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	enrollment, err := h.mfaService.Enroll(ctx.Request().Context(), userID)
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("scan the QR code, then confirm with a code from your authenticator app", enrollment))
+}
+
+func (h *MFAHandler) Confirm(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req mfaCodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	codes, err := h.mfaService.Confirm(ctx.Request().Context(), userID, req.Code)
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("two-factor authentication enabled, the recovery codes will not be shown again", map[string]interface{}{
+		"recovery_codes": codes,
+	}))
+}
+
+func (h *MFAHandler) Disable(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req mfaCodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if err := h.mfaService.Disable(ctx.Request().Context(), userID, req.Code); err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("two-factor authentication disabled", nil))
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req mfaCodeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	codes, err := h.mfaService.RegenerateRecoveryCodes(ctx.Request().Context(), userID, req.Code)
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("recovery codes regenerated, the old codes no longer work", map[string]interface{}{
+		"recovery_codes": codes,
+	}))
+}
+
+func (h *MFAHandler) GetPolicy(ctx echo.Context) error {
+	required, err := h.mfaService.AdminRequired(ctx.Request().Context())
+	if err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch two-factor authentication policy", map[string]interface{}{
+		"require_for_admin": required,
+	}))
+}
+
+// UpdatePolicy mewajibkan 2FA untuk role admin. Admin yang belum mendaftar
+// diminta mendaftar saat login berikutnya.
+func (h *MFAHandler) UpdatePolicy(ctx echo.Context) error {
+	var req struct {
+		RequireForAdmin *bool `json:"require_for_admin"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	if req.RequireForAdmin == nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "require_for_admin is required"))
+	}
+	if err := h.mfaService.SetAdminRequired(ctx.Request().Context(), *req.RequireForAdmin); err != nil {
+		return mfaError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("two-factor authentication policy updated", map[string]interface{}{
+		"require_for_admin": *req.RequireForAdmin,
+	}))
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) NotificationHandler {
+	return NotificationHandler{notificationService}
+}
+
+func (h *NotificationHandler) FindAll(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	unreadOnly := ctx.QueryParam("unread") == "true"
+	var limit, offset int
+	for param, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if v := ctx.QueryParam(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid "+param))
+			}
+			*dst = n
+		}
+	}
+
+	notifications, unread, err := h.notificationService.GetNotifications(ctx.Request().Context(), userID, unreadOnly, limit, offset)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch notifications", map[string]interface{}{
+		"notifications": notifications,
+		"unread_count":  unread,
+	}))
+}
+
+func (h *NotificationHandler) MarkRead(ctx echo.Context) error {
+	return h.setRead(ctx, true)
+}
+
+func (h *NotificationHandler) MarkUnread(ctx echo.Context) error {
+	return h.setRead(ctx, false)
+}
+
+func (h *NotificationHandler) setRead(ctx echo.Context, read bool) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid notification ID"))
+	}
+	if err := h.notificationService.SetRead(ctx.Request().Context(), userID, uint(id), read); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("notification updated successfully", nil))
+}
+
+func (h *NotificationHandler) MarkAllRead(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	updated, err := h.notificationService.MarkAllRead(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("notifications marked as read", map[string]interface{}{
+		"updated": updated,
+	}))
+}
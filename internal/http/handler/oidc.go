@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// oidcStateCookie mengikat state login ke browser yang memulai login, agar
+// callback dengan state milik orang lain (login CSRF) ditolak.
+const oidcStateCookie = "todo_list_oidc_state"
+
+type OIDCHandler struct {
+	oidcService service.OIDCService
+}
+
+func NewOIDCHandler(oidcService service.OIDCService) OIDCHandler {
+	return OIDCHandler{oidcService}
+}
+
+func oidcError(ctx echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrOIDCDisabled):
+		return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+	case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCLoginFailed):
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, err.Error()))
+	case errors.Is(err, service.ErrOIDCNotProvisioned):
+		return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+	}
+	return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+}
+
+// Login mengarahkan browser ke halaman login identity provider. Cookie
+// memakai SameSite=Lax karena callback adalah redirect top-level dari
+// identity provider.
+func (h *OIDCHandler) Login(ctx echo.Context) error {
+	url, state, err := h.oidcService.AuthURL(ctx.Request().Context())
+	if err != nil {
+		return oidcError(ctx, err)
+	}
+	ctx.SetCookie(oidcCookie(ctx, state, 0))
+	return ctx.Redirect(http.StatusFound, url)
+}
+
+// Callback menerima redirect dari identity provider dan mengembalikan hasil
+// login yang sama seperti POST /login.
+func (h *OIDCHandler) Callback(ctx echo.Context) error {
+	if e := ctx.QueryParam("error"); e != "" {
+		message := e
+		if description := ctx.QueryParam("error_description"); description != "" {
+			message += ": " + description
+		}
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, message))
+	}
+	state := ctx.QueryParam("state")
+	cookie, err := ctx.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return oidcError(ctx, service.ErrInvalidOIDCState)
+	}
+	ctx.SetCookie(oidcCookie(ctx, "", -1))
+
+	result, err := h.oidcService.Callback(ctx.Request().Context(), state, ctx.QueryParam("code"))
+	if err != nil {
+		return oidcError(ctx, err)
+	}
+	if result.MFARequired {
+		return ctx.JSON(http.StatusOK, response.SuccessResponse("two-factor authentication required", result))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully login", map[string]interface{}{
+		"token": result.Token,
+	}))
+}
+
+// oidcCookie dengan maxAge -1 menghapus cookie state.
+func oidcCookie(ctx echo.Context, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/v1/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   ctx.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-list/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type fakeOIDCService struct {
+	service.OIDCService
+	callbacks []string
+}
+
+func (s *fakeOIDCService) AuthURL(ctx context.Context) (string, string, error) {
+	return "https://idp.example.com/authorize?state=state-1", "state-1", nil
+}
+
+func (s *fakeOIDCService) Callback(ctx context.Context, state, code string) (*service.LoginResult, error) {
+	s.callbacks = append(s.callbacks, state)
+	return &service.LoginResult{Token: "access-token"}, nil
+}
+
+func TestOIDCLoginSetsStateCookie(t *testing.T) {
+	h := NewOIDCHandler(&fakeOIDCService{})
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/oidc/login", nil), rec)
+	if err := h.Login(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies %v", cookies)
+	}
+	c := cookies[0]
+	if c.Name != oidcStateCookie || c.Value != "state-1" || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/api/v1/oidc" {
+		t.Errorf("unexpected cookie %+v", c)
+	}
+}
+
+func TestOIDCCallbackChecksStateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		state  string
+		status int
+	}{
+		{"matching state", "state-1", "state-1", http.StatusOK},
+		{"no cookie", "", "state-1", http.StatusUnauthorized},
+		{"state of another browser", "state-2", "state-1", http.StatusUnauthorized},
+		{"no state", "state-1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oidcService := &fakeOIDCService{}
+			h := NewOIDCHandler(oidcService)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?code=code-1&state="+tt.state, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			if err := h.Callback(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				if len(oidcService.callbacks) != 0 {
+					t.Error("service callback was called")
+				}
+				return
+			}
+			cookies := rec.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || cookies[0].MaxAge >= 0 {
+				t.Errorf("state cookie was not cleared: %v", cookies)
+			}
+		})
+	}
+}
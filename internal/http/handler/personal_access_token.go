@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PersonalAccessTokenHandler struct {
+	tokenService service.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(tokenService service.PersonalAccessTokenService) PersonalAccessTokenHandler {
+	return PersonalAccessTokenHandler{tokenService}
+}
+
+func (h *PersonalAccessTokenHandler) Create(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	token, accessToken, err := h.tokenService.CreateToken(ctx.Request().Context(), userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAccessTokenName), errors.Is(err, service.ErrAccessTokenLifetime),
+			errors.Is(err, service.ErrInvalidScope):
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		case errors.Is(err, service.ErrAccessTokenLimit):
+			return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusCreated, response.SuccessResponse("personal access token created, it will not be shown again", map[string]interface{}{
+		"token":                 token,
+		"personal_access_token": accessToken,
+	}))
+}
+
+func (h *PersonalAccessTokenHandler) FindAll(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	tokens, err := h.tokenService.GetTokens(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch personal access tokens", tokens))
+}
+
+func (h *PersonalAccessTokenHandler) Revoke(ctx echo.Context) error {
+	userID, ok := actorUserID(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid token ID"))
+	}
+	if err := h.tokenService.RevokeToken(ctx.Request().Context(), userID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrAccessTokenNotFound) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("personal access token revoked successfully", nil))
+}
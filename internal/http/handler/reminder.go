@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/internal/service"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReminderHandler struct {
+	reminderService service.ReminderService
+}
+
+func NewReminderHandler(reminderService service.ReminderService) ReminderHandler {
+	return ReminderHandler{reminderService}
+}
+
+func (h *ReminderHandler) Create(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	var req struct {
+		RemindAt      *time.Time `json:"remind_at"`
+		MinutesBefore *int       `json:"minutes_before"`
+		Channels      []string   `json:"channels"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	reminder, err := h.reminderService.CreateReminder(ctx.Request().Context(), userID, uint(todoID), req.RemindAt, req.MinutesBefore, req.Channels)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReminder), errors.Is(err, service.ErrInvalidReminderChannel), errors.Is(err, service.ErrTooManyReminders):
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		case err.Error() == "unauthorized or not found":
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusCreated, response.SuccessResponse("reminder created successfully", reminder))
+}
+
+func (h *ReminderHandler) FindAll(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	reminders, err := h.reminderService.GetReminders(ctx.Request().Context(), userID, uint(todoID))
+	if err != nil {
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch reminders", reminders))
+}
+
+func (h *ReminderHandler) Delete(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("reminderID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid reminder ID"))
+	}
+	if err := h.reminderService.DeleteReminder(ctx.Request().Context(), userID, uint(todoID), uint(id)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrReminderNotFound):
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		case err.Error() == "unauthorized or not found":
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("reminder deleted successfully", nil))
+}
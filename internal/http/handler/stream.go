@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/internal/event"
+	"todo-list/pkg/response"
+	"todo-list/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// streamHeartbeat menjaga koneksi tetap hidup melewati proxy yang menutup
+// koneksi idle.
+const streamHeartbeat = 25 * time.Second
+
+type StreamHandler struct {
+	subscriber event.Subscriber
+}
+
+func NewStreamHandler(subscriber event.Subscriber) StreamHandler {
+	return StreamHandler{subscriber}
+}
+
+// tokenExpiry mengembalikan channel yang terisi saat token request habis
+// masa berlakunya. Token tanpa exp tidak pernah memicu channel.
+func tokenExpiry(ctx echo.Context) (<-chan time.Time, func()) {
+	user, ok := ctx.Get("user").(*jwt.Token)
+	if !ok {
+		return nil, func() {}
+	}
+	claims, ok := user.Claims.(*token.JwtCustomClaims)
+	if !ok || claims.ExpiresAt == nil {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+	return timer.C, func() { timer.Stop() }
+}
+
+// SSE mengirim event todo milik user sebagai Server-Sent Events. Stream
+// ditutup saat token habis atau dicabut sehingga client harus login ulang.
+func (h *StreamHandler) SSE(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	expired, stop := tokenExpiry(ctx)
+	defer stop()
+	events := h.subscriber.Subscribe(ctx.Request().Context(), userID)
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, ": connected\n\n")
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", strconv.FormatUint(uint64(e.ID), 10), e.Type, data); err != nil {
+				return nil
+			}
+			if e.EndsSession() {
+				res.Flush()
+				return nil
+			}
+		case <-expired:
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// WebSocket mengirim event todo milik user sebagai pesan JSON. Pesan dari
+// client diabaikan, hanya dibaca untuk mendeteksi koneksi yang ditutup.
+// Seperti SSE, koneksi ditutup saat token habis atau dicabut.
+func (h *StreamHandler) WebSocket(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	expired, stop := tokenExpiry(ctx)
+	defer stop()
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		subCtx, cancel := context.WithCancel(ws.Request().Context())
+		defer cancel()
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var discard []byte
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+		}()
+
+		events := h.subscriber.Subscribe(subCtx, userID)
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(ws, e); err != nil || e.EndsSession() {
+					return
+				}
+			case <-expired:
+				return
+			case <-heartbeat.C:
+				if err := websocket.Message.Send(ws, `{"type":"ping"}`); err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
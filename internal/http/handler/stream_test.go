@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/internal/event"
+	"todo-list/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// fakeSubscriber mengirim event yang dimasukkan test ke stream.
+type fakeSubscriber struct {
+	events     chan event.Event
+	subscribed chan uint
+}
+
+func newFakeSubscriber() *fakeSubscriber {
+	return &fakeSubscriber{events: make(chan event.Event, 4), subscribed: make(chan uint, 1)}
+}
+
+func (s *fakeSubscriber) Subscribe(ctx context.Context, userID uint) <-chan event.Event {
+	s.subscribed <- userID
+	return s.events
+}
+
+// newStreamServer menjalankan stream untuk user 1 dengan token yang habis
+// setelah ttl.
+func newStreamServer(t *testing.T, subscriber *fakeSubscriber, ttl time.Duration) *httptest.Server {
+	h := NewStreamHandler(subscriber)
+	authenticated := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			claims := &token.JwtCustomClaims{UserID: 1, Role: "user"}
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ttl))
+			ctx.Set("user", &jwt.Token{Claims: claims, Valid: true})
+			ctx.Set("user_id", uint(1))
+			return next(ctx)
+		}
+	}
+	e := echo.New()
+	e.GET("/sse", h.SSE, authenticated)
+	e.GET("/ws", h.WebSocket, authenticated)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func openSSE(t *testing.T, server *httptest.Server) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/sse", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /sse: %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	return bufio.NewReader(res.Body)
+}
+
+func TestSSEForwardsEventsAndClosesOnRevocation(t *testing.T) {
+	subscriber := newFakeSubscriber()
+	body := openSSE(t, newStreamServer(t, subscriber, time.Hour))
+	if userID := <-subscriber.subscribed; userID != 1 {
+		t.Fatalf("subscribed user %d, want 1", userID)
+	}
+
+	subscriber.events <- event.Event{ID: 7, Type: event.TypeTodoCreated, UserID: 1}
+	subscriber.events <- event.Event{Type: event.TypeUserTokensRevoked, UserID: 1}
+	rest, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("stream was not closed after revocation: %v", err)
+	}
+	if !strings.Contains(string(rest), "id: 7\nevent: todo.created\n") || !strings.Contains(string(rest), "event: user.tokens_revoked\n") {
+		t.Errorf("unexpected stream %q", rest)
+	}
+}
+
+func TestSSEClosesWhenTokenExpires(t *testing.T) {
+	subscriber := newFakeSubscriber()
+	body := openSSE(t, newStreamServer(t, subscriber, 100*time.Millisecond))
+
+	if _, err := io.ReadAll(body); err != nil {
+		t.Fatalf("stream was not closed at token expiry: %v", err)
+	}
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "", server.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+	return ws
+}
+
+func TestWebSocketClosesOnRoleChange(t *testing.T) {
+	subscriber := newFakeSubscriber()
+	ws := dialWebSocket(t, newStreamServer(t, subscriber, time.Hour))
+	<-subscriber.subscribed
+
+	subscriber.events <- event.Event{ID: 7, Type: event.TypeTodoUpdated, UserID: 1}
+	subscriber.events <- event.Event{Type: event.TypeUserRoleChanged, UserID: 1}
+	for _, want := range []string{event.TypeTodoUpdated, event.TypeUserRoleChanged} {
+		var e event.Event
+		if err := websocket.JSON.Receive(ws, &e); err != nil || e.Type != want {
+			t.Fatalf("received %q, %v; want %s", e.Type, err, want)
+		}
+	}
+	var discard []byte
+	if err := websocket.Message.Receive(ws, &discard); err == nil {
+		t.Error("connection still open after role change")
+	}
+}
+
+func TestWebSocketClosesWhenTokenExpires(t *testing.T) {
+	subscriber := newFakeSubscriber()
+	ws := dialWebSocket(t, newStreamServer(t, subscriber, 100*time.Millisecond))
+
+	var discard []byte
+	if err := websocket.Message.Receive(ws, &discard); err != io.EOF {
+		t.Errorf("Receive = %v, want EOF at token expiry", err)
+	}
+}
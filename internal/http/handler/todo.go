@@ -1,12 +1,18 @@
 package handler
 
 import (
-	"context"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"todo-list/internal/entity"
 	"todo-list/internal/service"
+	"todo-list/pkg/jsonpatch"
+	"todo-list/pkg/rank"
 	"todo-list/pkg/response"
 
 	"github.com/labstack/echo/v4"
@@ -20,19 +26,31 @@ func NewTodoHandler(todoService service.TodoService) *TodoHandler {
 	return &TodoHandler{todoService}
 }
 
+func isValidationError(err error) bool {
+	return errors.Is(err, service.ErrTitleTooLong) ||
+		errors.Is(err, service.ErrDescriptionTooLong) ||
+		errors.Is(err, service.ErrInvalidPriority) ||
+		errors.Is(err, service.ErrInvalidProject) ||
+		errors.Is(err, service.ErrInvalidTag)
+}
+
 func (h *TodoHandler) CreateTodoAsAdmin(ctx echo.Context) error {
-	userID, ok := ctx.Get("user_id").(uint)
-	if !ok {
-		return ctx.JSON(http.StatusUnauthorized,response.ErrorResponse( http.StatusUnauthorized,fmt.Sprintf("Invalid or missing userID: %d " ,userID) ))
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
 	}
 	var req struct {
-		Title string `json:"title"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
 	}
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
-	todo, err := h.todoService.CreateTodo(ctx.Request().Context(), uint(userID), req.Title)
+	todo, err := h.todoService.CreateTodo(ctx.Request().Context(), uint(userID), req.Title, req.Description)
 	if err != nil {
+		if isValidationError(err) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
 		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
 	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo created successfully", todo))
@@ -44,13 +62,17 @@ func (h *TodoHandler) CreateTodoHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusUnauthorized,response.ErrorResponse( http.StatusUnauthorized,fmt.Sprintf("Invalid or missing userID: %d " ,userID) ))
 	}
 	var req struct {
-		Title string `json:"title"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
 	}
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
-	todo, err := h.todoService.CreateTodo(ctx.Request().Context(), userID, req.Title)
+	todo, err := h.todoService.CreateTodo(ctx.Request().Context(), userID, req.Title, req.Description)
 	if err != nil {
+		if isValidationError(err) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
 		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
 	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo created successfully", todo))
@@ -63,7 +85,7 @@ func (h *TodoHandler) GetAllHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusInternalServerError,
 			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
-	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch all todos", todos))
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch all todos", todos))
 
 }
 
@@ -77,7 +99,7 @@ func (h *TodoHandler) GetTodosByUserIdAsAdmin(ctx echo.Context) error {
 		return ctx.JSON(http.StatusInternalServerError,
 			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
-	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch all todos", todos))
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch all todos", todos))
 
 }
 
@@ -92,7 +114,7 @@ func (h *TodoHandler) GetTodosHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusInternalServerError,
 			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
-	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch all todos", todos))
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch all todos", todos))
 
 }
 
@@ -114,9 +136,19 @@ func (h *TodoHandler) UpdateTodoAsAdmin(ctx echo.Context) error {
 	if err := ctx.Bind(req); err != nil {
 		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
 
-	err = h.todoService.UpdateTodo(context.Background(), req.UserID, req.ID, req.Title, req.Done)
+	err = h.todoService.UpdateTodo(ctx.Request().Context(), req.UserID, req.ID, version, req.Title, req.Description, req.Done)
 	if err != nil {
+		if isValidationError(err) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
 		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
 
@@ -135,15 +167,26 @@ func (h *TodoHandler) UpdateTodoHandler(ctx echo.Context) error {
 	}
 
 	var req struct {
-		Title string `json:"title"`
-		Done  bool   `json:"done"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Done        bool   `json:"done"`
 	}
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
 
-	err = h.todoService.UpdateTodo(context.Background(), userID, uint(todoID), req.Title, req.Done)
+	err = h.todoService.UpdateTodo(ctx.Request().Context(), userID, uint(todoID), version, req.Title, req.Description, req.Done)
 	if err != nil {
+		if isValidationError(err) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
 		if err.Error() == "unauthorized or not found" {
 			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
 		}
@@ -164,8 +207,16 @@ func (h *TodoHandler) DeleteTodoAsAdmin(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
 	}
 
-	err = h.todoService.DeleteTodo(ctx.Request().Context(), uint(userID), uint(todoID))
+	version, err := ifMatchVersion(ctx)
 	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	err = h.todoService.DeleteTodo(ctx.Request().Context(), uint(userID), uint(todoID), version)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
 		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
 
@@ -189,8 +240,16 @@ func (h *TodoHandler) DeleteTodoHandler(ctx echo.Context) error {
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
-	err = h.todoService.DeleteTodo(ctx.Request().Context(), userID, req.TodoID)
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	err = h.todoService.DeleteTodo(ctx.Request().Context(), userID, req.TodoID, version)
 	if err != nil {
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
 		if err.Error() == "unauthorized or not found" {
 			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
 		}
@@ -199,3 +258,434 @@ func (h *TodoHandler) DeleteTodoHandler(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo deleted successfully", nil))
 }
+
+func (h *TodoHandler) GetTrashHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todos, err := h.todoService.GetTrash(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError,
+			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch trashed todos", todos))
+}
+
+func (h *TodoHandler) GetTrashAsAdmin(ctx echo.Context) error {
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	todos, err := h.todoService.GetTrash(ctx.Request().Context(), uint(userID))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError,
+			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch trashed todos", todos))
+}
+
+func (h *TodoHandler) RestoreTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	return h.restoreTodo(ctx, userID, uint(todoID))
+}
+
+func (h *TodoHandler) RestoreTodoAsAdmin(ctx echo.Context) error {
+	todoID, err := strconv.ParseUint(ctx.Param("todo_id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.restoreTodo(ctx, uint(userID), uint(todoID))
+}
+
+func (h *TodoHandler) restoreTodo(ctx echo.Context, userID, todoID uint) error {
+	err := h.todoService.RestoreTodo(ctx.Request().Context(), userID, todoID)
+	if err != nil {
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo restored successfully", nil))
+}
+
+func (h *TodoHandler) PurgeTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	return h.purgeTodo(ctx, userID, uint(todoID))
+}
+
+func (h *TodoHandler) PurgeTodoAsAdmin(ctx echo.Context) error {
+	todoID, err := strconv.ParseUint(ctx.Param("todo_id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.purgeTodo(ctx, uint(userID), uint(todoID))
+}
+
+func (h *TodoHandler) purgeTodo(ctx echo.Context, userID, todoID uint) error {
+	err := h.todoService.PurgeTodo(ctx.Request().Context(), userID, todoID)
+	if err != nil {
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo permanently deleted", nil))
+}
+
+func (h *TodoHandler) GetArchiveHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todos, err := h.todoService.GetArchive(ctx.Request().Context(), userID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError,
+			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch archived todos", todos))
+}
+
+func (h *TodoHandler) ArchiveTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	err = h.todoService.ArchiveTodo(ctx.Request().Context(), userID, uint(todoID), version)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo archived successfully", nil))
+}
+
+func (h *TodoHandler) UnarchiveTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	err = h.todoService.UnarchiveTodo(ctx.Request().Context(), userID, uint(todoID), version)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo unarchived successfully", nil))
+}
+
+func (h *TodoHandler) MoveTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	var req struct {
+		BeforeID *uint `json:"before_id"`
+		AfterID  *uint `json:"after_id"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	todo, err := h.todoService.MoveTodo(ctx.Request().Context(), userID, uint(todoID), req.BeforeID, req.AfterID)
+	if err != nil {
+		if errors.Is(err, service.ErrMissingAnchor) || errors.Is(err, rank.ErrInvalidRange) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo moved successfully", todo))
+}
+
+func (h *TodoHandler) GetTodoHistoryHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	return h.getTodoHistory(ctx, userID, uint(todoID))
+}
+
+func (h *TodoHandler) GetTodoHistoryAsAdmin(ctx echo.Context) error {
+	todoID, err := strconv.ParseUint(ctx.Param("todo_id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.getTodoHistory(ctx, uint(userID), uint(todoID))
+}
+
+func (h *TodoHandler) getTodoHistory(ctx echo.Context, userID, todoID uint) error {
+	events, err := h.todoService.GetTodoHistory(ctx.Request().Context(), userID, todoID)
+	if err != nil {
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch todo history", events))
+}
+
+func (h *TodoHandler) RevertTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	return h.revertTodo(ctx, userID, uint(todoID))
+}
+
+func (h *TodoHandler) RevertTodoAsAdmin(ctx echo.Context) error {
+	todoID, err := strconv.ParseUint(ctx.Param("todo_id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.revertTodo(ctx, uint(userID), uint(todoID))
+}
+
+func (h *TodoHandler) revertTodo(ctx echo.Context, userID, todoID uint) error {
+	var req struct {
+		EventID uint `json:"event_id"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	todo, err := h.todoService.RevertTodo(ctx.Request().Context(), userID, todoID, version, req.EventID)
+	if err != nil {
+		if errors.Is(err, service.ErrEventNotFound) {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo reverted successfully", todo))
+}
+
+func (h *TodoHandler) BulkHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req struct {
+		Action  string `json:"action"`
+		IDs     []uint `json:"ids"`
+		Project string `json:"project"`
+		Tag     string `json:"tag"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	args := service.BulkArgs{Project: req.Project, Tag: req.Tag}
+	results, err := h.todoService.BulkUpdate(ctx.Request().Context(), userID, req.Action, req.IDs, args)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBulkUnknownAction),
+			errors.Is(err, service.ErrBulkEmpty),
+			errors.Is(err, service.ErrBulkTooMany),
+			isValidationError(err):
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("bulk action processed", results))
+}
+
+func (h *TodoHandler) PatchTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	return h.patchTodo(ctx, userID, uint(todoID))
+}
+
+func (h *TodoHandler) PatchTodoAsAdmin(ctx echo.Context) error {
+	todoID, err := strconv.ParseUint(ctx.Param("todo_id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.patchTodo(ctx, uint(userID), uint(todoID))
+}
+
+// patchTodo menerima JSON Merge Patch (RFC 7396) atau JSON Patch (RFC 6902)
+// dan hanya meneruskan field yang benar-benar berubah ke service.
+func (h *TodoHandler) patchTodo(ctx echo.Context, userID, todoID uint) error {
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	todo, err := h.todoService.GetTodo(ctx.Request().Context(), userID, todoID)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+	}
+	doc, err := json.Marshal(entity.NewTodoPatch(todo))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+
+	var patched []byte
+	mediaType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case jsonpatch.JSONPatchContentType:
+		patched, err = jsonpatch.Apply(doc, body)
+	case jsonpatch.MergePatchContentType, echo.MIMEApplicationJSON:
+		patched, err = jsonpatch.MergePatch(doc, body)
+	default:
+		return ctx.JSON(http.StatusUnsupportedMediaType, response.ErrorResponse(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("use %s or %s", jsonpatch.MergePatchContentType, jsonpatch.JSONPatchContentType)))
+	}
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, err.Error()))
+		}
+		return ctx.JSON(http.StatusUnprocessableEntity, response.ErrorResponse(http.StatusUnprocessableEntity, err.Error()))
+	}
+
+	var result entity.TodoPatch
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return ctx.JSON(http.StatusUnprocessableEntity, response.ErrorResponse(http.StatusUnprocessableEntity, err.Error()))
+	}
+	// field yang dihapus dianggap kosong, kecuali field wajib
+	if result.Title == nil || result.Done == nil {
+		return ctx.JSON(http.StatusUnprocessableEntity, response.ErrorResponse(http.StatusUnprocessableEntity, "title and done must not be null or removed"))
+	}
+
+	todo, err = h.todoService.PatchTodo(ctx.Request().Context(), userID, todoID, version, result.Changes(todo))
+	if err != nil {
+		if isValidationError(err) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		if err.Error() == "unauthorized or not found" {
+			return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	ctx.Response().Header().Set("ETag", versionETag(todo.Version))
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todo updated successfully", todo))
+}
+
+func (h *TodoHandler) GetTodoHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	todoID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	return h.getTodo(ctx, userID, uint(todoID))
+}
+
+func (h *TodoHandler) GetTodoAsAdmin(ctx echo.Context) error {
+	todoID, err := strconv.ParseUint(ctx.Param("todo_id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid todo ID"))
+	}
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.getTodo(ctx, uint(userID), uint(todoID))
+}
+
+func (h *TodoHandler) getTodo(ctx echo.Context, userID, todoID uint) error {
+	todo, err := h.todoService.GetTodo(ctx.Request().Context(), userID, todoID)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, err.Error()))
+	}
+	return jsonWithETag(ctx, versionETag(todo.Version), response.SuccessResponse("successfully fetch todo", todo))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/todofmt"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxImportSize = 10 << 20
+
+func (h *TodoHandler) ExportTodosHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	return h.exportTodos(ctx, userID)
+}
+
+func (h *TodoHandler) ExportTodosAsAdmin(ctx echo.Context) error {
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.exportTodos(ctx, uint(userID))
+}
+
+// exportTodos menulis todo langsung ke response per batch.
+func (h *TodoHandler) exportTodos(ctx echo.Context, userID uint) error {
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = todofmt.FormatJSON
+	}
+	res := ctx.Response()
+	encoder, err := todofmt.NewEncoder(format, res)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	filename := fmt.Sprintf("todos-%d-%s.%s", userID, time.Now().Format("20060102"), todofmt.Extension(format))
+	res.Header().Set(echo.HeaderContentType, todofmt.ContentType(format))
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	err = h.todoService.ExportTodos(ctx.Request().Context(), userID, func(todo *entity.Todo) error {
+		if err := encoder.Encode(todo); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		ctx.Logger().Errorf("export todos of user %d: %v", userID, err)
+		if !res.Committed {
+			res.Header().Del(echo.HeaderContentDisposition)
+			return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+		}
+		abortResponse()
+	}
+	return nil
+}
+
+// abortResponse memutus koneksi ketika error terjadi setelah status 200
+// terkirim, sehingga client melihat body yang terpotong sebagai error dan
+// bukan sebagai file yang lengkap.
+func abortResponse() {
+	panic(http.ErrAbortHandler)
+}
+
+func (h *TodoHandler) ImportTodosHandler(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	return h.importTodos(ctx, userID)
+}
+
+func (h *TodoHandler) ImportTodosAsAdmin(ctx echo.Context) error {
+	userID, err := strconv.ParseUint(ctx.Param("userID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	return h.importTodos(ctx, uint(userID))
+}
+
+// importTodos menerima file lewat multipart (field "file") atau langsung
+// sebagai body. mode=dry-run (default) hanya melaporkan hasil tanpa
+// menyimpan, mode=commit menyimpan todo yang valid.
+func (h *TodoHandler) importTodos(ctx echo.Context, userID uint) error {
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = todofmt.FormatJSON
+	}
+	var commit bool
+	switch ctx.QueryParam("mode") {
+	case "", "dry-run":
+	case "commit":
+		commit = true
+	default:
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "mode must be dry-run or commit"))
+	}
+
+	body, err := importBody(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	defer body.Close()
+
+	records, err := todofmt.Decode(format, body)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	report, err := h.todoService.ImportTodos(ctx.Request().Context(), userID, records, commit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("todos import processed", report))
+}
+
+func importBody(ctx echo.Context) (io.ReadCloser, error) {
+	req := ctx.Request()
+	req.Body = http.MaxBytesReader(ctx.Response(), req.Body, maxImportSize)
+	if !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return req.Body, nil
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, errors.New("multipart import requires a file field")
+	}
+	return file.Open()
+}
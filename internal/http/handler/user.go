@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"log"
+	"strconv"
 	"todo-list/internal/entity"
 	"todo-list/internal/service"
 	"todo-list/pkg/response"
@@ -10,11 +13,12 @@ import (
 )
 
 type UserHandler struct {
-	userService service.UserService
+	userService    service.UserService
+	accountService service.AccountService
 }
 
-func NewUserHandler(userService service.UserService) UserHandler {
-	return UserHandler{userService}
+func NewUserHandler(userService service.UserService, accountService service.AccountService) UserHandler {
+	return UserHandler{userService, accountService}
 }
 
 func (h *UserHandler) FindAll(ctx echo.Context) error {
@@ -23,7 +27,7 @@ func (h *UserHandler) FindAll(ctx echo.Context) error {
 		return ctx.JSON(http.StatusInternalServerError,
 			response.ErrorResponse(http.StatusInternalServerError, err.Error()))
 	}
-	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch all users", users))
+	return jsonWithContentETag(ctx, response.SuccessResponse("successfully fetch all users", users))
 }
 
 // Handler untuk registrasi
@@ -37,6 +41,14 @@ func (h *UserHandler) Register(ctx echo.Context) error {
         return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, err.Error()))
     }
 
+	// kegagalan mengirim email verifikasi tidak membatalkan registrasi,
+	// user bisa meminta ulang lewat POST /email/verification
+	if req.Email != "" {
+		if err := h.accountService.SendEmailVerification(ctx.Request().Context(), req.ID); err != nil && !errors.Is(err, service.ErrEmailUnavailable) {
+			log.Printf("failed to send email verification to user %d: %v", req.ID, err)
+		}
+	}
+
     return ctx.JSON(http.StatusCreated, response.SuccessResponse("user created successfully", map[string]interface{}{
 		"user": req,
 	}))
@@ -55,12 +67,47 @@ func (h *UserHandler) Login(ctx echo.Context) error {
 			response.ErrorResponse(http.StatusBadRequest, err.Error()))
 	}
 
-	token, err := h.userService.Login(ctx.Request().Context(), loginRequest.Username, loginRequest.Password)
+	result, err := h.userService.Login(ctx.Request().Context(), loginRequest.Username, loginRequest.Password)
 	if err != nil {
 		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, err.Error()))
 	}
+	if result.MFARequired {
+		return ctx.JSON(http.StatusOK, response.SuccessResponse("two-factor authentication required", result))
+	}
 
 	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully login", map[string]interface{}{
-		"token": token,
+		"token": result.Token,
 	}))
 }
+
+func (h *UserHandler) UpdateRole(ctx echo.Context) error {
+	userID, err := strconv.ParseInt(ctx.Param("userID"), 10, 64)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid user ID"))
+	}
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	if err := h.userService.UpdateRole(ctx.Request().Context(), userID, version, req.Role); err != nil {
+		if errors.Is(err, service.ErrInvalidRole) {
+			return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+		}
+		if isPreconditionFailed(err) {
+			return ctx.JSON(http.StatusPreconditionFailed, response.ErrorResponse(http.StatusPreconditionFailed, err.Error()))
+		}
+		if err.Error() == "user not found" {
+			return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+		}
+		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("user role updated successfully", nil))
+}
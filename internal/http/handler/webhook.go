@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todo-list/internal/service"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) WebhookHandler {
+	return WebhookHandler{webhookService}
+}
+
+// webhookOwner mengembalikan user yang login dan apakah webhook-nya global
+// (dibuat oleh admin).
+func webhookOwner(ctx echo.Context) (uint, bool, bool) {
+	a, ok := actor.FromContext(ctx.Request().Context())
+	if !ok || a.UserID == 0 {
+		return 0, false, false
+	}
+	return a.UserID, a.Role == "admin", true
+}
+
+func webhookError(ctx echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		return ctx.JSON(http.StatusNotFound, response.ErrorResponse(http.StatusNotFound, err.Error()))
+	case errors.Is(err, service.ErrInvalidWebhookURL), errors.Is(err, service.ErrInvalidWebhookEvent):
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+	return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+}
+
+func (h *WebhookHandler) Create(ctx echo.Context) error {
+	ownerID, global, ok := webhookOwner(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+	}
+
+	hook, secret, err := h.webhookService.CreateWebhook(ctx.Request().Context(), ownerID, global, req.URL, req.Events)
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(http.StatusCreated, response.SuccessResponse("webhook created, the secret will not be shown again", map[string]interface{}{
+		"webhook": hook,
+		"secret":  secret,
+	}))
+}
+
+func (h *WebhookHandler) FindAll(ctx echo.Context) error {
+	ownerID, _, ok := webhookOwner(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	hooks, err := h.webhookService.GetWebhooks(ctx.Request().Context(), ownerID)
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch webhooks", hooks))
+}
+
+func (h *WebhookHandler) Delete(ctx echo.Context) error {
+	ownerID, _, ok := webhookOwner(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid webhook ID"))
+	}
+	if err := h.webhookService.DeleteWebhook(ctx.Request().Context(), ownerID, uint(id)); err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("webhook deleted successfully", nil))
+}
+
+func (h *WebhookHandler) Deliveries(ctx echo.Context) error {
+	ownerID, _, ok := webhookOwner(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid webhook ID"))
+	}
+	deliveries, err := h.webhookService.GetDeliveries(ctx.Request().Context(), ownerID, uint(id))
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response.SuccessResponse("successfully fetch webhook deliveries", deliveries))
+}
+
+func (h *WebhookHandler) Redeliver(ctx echo.Context) error {
+	ownerID, _, ok := webhookOwner(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "Invalid or missing userID"))
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid webhook ID"))
+	}
+	deliveryID, err := strconv.ParseUint(ctx.Param("deliveryID"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Invalid delivery ID"))
+	}
+	delivery, err := h.webhookService.Redeliver(ctx.Request().Context(), ownerID, uint(id), uint(deliveryID))
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(http.StatusAccepted, response.SuccessResponse("webhook delivery scheduled", delivery))
+}
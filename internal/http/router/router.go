@@ -4,84 +4,613 @@ import (
 	"todo-list/internal/http/handler"
 	"todo-list/pkg/route"
 	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
 )
 
-func PublicRoutes(userHandler handler.UserHandler) []route.Route {
-	return []route.Route{
+func PublicRoutes(userHandler handler.UserHandler, feedHandler handler.FeedHandler, caldavHandler handler.CalDAVHandler, digestHandler handler.DigestHandler, accountHandler handler.AccountHandler, mfaHandler handler.MFAHandler, oidcHandler handler.OIDCHandler) []route.Route {
+	return append(caldavRoutes(caldavHandler), []route.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/feeds/:token/todos.ics",
+			Handler: feedHandler.Feed,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/digest/unsubscribe",
+			Handler: digestHandler.UnsubscribeByToken,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/digest/unsubscribe",
+			Handler: digestHandler.UnsubscribeByToken,
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/password/forgot",
+			Handler:   accountHandler.ForgotPassword,
+			RateLimit: &route.RateLimit{Requests: 5, Window: 15 * time.Minute},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/password/reset",
+			Handler:   accountHandler.ResetPassword,
+			RateLimit: &route.RateLimit{Requests: 10, Window: 15 * time.Minute},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/email/verify",
+			Handler:   accountHandler.VerifyEmail,
+			RateLimit: &route.RateLimit{Requests: 20, Window: 15 * time.Minute},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/email/verify",
+			Handler:   accountHandler.VerifyEmail,
+			RateLimit: &route.RateLimit{Requests: 20, Window: 15 * time.Minute},
+		},
 		{
 			Method:  http.MethodPost,
 			Path:    "/login",
 			Handler: userHandler.Login,
 		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/oidc/login",
+			Handler:   oidcHandler.Login,
+			RateLimit: &route.RateLimit{Requests: 30, Window: 5 * time.Minute},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/oidc/callback",
+			Handler:   oidcHandler.Callback,
+			RateLimit: &route.RateLimit{Requests: 30, Window: 5 * time.Minute},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/login/mfa",
+			Handler:   mfaHandler.VerifyLogin,
+			RateLimit: &route.RateLimit{Requests: 10, Window: 5 * time.Minute},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/login/mfa/enroll",
+			Handler:   mfaHandler.EnrollLogin,
+			RateLimit: &route.RateLimit{Requests: 10, Window: 5 * time.Minute},
+		},
 		{
 			Method:  http.MethodPost,
 			Path:    "/register",
 			Handler: userHandler.Register,
 		},
-	}
+	}...)
 }
 
-func PrivateRoutes(userHandler handler.UserHandler, todosHandler handler.TodoHandler) []route.Route {
+func PrivateRoutes(userHandler handler.UserHandler, todosHandler handler.TodoHandler, auditHandler handler.AuditHandler, feedHandler handler.FeedHandler, appPasswordHandler handler.AppPasswordHandler, streamHandler handler.StreamHandler, webhookHandler handler.WebhookHandler, jobHandler handler.JobHandler, reminderHandler handler.ReminderHandler, notificationHandler handler.NotificationHandler, digestHandler handler.DigestHandler, accountHandler handler.AccountHandler, mfaHandler handler.MFAHandler, tokenHandler handler.PersonalAccessTokenHandler) []route.Route {
 	return []route.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/webhooks",
+			Handler: webhookHandler.Create,
+			Roles:   []string{"user", "admin"},
+			Scopes:  []string{"webhooks:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/webhooks",
+			Handler: webhookHandler.FindAll,
+			Roles:   []string{"user", "admin"},
+			Scopes:  []string{"webhooks:read"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/webhooks/:id",
+			Handler: webhookHandler.Delete,
+			Roles:   []string{"user", "admin"},
+			Scopes:  []string{"webhooks:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/webhooks/:id/deliveries",
+			Handler: webhookHandler.Deliveries,
+			Roles:   []string{"user", "admin"},
+			Scopes:  []string{"webhooks:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/webhooks/:id/deliveries/:deliveryID/redeliver",
+			Handler: webhookHandler.Redeliver,
+			Roles:   []string{"user", "admin"},
+			Scopes:  []string{"webhooks:write"},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/todos/stream",
+			Handler:    streamHandler.SSE,
+			Roles:      []string{"user"},
+			Scopes:     []string{"todos:read"},
+			QueryToken: true,
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/todos/ws",
+			Handler:    streamHandler.WebSocket,
+			Roles:      []string{"user"},
+			Scopes:     []string{"todos:read"},
+			QueryToken: true,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/app-passwords",
+			Handler: appPasswordHandler.Create,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/app-passwords",
+			Handler: appPasswordHandler.FindAll,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/app-passwords/:id",
+			Handler: appPasswordHandler.Revoke,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/tokens",
+			Handler: tokenHandler.Create,
+			Roles:   []string{"user", "admin"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/tokens",
+			Handler: tokenHandler.FindAll,
+			Roles:   []string{"user", "admin"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/tokens/:id",
+			Handler: tokenHandler.Revoke,
+			Roles:   []string{"user", "admin"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/feed-token",
+			Handler: feedHandler.CreateFeedToken,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/todos/feed-token",
+			Handler: feedHandler.RevokeFeedToken,
+			Roles:   []string{"user"},
+		},
 		{
 			Method:  http.MethodGet,
 			Path:    "/users",
 			Handler: userHandler.FindAll,
 			Roles:   []string{"admin"},
+			Scopes:  []string{"admin:read"},
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/admin/users/:userID/role",
+			Handler: userHandler.UpdateRole,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"admin:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/audit-logs",
+			Handler: auditHandler.FindAll,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"admin:read"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/audit-logs/verify",
+			Handler: auditHandler.Verify,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"admin:read"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/users/me/2fa",
+			Handler: mfaHandler.Status,
+			Roles:   []string{"user", "admin"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/me/2fa",
+			Handler: mfaHandler.Enroll,
+			Roles:   []string{"user", "admin"},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/users/me/2fa/confirm",
+			Handler:   mfaHandler.Confirm,
+			Roles:     []string{"user", "admin"},
+			RateLimit: &route.RateLimit{Requests: 10, Window: 5 * time.Minute},
+		},
+		{
+			Method:    http.MethodDelete,
+			Path:      "/users/me/2fa",
+			Handler:   mfaHandler.Disable,
+			Roles:     []string{"user", "admin"},
+			RateLimit: &route.RateLimit{Requests: 10, Window: 5 * time.Minute},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/users/me/2fa/recovery-codes",
+			Handler:   mfaHandler.RegenerateRecoveryCodes,
+			Roles:     []string{"user", "admin"},
+			RateLimit: &route.RateLimit{Requests: 10, Window: 5 * time.Minute},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/security/2fa",
+			Handler: mfaHandler.GetPolicy,
+			Roles:   []string{"admin"},
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/admin/security/2fa",
+			Handler: mfaHandler.UpdatePolicy,
+			Roles:   []string{"admin"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/jobs",
+			Handler: jobHandler.FindAll,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"admin:read"},
 		},
 		{
 			Method:  http.MethodPost,
 			Path:    "/admin/user/:userID/todos",
 			Handler: todosHandler.CreateTodoAsAdmin,
 			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
 		},
 		{
 			Method:  http.MethodPost,
 			Path:    "/todos",
 			Handler: todosHandler.CreateTodoHandler,
 			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
 		},
 		{
 			Method:  http.MethodGet,
 			Path:    "/admin/todos",
 			Handler: todosHandler.GetAllHandler,
 			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:read", "admin:read"},
 		},
 		{
 			Method:  http.MethodGet,
 			Path:    "/admin/todos/:userID",
 			Handler: todosHandler.GetTodosByUserIdAsAdmin,
 			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:read", "admin:read"},
 		},
 		{
 			Method:  http.MethodGet,
 			Path:    "/todos",
 			Handler: todosHandler.GetTodosHandler,
 			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
 		},
 		{
 			Method:  http.MethodPut,
 			Path:    "/admin/user/:userID/todos/:todo_id",
 			Handler: todosHandler.UpdateTodoAsAdmin,
 			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
 		},
 		{
 			Method:  http.MethodPut,
 			Path:    "/todos/:id",
 			Handler: todosHandler.UpdateTodoHandler,
 			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
 		},
 		{
 			Method:  http.MethodDelete,
 			Path:    "/admin/user/:userID/todos/:todo_id",
 			Handler: todosHandler.DeleteTodoAsAdmin,
 			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
 		},
 		{
 			Method:  http.MethodDelete,
 			Path:    "/todos/:id",
 			Handler: todosHandler.DeleteTodoHandler,
 			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/todos/trash",
+			Handler: todosHandler.GetTrashHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/:id/restore",
+			Handler: todosHandler.RestoreTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/todos/:id/purge",
+			Handler: todosHandler.PurgeTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/user/:userID/todos/trash",
+			Handler: todosHandler.GetTrashAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:read", "admin:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/user/:userID/todos/:todo_id/restore",
+			Handler: todosHandler.RestoreTodoAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/admin/user/:userID/todos/:todo_id/purge",
+			Handler: todosHandler.PurgeTodoAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/todos/archive",
+			Handler: todosHandler.GetArchiveHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/:id/archive",
+			Handler: todosHandler.ArchiveTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/:id/unarchive",
+			Handler: todosHandler.UnarchiveTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/:id/move",
+			Handler: todosHandler.MoveTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/todos/:id/history",
+			Handler: todosHandler.GetTodoHistoryHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/:id/revert",
+			Handler: todosHandler.RevertTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/:id/reminders",
+			Handler: reminderHandler.Create,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
 		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/todos/:id/reminders",
+			Handler: reminderHandler.FindAll,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/todos/:id/reminders/:reminderID",
+			Handler: reminderHandler.Delete,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/notifications",
+			Handler: notificationHandler.FindAll,
+			Roles:   []string{"user"},
+			Scopes:  []string{"notifications:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/notifications/read-all",
+			Handler: notificationHandler.MarkAllRead,
+			Roles:   []string{"user"},
+			Scopes:  []string{"notifications:write"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/notifications/:id/read",
+			Handler: notificationHandler.MarkRead,
+			Roles:   []string{"user"},
+			Scopes:  []string{"notifications:write"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/notifications/:id/unread",
+			Handler: notificationHandler.MarkUnread,
+			Roles:   []string{"user"},
+			Scopes:  []string{"notifications:write"},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/email/verification",
+			Handler:   accountHandler.ResendVerification,
+			Roles:     []string{"user"},
+			RateLimit: &route.RateLimit{Requests: 5, Window: time.Hour},
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/users/me/email",
+			Handler: accountHandler.ChangeEmail,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/digest",
+			Handler: digestHandler.Get,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/digest",
+			Handler: digestHandler.Subscribe,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/digest",
+			Handler: digestHandler.Unsubscribe,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/digest/preview",
+			Handler: digestHandler.Preview,
+			Roles:   []string{"user"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/user/:userID/todos/:todo_id/history",
+			Handler: todosHandler.GetTodoHistoryAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:read", "admin:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/user/:userID/todos/:todo_id/revert",
+			Handler: todosHandler.RevertTodoAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/bulk",
+			Handler: todosHandler.BulkHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodPatch,
+			Path:    "/todos/:id",
+			Handler: todosHandler.PatchTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodPatch,
+			Path:    "/admin/user/:userID/todos/:todo_id",
+			Handler: todosHandler.PatchTodoAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/todos/:id",
+			Handler: todosHandler.GetTodoHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/user/:userID/todos/:todo_id",
+			Handler: todosHandler.GetTodoAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:read", "admin:read"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/todos/export",
+			Handler: todosHandler.ExportTodosHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/todos/import",
+			Handler: todosHandler.ImportTodosHandler,
+			Roles:   []string{"user"},
+			Scopes:  []string{"todos:write"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/user/:userID/todos/export",
+			Handler: todosHandler.ExportTodosAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:read", "admin:read"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/user/:userID/todos/import",
+			Handler: todosHandler.ImportTodosAsAdmin,
+			Roles:   []string{"admin"},
+			Scopes:  []string{"todos:write", "admin:write"},
+		},
+	}
+}
+
+// caldavRoutes didaftarkan sebagai route public karena autentikasinya
+// memakai Basic auth dengan app password, bukan JWT. Collection didaftarkan
+// dengan dan tanpa trailing slash karena client CalDAV berbeda-beda.
+func caldavRoutes(caldavHandler handler.CalDAVHandler) []route.Route {
+	auth := []echo.MiddlewareFunc{caldavHandler.Authenticate}
+	collections := map[string]echo.HandlerFunc{
+		"/caldav":                           caldavHandler.PropfindRoot,
+		"/caldav/principals/:username":      caldavHandler.PropfindPrincipal,
+		"/caldav/calendars/:username":       caldavHandler.PropfindHome,
+		"/caldav/calendars/:username/todos": caldavHandler.PropfindCollection,
+	}
+
+	var routes []route.Route
+	for path, propfind := range collections {
+		for _, p := range []string{path, path + "/"} {
+			routes = append(routes,
+				route.Route{Method: "PROPFIND", Path: p, Handler: propfind, Middlewares: auth},
+				route.Route{Method: http.MethodOptions, Path: p, Handler: caldavHandler.Options, Middlewares: auth},
+			)
+			if path == "/caldav/calendars/:username/todos" {
+				routes = append(routes, route.Route{Method: "REPORT", Path: p, Handler: caldavHandler.Report, Middlewares: auth})
+			}
+		}
 	}
+
+	object := "/caldav/calendars/:username/todos/:name"
+	return append(routes,
+		route.Route{Method: http.MethodOptions, Path: object, Handler: caldavHandler.Options, Middlewares: auth},
+		route.Route{Method: http.MethodGet, Path: object, Handler: caldavHandler.GetObject, Middlewares: auth},
+		route.Route{Method: http.MethodPut, Path: object, Handler: caldavHandler.PutObject, Middlewares: auth},
+		route.Route{Method: http.MethodDelete, Path: object, Handler: caldavHandler.DeleteObject, Middlewares: auth},
+	)
 }
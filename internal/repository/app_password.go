@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type AppPasswordRepository interface {
+	Create(ctx context.Context, password *entity.AppPassword) error
+	GetByUserID(ctx context.Context, userID uint) ([]entity.AppPassword, error)
+	FindActiveByHash(ctx context.Context, hash string) (*entity.AppPassword, error)
+	Revoke(ctx context.Context, userID, id uint) (int64, error)
+	RevokeByUserID(ctx context.Context, userID uint) error
+	TouchLastUsed(ctx context.Context, id uint) error
+}
+
+type appPasswordRepository struct {
+	db *gorm.DB
+}
+
+func NewAppPasswordRepository(db *gorm.DB) AppPasswordRepository {
+	return &appPasswordRepository{db}
+}
+
+func (r *appPasswordRepository) Create(ctx context.Context, password *entity.AppPassword) error {
+	return conn(ctx, r.db).Create(password).Error
+}
+
+func (r *appPasswordRepository) GetByUserID(ctx context.Context, userID uint) ([]entity.AppPassword, error) {
+	passwords := make([]entity.AppPassword, 0)
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id").
+		Find(&passwords).Error; err != nil {
+		return nil, err
+	}
+	return passwords, nil
+}
+
+func (r *appPasswordRepository) FindActiveByHash(ctx context.Context, hash string) (*entity.AppPassword, error) {
+	password := new(entity.AppPassword)
+	if err := conn(ctx, r.db).
+		Where("password_hash = ? AND revoked_at IS NULL", hash).
+		First(password).Error; err != nil {
+		return nil, err
+	}
+	return password, nil
+}
+
+func (r *appPasswordRepository) Revoke(ctx context.Context, userID, id uint) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.AppPassword{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *appPasswordRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).
+		Model(&entity.AppPassword{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *appPasswordRepository) TouchLastUsed(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).
+		Model(&entity.AppPassword{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now()).Error
+}
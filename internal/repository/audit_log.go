@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// auditLockKey dipakai sebagai pg_advisory_xact_lock agar penambahan audit
+// log berjalan berurutan dan hash chain tidak bercabang.
+const auditLockKey = 7301
+
+type AuditLogFilter struct {
+	Action     string
+	ActorID    *uint
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditLogRepository interface {
+	Append(ctx context.Context, log *entity.AuditLog) error
+	Find(ctx context.Context, filter AuditLogFilter) ([]entity.AuditLog, error)
+	Walk(ctx context.Context, fn func(log *entity.AuditLog) error) error
+	Head(ctx context.Context) (count int64, hash string, found bool, err error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db}
+}
+
+// Append menyambungkan log ke ujung hash chain lalu menyimpannya. Jumlah
+// log dan hash terakhir ikut dicatat di settings sebagai head chain.
+func (r *auditLogRepository) Append(ctx context.Context, log *entity.AuditLog) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLockKey).Error; err != nil {
+			return err
+		}
+
+		var last entity.AuditLog
+		err := tx.Select("hash").Order("id DESC").Take(&last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		count, _, found, err := readAuditHead(tx)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// log yang ditulis sebelum head dicatat
+			if err := tx.Model(&entity.AuditLog{}).Count(&count).Error; err != nil {
+				return err
+			}
+		}
+
+		log.PrevHash = last.Hash
+		log.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		log.Hash = log.ComputeHash()
+		if err := tx.Create(log).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&entity.Setting{
+			Key:   entity.SettingAuditHead,
+			Value: fmt.Sprintf("%d:%s", count+1, log.Hash),
+		}).Error
+	})
+}
+
+// Head mengembalikan jumlah log dan hash terakhir yang dicatat oleh Append.
+func (r *auditLogRepository) Head(ctx context.Context) (int64, string, bool, error) {
+	return readAuditHead(conn(ctx, r.db))
+}
+
+func readAuditHead(db *gorm.DB) (int64, string, bool, error) {
+	var setting entity.Setting
+	err := db.Where("key = ?", entity.SettingAuditHead).Take(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, "", false, nil
+	}
+	if err != nil {
+		return 0, "", false, err
+	}
+	countValue, hash, ok := strings.Cut(setting.Value, ":")
+	count, err := strconv.ParseInt(countValue, 10, 64)
+	if !ok || err != nil {
+		return 0, "", false, errors.New("invalid audit head setting")
+	}
+	return count, hash, true, nil
+}
+
+func (r *auditLogRepository) Find(ctx context.Context, filter AuditLogFilter) ([]entity.AuditLog, error) {
+	query := conn(ctx, r.db)
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	logs := make([]entity.AuditLog, 0)
+	if err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Walk membaca seluruh audit log secara berurutan per batch.
+func (r *auditLogRepository) Walk(ctx context.Context, fn func(log *entity.AuditLog) error) error {
+	var batch []entity.AuditLog
+	return conn(ctx, r.db).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				if err := fn(&batch[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
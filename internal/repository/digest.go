@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type DigestRepository interface {
+	GetByUserID(ctx context.Context, userID uint) (*entity.DigestSubscription, error)
+	Save(ctx context.Context, subscription *entity.DigestSubscription) error
+	DeleteByUserID(ctx context.Context, userID uint) (bool, error)
+	DeleteByUnsubscribeTokenHash(ctx context.Context, hash string) (bool, error)
+	UpdateUnsubscribeTokenHash(ctx context.Context, id uint, hash string) error
+	FindDue(ctx context.Context, now time.Time, limit int) ([]entity.DigestSubscription, error)
+	UpdateSchedule(ctx context.Context, id uint, prevNextSendAt time.Time, lastSentAt *time.Time, nextSendAt time.Time) (bool, error)
+}
+
+type digestRepository struct {
+	db *gorm.DB
+}
+
+func NewDigestRepository(db *gorm.DB) DigestRepository {
+	return &digestRepository{db}
+}
+
+func (r *digestRepository) GetByUserID(ctx context.Context, userID uint) (*entity.DigestSubscription, error) {
+	subscription := new(entity.DigestSubscription)
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (r *digestRepository) Save(ctx context.Context, subscription *entity.DigestSubscription) error {
+	return conn(ctx, r.db).Save(subscription).Error
+}
+
+func (r *digestRepository) DeleteByUserID(ctx context.Context, userID uint) (bool, error) {
+	result := conn(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.DigestSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *digestRepository) DeleteByUnsubscribeTokenHash(ctx context.Context, hash string) (bool, error) {
+	result := conn(ctx, r.db).Where("unsubscribe_token_hash = ?", hash).Delete(&entity.DigestSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *digestRepository) UpdateUnsubscribeTokenHash(ctx context.Context, id uint, hash string) error {
+	return conn(ctx, r.db).
+		Model(&entity.DigestSubscription{}).
+		Where("id = ?", id).
+		Update("unsubscribe_token_hash", hash).Error
+}
+
+func (r *digestRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]entity.DigestSubscription, error) {
+	var subscriptions []entity.DigestSubscription
+	err := conn(ctx, r.db).
+		Where("next_send_at <= ?", now).
+		Order("next_send_at, id").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// UpdateSchedule mengembalikan false jika jadwal sudah diubah proses lain,
+// sehingga digest yang sama tidak terkirim dua kali.
+func (r *digestRepository) UpdateSchedule(ctx context.Context, id uint, prevNextSendAt time.Time, lastSentAt *time.Time, nextSendAt time.Time) (bool, error) {
+	columns := map[string]interface{}{"next_send_at": nextSendAt}
+	if lastSentAt != nil {
+		columns["last_sent_at"] = *lastSentAt
+	}
+	result := conn(ctx, r.db).
+		Model(&entity.DigestSubscription{}).
+		Where("id = ? AND next_send_at = ?", id, prevNextSendAt).
+		Updates(columns)
+	return result.RowsAffected > 0, result.Error
+}
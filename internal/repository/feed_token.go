@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type FeedTokenRepository interface {
+	Create(ctx context.Context, token *entity.FeedToken) error
+	FindActiveByHash(ctx context.Context, hash string) (*entity.FeedToken, error)
+	RevokeByUserID(ctx context.Context, userID uint) error
+}
+
+type feedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedTokenRepository(db *gorm.DB) FeedTokenRepository {
+	return &feedTokenRepository{db}
+}
+
+func (r *feedTokenRepository) Create(ctx context.Context, token *entity.FeedToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+func (r *feedTokenRepository) FindActiveByHash(ctx context.Context, hash string) (*entity.FeedToken, error) {
+	token := new(entity.FeedToken)
+	if err := conn(ctx, r.db).
+		Where("token_hash = ? AND revoked_at IS NULL", hash).
+		First(token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *feedTokenRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).
+		Model(&entity.FeedToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
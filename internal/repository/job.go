@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrJobLeaseLost berarti job sudah diklaim ulang replica lain karena lease
+// habis, sehingga hasil eksekusi ini tidak boleh ditulis.
+var ErrJobLeaseLost = errors.New("job lease was lost to another worker")
+
+type JobFilter struct {
+	Name   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+type JobRepository interface {
+	Create(ctx context.Context, job *entity.Job) (bool, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.Job, error)
+	Update(ctx context.Context, job *entity.Job) error
+	Find(ctx context.Context, filter JobFilter) ([]entity.Job, error)
+	DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db}
+}
+
+// Create mengembalikan false jika job dengan UniqueKey yang sama sudah ada.
+func (r *jobRepository) Create(ctx context.Context, job *entity.Job) (bool, error) {
+	result := conn(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "unique_key"}}, DoNothing: true}).
+		Create(job)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimDue mengambil job yang sudah waktunya, termasuk job running yang
+// lease-nya habis karena replica-nya mati, lalu menandainya running dan
+// memundurkan run_at sebesar lease. Job running yang lease-nya habis setelah
+// percobaan terakhir ditandai failed, bukan diklaim lagi.
+func (r *jobRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.Job, error) {
+	var jobs []entity.Job
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// presisi timestamptz hanya mikrodetik, started_at dipakai di Update
+		now := time.Now().Truncate(time.Microsecond)
+		if err := tx.Model(&entity.Job{}).
+			Where("status = ? AND run_at <= ? AND attempts >= max_attempts", entity.JobRunning, now).
+			Updates(map[string]interface{}{
+				"status":      entity.JobFailed,
+				"last_error":  "lease expired on the last attempt",
+				"finished_at": now,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND run_at <= ? AND attempts < max_attempts", []string{entity.JobPending, entity.JobRunning}, now).
+			Order("run_at, id").
+			Limit(limit).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status = entity.JobRunning
+			jobs[i].Attempts++
+			jobs[i].StartedAt = &now
+		}
+		return tx.Model(&entity.Job{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":     entity.JobRunning,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"started_at": now,
+				"run_at":     now.Add(lease),
+			}).Error
+	})
+	return jobs, err
+}
+
+// Update hanya menulis hasil job jika job masih dipegang klaim yang sama
+// (status running dengan started_at yang sama), selain itu ErrJobLeaseLost.
+func (r *jobRepository) Update(ctx context.Context, job *entity.Job) error {
+	result := conn(ctx, r.db).
+		Model(job).
+		Where("status = ? AND started_at = ?", entity.JobRunning, job.StartedAt).
+		Select("status", "attempts", "last_error", "run_at", "finished_at").
+		Updates(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+func (r *jobRepository) Find(ctx context.Context, filter JobFilter) ([]entity.Job, error) {
+	query := conn(ctx, r.db)
+	if filter.Name != "" {
+		query = query.Where("name = ?", filter.Name)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	jobs := make([]entity.Job, 0)
+	if err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("finished_at < ?", cutoff).
+		Delete(&entity.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository interface {
+	FindTOTP(ctx context.Context, userID uint) (*entity.UserTOTP, error)
+	SaveTOTP(ctx context.Context, totp *entity.UserTOTP) error
+	ConfirmTOTP(ctx context.Context, userID uint, step int64) (bool, error)
+	UseStep(ctx context.Context, userID uint, step int64) (bool, error)
+	DeleteTOTP(ctx context.Context, userID uint) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db}
+}
+
+func (r *mfaRepository) FindTOTP(ctx context.Context, userID uint) (*entity.UserTOTP, error) {
+	totp := new(entity.UserTOTP)
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(totp).Error; err != nil {
+		return nil, err
+	}
+	return totp, nil
+}
+
+// SaveTOTP menimpa secret yang belum dikonfirmasi.
+func (r *mfaRepository) SaveTOTP(ctx context.Context, totp *entity.UserTOTP) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_used_step", "updated_at"}),
+	}).Create(totp).Error
+}
+
+func (r *mfaRepository) ConfirmTOTP(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.UserTOTP{}).
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		Updates(map[string]interface{}{"confirmed_at": time.Now(), "last_used_step": step})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UseStep hanya berhasil jika step lebih baru dari step terakhir yang
+// dipakai, sehingga kode yang sama tidak bisa dipakai ulang.
+func (r *mfaRepository) UseStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.UserTOTP{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *mfaRepository) DeleteTOTP(ctx context.Context, userID uint) error {
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.UserTOTP{}).Error
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]entity.RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, entity.RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return conn(ctx, r.db).Create(&codes).Error
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *entity.Notification) error
+	GetByUserID(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]entity.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	SetRead(ctx context.Context, userID, id uint, read bool) (bool, error)
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *entity.Notification) error {
+	return conn(ctx, r.db).Create(notification).Error
+}
+
+func (r *notificationRepository) GetByUserID(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]entity.Notification, error) {
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	notifications := make([]entity.Notification, 0)
+	if err := query.
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// SetRead mengembalikan false jika notifikasi tidak ditemukan.
+func (r *notificationRepository) SetRead(ctx context.Context, userID, id uint, read bool) (bool, error) {
+	var readAt interface{}
+	if read {
+		readAt = time.Now()
+	}
+	var notification entity.Notification
+	if err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Take(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	// waktu baca pertama dipertahankan
+	if read == (notification.ReadAt != nil) {
+		return true, nil
+	}
+	return true, conn(ctx, r.db).
+		Model(&entity.Notification{}).
+		Where("id = ?", id).
+		Update("read_at", readAt).Error
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, message *entity.OutboxMessage) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id uint) error
+	MarkFailed(ctx context.Context, id uint, attempts int, lastError string, availableAt time.Time) error
+	DeletePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db}
+}
+
+// Add ikut transaksi yang sedang berjalan di ctx, sehingga event hanya
+// tersimpan jika perubahan datanya juga tersimpan.
+func (r *outboxRepository) Add(ctx context.Context, message *entity.OutboxMessage) error {
+	if message.AvailableAt.IsZero() {
+		message.AvailableAt = time.Now()
+	}
+	return conn(ctx, r.db).Create(message).Error
+}
+
+// ClaimDue mengambil message yang belum terkirim sesuai urutan ID dan
+// memundurkan available_at sebesar lease agar tidak diambil relay lain.
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxMessage, error) {
+	var messages []entity.OutboxMessage
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND available_at <= ?", now).
+			Order("id").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+		}
+		return tx.Model(&entity.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("available_at", now.Add(lease)).Error
+	})
+	return messages, err
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).
+		Model(&entity.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"published_at": time.Now(), "last_error": ""}).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uint, attempts int, lastError string, availableAt time.Time) error {
+	return conn(ctx, r.db).
+		Model(&entity.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "last_error": lastError, "available_at": availableAt}).Error
+}
+
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("published_at < ?", cutoff).
+		Delete(&entity.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *entity.PersonalAccessToken) error
+	GetByUserID(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error)
+	CountActive(ctx context.Context, userID uint) (int64, error)
+	FindActiveByHash(ctx context.Context, hash string) (*entity.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id uint) (int64, error)
+	RevokeByUserID(ctx context.Context, userID uint) error
+	TouchLastUsed(ctx context.Context, id uint, ip string, before time.Time) error
+}
+
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db}
+}
+
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *entity.PersonalAccessToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+// GetByUserID juga mengembalikan token yang sudah kedaluwarsa agar user bisa
+// melihat token mana yang perlu dibuat ulang.
+func (r *personalAccessTokenRepository) GetByUserID(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error) {
+	tokens := make([]entity.PersonalAccessToken, 0)
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id").
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *personalAccessTokenRepository) CountActive(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *personalAccessTokenRepository) FindActiveByHash(ctx context.Context, hash string) (*entity.PersonalAccessToken, error) {
+	token := new(entity.PersonalAccessToken)
+	if err := conn(ctx, r.db).
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hash, time.Now()).
+		First(token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *personalAccessTokenRepository) Revoke(ctx context.Context, userID, id uint) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *personalAccessTokenRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).
+		Model(&entity.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed hanya menulis jika last_used_at lebih lama dari before,
+// agar token yang dipakai terus-menerus tidak menulis ke database di setiap
+// request.
+func (r *personalAccessTokenRepository) TouchLastUsed(ctx context.Context, id uint, ip string, before time.Time) error {
+	return conn(ctx, r.db).
+		Model(&entity.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, before).
+		Updates(map[string]interface{}{"last_used_at": time.Now(), "last_used_ip": ip}).Error
+}
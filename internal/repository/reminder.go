@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type ReminderRepository interface {
+	Create(ctx context.Context, reminder *entity.Reminder) error
+	GetByID(ctx context.Context, id uint) (*entity.Reminder, error)
+	GetByTodoID(ctx context.Context, todoID uint) ([]entity.Reminder, error)
+	Delete(ctx context.Context, id uint) error
+	FindDue(ctx context.Context, now time.Time, limit int) ([]entity.Reminder, error)
+	MarkSent(ctx context.Context, id uint, sentAt time.Time) (bool, error)
+}
+
+type reminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) ReminderRepository {
+	return &reminderRepository{db}
+}
+
+func (r *reminderRepository) Create(ctx context.Context, reminder *entity.Reminder) error {
+	return conn(ctx, r.db).Create(reminder).Error
+}
+
+func (r *reminderRepository) GetByID(ctx context.Context, id uint) (*entity.Reminder, error) {
+	reminder := new(entity.Reminder)
+	if err := conn(ctx, r.db).First(reminder, id).Error; err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func (r *reminderRepository) GetByTodoID(ctx context.Context, todoID uint) ([]entity.Reminder, error) {
+	reminders := make([]entity.Reminder, 0)
+	if err := conn(ctx, r.db).
+		Where("todo_id = ?", todoID).
+		Order("id").
+		Find(&reminders).Error; err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+func (r *reminderRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&entity.Reminder{}, id).Error
+}
+
+// FindDue mengambil reminder yang belum terkirim dan sudah waktunya. Waktu
+// reminder relatif dihitung dari due_at todo saat ini. Todo yang sudah
+// selesai, diarsipkan atau dihapus dilewati.
+func (r *reminderRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]entity.Reminder, error) {
+	var reminders []entity.Reminder
+	err := conn(ctx, r.db).
+		Table("public.todo_reminders AS r").
+		Select("r.*").
+		Joins("JOIN public.todos t ON t.id = r.todo_id AND t.deleted_at IS NULL").
+		Where("r.sent_at IS NULL AND t.done = false AND t.archived_at IS NULL").
+		Where("COALESCE(r.remind_at, t.due_at - r.minutes_before * INTERVAL '1 minute') <= ?", now).
+		Order("r.id").
+		Limit(limit).
+		Find(&reminders).Error
+	return reminders, err
+}
+
+// MarkSent mengembalikan false jika reminder sudah ditandai terkirim oleh
+// proses lain.
+func (r *reminderRepository) MarkSent(ctx context.Context, id uint, sentAt time.Time) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.Reminder{}).
+		Where("id = ? AND sent_at IS NULL", id).
+		Update("sent_at", sentAt)
+	return result.RowsAffected > 0, result.Error
+}
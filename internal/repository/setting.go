@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string) error
+}
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) SettingRepository {
+	return &settingRepository{db}
+}
+
+func (r *settingRepository) Get(ctx context.Context, key string) (string, bool, error) {
+	setting := new(entity.Setting)
+	err := conn(ctx, r.db).Where("key = ?", key).First(setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return setting.Value, true, nil
+}
+
+func (r *settingRepository) Set(ctx context.Context, key, value string) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&entity.Setting{Key: key, Value: value}).Error
+}
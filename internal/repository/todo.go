@@ -2,20 +2,46 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 	"todo-list/internal/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrVersionConflict = errors.New("the resource has been modified by another request")
+
 type TodoRepository interface {
 	Create(ctx context.Context,todo *entity.Todo) error
 	GetAll(ctx context.Context)	([]entity.Todo, error)
 	GetByID(ctx context.Context,id uint) (*entity.Todo, error)
 	GetByUserID(ctx context.Context,userID uint) ([]entity.Todo, error)
-	Update(ctx context.Context,todo *entity.Todo) error
-	Delete(ctx context.Context,id uint) error
+	Update(ctx context.Context, id, version uint, columns map[string]interface{}) error
+	Delete(ctx context.Context, id, version uint) error
+	GetTrashByUserID(ctx context.Context, userID uint) ([]entity.Todo, error)
+	GetTrashedByID(ctx context.Context, id uint) (*entity.Todo, error)
+	Restore(ctx context.Context, id uint) error
+	Purge(ctx context.Context, id uint) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]entity.Todo, error)
+	GetArchivedByUserID(ctx context.Context, userID uint) ([]entity.Todo, error)
+	ArchiveCompletedBefore(ctx context.Context, cutoff time.Time) ([]entity.Todo, error)
+	GetLastPosition(ctx context.Context, userID uint) (string, error)
+	GetPositionAfter(ctx context.Context, userID uint, position string) (string, error)
+	GetPositionBefore(ctx context.Context, userID uint, position string) (string, error)
+	LockByIDs(ctx context.Context, ids []uint) ([]entity.Todo, error)
+	LockByUserID(ctx context.Context, userID uint) ([]entity.Todo, error)
+	GetUserIDsWithLongPositions(ctx context.Context, maxLength int) ([]uint, error)
+	StreamByUserID(ctx context.Context, userID uint, fn func(todo *entity.Todo) error) error
+	GetByCalDAVName(ctx context.Context, userID uint, name string) (*entity.Todo, error)
+	GetOpenDueBefore(ctx context.Context, userID uint, before time.Time, limit int) ([]entity.Todo, error)
+	GetCompletedSince(ctx context.Context, userID uint, since time.Time, limit int) ([]entity.Todo, error)
 }
 
+// position dibandingkan byte-wise agar urutan sama dengan pkg/rank,
+// apapun collation database-nya.
+const positionOrder = `position COLLATE "C", id`
+
 type todoRepository struct {
 	db *gorm.DB
 }
@@ -25,12 +51,12 @@ func NewTodoRepository(db *gorm.DB) TodoRepository {
 }
 
 func (r *todoRepository) Create(ctx context.Context,todo *entity.Todo) error {
-	return r.db.WithContext(ctx).Create(todo).Error
+	return conn(ctx, r.db).Create(todo).Error
 }
 
 func (r *todoRepository) GetAll(ctx context.Context) ([]entity.Todo, error) {
 	var todos []entity.Todo
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
 	Find(&todos).Error; err != nil {
 		return nil, err
 	}
@@ -40,7 +66,7 @@ func (r *todoRepository) GetAll(ctx context.Context) ([]entity.Todo, error) {
 
 func (r *todoRepository) GetByID(ctx context.Context,id uint) (*entity.Todo, error) {
 	var todo entity.Todo
-	if err := r.db.WithContext(ctx).First(&todo, id).Error; err != nil{
+	if err := conn(ctx, r.db).First(&todo, id).Error; err != nil{
 		return nil, err
 	}
 	return &todo, nil
@@ -48,18 +74,259 @@ func (r *todoRepository) GetByID(ctx context.Context,id uint) (*entity.Todo, err
 
 func (r *todoRepository) GetByUserID(ctx context.Context,userID uint) ([]entity.Todo, error) {
 	var todos []entity.Todo
-	if err := r.db.WithContext(ctx).
-	Where("user_id = ?", userID).
+	if err := conn(ctx, r.db).
+	Where("user_id = ? AND archived_at IS NULL", userID).
+	Order(positionOrder).
 	Find(&todos).Error; err != nil {
 		return nil, err
 	}
 	return todos, nil
 }
 
-func (r *todoRepository) Update(ctx context.Context, todo *entity.Todo) error {
-	return r.db.WithContext(ctx).Save(todo).Error
+// Update hanya menulis kolom yang ada di columns dan menaikkan version.
+// Jika version di database sudah bukan version, ErrVersionConflict
+// dikembalikan.
+func (r *todoRepository) Update(ctx context.Context, id, version uint, columns map[string]interface{}) error {
+	columns["version"] = gorm.Expr("version + 1")
+	result := conn(ctx, r.db).
+		Model(&entity.Todo{}).
+		Where("id = ? AND version = ?", id, version).
+		Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Delete memindahkan todo ke trash jika version di database masih version,
+// selain itu ErrVersionConflict dikembalikan.
+func (r *todoRepository) Delete(ctx context.Context, id, version uint) error {
+	result := conn(ctx, r.db).Where("version = ?", version).Delete(&entity.Todo{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *todoRepository) GetTrashByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) GetTrashedByID(ctx context.Context, id uint) (*entity.Todo, error) {
+	var todo entity.Todo
+	if err := conn(ctx, r.db).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&todo, id).Error; err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *todoRepository) Restore(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Unscoped().
+		Model(&entity.Todo{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+}
+
+func (r *todoRepository) Purge(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Unscoped().Delete(&entity.Todo{}, id).Error
+}
+
+// PurgeDeletedBefore mengembalikan todo yang dihapus permanen agar event
+// bisa dicatat per todo.
+func (r *todoRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).Unscoped().
+		Clauses(clause.Returning{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) GetArchivedByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND archived_at IS NOT NULL", userID).
+		Order("archived_at DESC").
+		Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// ArchiveCompletedBefore mengembalikan todo yang diarsipkan, sudah dengan
+// archived_at dan version yang baru.
+func (r *todoRepository) ArchiveCompletedBefore(ctx context.Context, cutoff time.Time) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).
+		Model(&todos).
+		Clauses(clause.Returning{}).
+		Where("done = ? AND archived_at IS NULL AND completed_at < ?", true, cutoff).
+		Updates(map[string]interface{}{"archived_at": time.Now(), "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) GetLastPosition(ctx context.Context, userID uint) (string, error) {
+	var positions []string
+	if err := conn(ctx, r.db).
+		Model(&entity.Todo{}).
+		Where("user_id = ?", userID).
+		Order(`position COLLATE "C" DESC`).
+		Limit(1).
+		Pluck("position", &positions).Error; err != nil {
+		return "", err
+	}
+	if len(positions) == 0 {
+		return "", nil
+	}
+	return positions[0], nil
+}
+
+// GetPositionAfter mengembalikan position terkecil yang lebih besar dari
+// position, atau string kosong jika tidak ada.
+func (r *todoRepository) GetPositionAfter(ctx context.Context, userID uint, position string) (string, error) {
+	var positions []string
+	if err := conn(ctx, r.db).
+		Model(&entity.Todo{}).
+		Where(`user_id = ? AND position COLLATE "C" > ?`, userID, position).
+		Order(`position COLLATE "C"`).
+		Limit(1).
+		Pluck("position", &positions).Error; err != nil {
+		return "", err
+	}
+	if len(positions) == 0 {
+		return "", nil
+	}
+	return positions[0], nil
+}
+
+// GetPositionBefore mengembalikan position terbesar yang lebih kecil dari
+// position, atau string kosong jika tidak ada.
+func (r *todoRepository) GetPositionBefore(ctx context.Context, userID uint, position string) (string, error) {
+	var positions []string
+	if err := conn(ctx, r.db).
+		Model(&entity.Todo{}).
+		Where(`user_id = ? AND position COLLATE "C" < ?`, userID, position).
+		Order(`position COLLATE "C" DESC`).
+		Limit(1).
+		Pluck("position", &positions).Error; err != nil {
+		return "", err
+	}
+	if len(positions) == 0 {
+		return "", nil
+	}
+	return positions[0], nil
+}
+
+// LockByIDs mengunci baris todo dengan SELECT ... FOR UPDATE sampai transaksi
+// di ctx selesai. Baris dikunci berurutan menurut id agar tidak deadlock.
+func (r *todoRepository) LockByIDs(ctx context.Context, ids []uint) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).
+		Where("id IN ?", ids).
+		Order("id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
 }
 
-func (r *todoRepository) Delete(ctx context.Context,id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.Todo{}, id).Error
+// LockByUserID mengunci seluruh todo milik user (termasuk yang diarsipkan)
+// sesuai urutan position sampai transaksi di ctx selesai.
+func (r *todoRepository) LockByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order(positionOrder).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *todoRepository) GetUserIDsWithLongPositions(ctx context.Context, maxLength int) ([]uint, error) {
+	var userIDs []uint
+	if err := conn(ctx, r.db).
+		Model(&entity.Todo{}).
+		Distinct("user_id").
+		Where("length(position) > ?", maxLength).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// StreamByUserID membaca seluruh todo milik user (termasuk yang diarsipkan)
+// per batch sehingga tidak perlu memuat semuanya ke memory.
+func (r *todoRepository) StreamByUserID(ctx context.Context, userID uint, fn func(todo *entity.Todo) error) error {
+	var batch []entity.Todo
+	return conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				if err := fn(&batch[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// GetByCalDAVName mencari todo milik user berdasarkan nama resource yang
+// dipilih client CalDAV.
+func (r *todoRepository) GetByCalDAVName(ctx context.Context, userID uint, name string) (*entity.Todo, error) {
+	todo := new(entity.Todo)
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND caldav_name = ?", userID, name).
+		First(todo).Error; err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// GetOpenDueBefore mengambil todo yang belum selesai dengan due_at sebelum
+// before, diurutkan dari yang paling lama.
+func (r *todoRepository) GetOpenDueBefore(ctx context.Context, userID uint, before time.Time, limit int) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND done = ? AND archived_at IS NULL AND due_at < ?", userID, false, before).
+		Order("due_at, id").
+		Limit(limit).
+		Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// GetCompletedSince juga mengambil todo yang sudah diarsipkan.
+func (r *todoRepository) GetCompletedSince(ctx context.Context, userID uint, since time.Time, limit int) ([]entity.Todo, error) {
+	var todos []entity.Todo
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND done = ? AND completed_at >= ?", userID, true, since).
+		Order("completed_at DESC, id").
+		Limit(limit).
+		Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
 }
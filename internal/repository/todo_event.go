@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type TodoEventRepository interface {
+	Create(ctx context.Context, event *entity.TodoEvent) error
+	GetByID(ctx context.Context, id uint) (*entity.TodoEvent, error)
+	GetByTodoID(ctx context.Context, todoID uint) ([]entity.TodoEvent, error)
+	GetNewerThan(ctx context.Context, todoID, eventID uint) ([]entity.TodoEvent, error)
+	GetByUserIDAfter(ctx context.Context, userID, eventID uint) ([]entity.TodoEvent, error)
+	GetLatestIDByUserID(ctx context.Context, userID uint) (uint, error)
+}
+
+type todoEventRepository struct {
+	db *gorm.DB
+}
+
+func NewTodoEventRepository(db *gorm.DB) TodoEventRepository {
+	return &todoEventRepository{db}
+}
+
+func (r *todoEventRepository) Create(ctx context.Context, event *entity.TodoEvent) error {
+	return conn(ctx, r.db).Create(event).Error
+}
+
+func (r *todoEventRepository) GetByID(ctx context.Context, id uint) (*entity.TodoEvent, error) {
+	var event entity.TodoEvent
+	if err := conn(ctx, r.db).First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *todoEventRepository) GetByTodoID(ctx context.Context, todoID uint) ([]entity.TodoEvent, error) {
+	events := make([]entity.TodoEvent, 0)
+	if err := conn(ctx, r.db).
+		Where("todo_id = ?", todoID).
+		Order("id").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetNewerThan mengembalikan event setelah eventID, dari yang terbaru.
+func (r *todoEventRepository) GetNewerThan(ctx context.Context, todoID, eventID uint) ([]entity.TodoEvent, error) {
+	var events []entity.TodoEvent
+	if err := conn(ctx, r.db).
+		Where("todo_id = ? AND id > ?", todoID, eventID).
+		Order("id DESC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetByUserIDAfter mengembalikan event todo milik user setelah eventID, dari
+// yang terlama.
+func (r *todoEventRepository) GetByUserIDAfter(ctx context.Context, userID, eventID uint) ([]entity.TodoEvent, error) {
+	var events []entity.TodoEvent
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND id > ?", userID, eventID).
+		Order("id").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *todoEventRepository) GetLatestIDByUserID(ctx context.Context, userID uint) (uint, error) {
+	var id uint
+	err := conn(ctx, r.db).
+		Model(&entity.TodoEvent{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
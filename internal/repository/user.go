@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 	"todo-list/internal/entity"
 
 	"gorm.io/gorm"
@@ -11,6 +12,12 @@ type UserRepository interface {
 	FindAll(ctx context.Context) ([]entity.User, error)
 	FindByUsername(ctx context.Context, username string) (*entity.User, error)
 	CreateUser(ctx context.Context, user *entity.UserReg) error
+	FindByID(ctx context.Context, id int64) (*entity.User, error)
+	UpdateRole(ctx context.Context, id int64, version uint, role string) error
+	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	UpdateEmail(ctx context.Context, id int64, email string) error
+	MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
 }
 
 type userRepository struct {
@@ -24,7 +31,7 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 
 func (r *userRepository) FindAll(ctx context.Context) ([]entity.User, error) {
 	user := make([]entity.User, 0)
-	if err := r.db.WithContext(ctx).Find(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -32,7 +39,7 @@ func (r *userRepository) FindAll(ctx context.Context) ([]entity.User, error) {
 
 func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
 	user := new(entity.User)
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
 		Where("username = ?", username).
 		First(&user).Error; err != nil {
 		return nil, err
@@ -41,5 +48,67 @@ func (r *userRepository) FindByUsername(ctx context.Context, username string) (*
 }
 
 func (r *userRepository) CreateUser(ctx context.Context, user *entity.UserReg) error {
-    return r.db.WithContext(ctx).Create(user).Error
-}
\ No newline at end of file
+    return conn(ctx, r.db).Create(user).Error
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id int64) (*entity.User, error) {
+	user := new(entity.User)
+	if err := conn(ctx, r.db).First(user, id).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id int64, version uint, role string) error {
+	result := conn(ctx, r.db).
+		Model(&entity.User{}).
+		Where("id = ? AND version = ?", id, version).
+		Updates(map[string]interface{}{"role": role, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// FindByEmail membandingkan email tanpa membedakan huruf besar dan kecil.
+// Email yang belum diverifikasi boleh dipakai beberapa user, jadi user yang
+// emailnya sudah terverifikasi didahulukan.
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	user := new(entity.User)
+	if err := conn(ctx, r.db).
+		Where("LOWER(email) = LOWER(?)", email).
+		Order("email_verified_at IS NULL, id").
+		First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateEmail juga menghapus status verifikasi email sebelumnya.
+func (r *userRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	return conn(ctx, r.db).
+		Model(&entity.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "email_verified_at": nil, "version": gorm.Expr("version + 1")}).Error
+}
+
+// MarkEmailVerified mengembalikan false jika email user sudah berubah sejak
+// token verifikasi dibuat, atau email sudah diverifikasi oleh user lain.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.User{}).
+		Where("id = ? AND email = ?", id, email).
+		Where("NOT EXISTS (SELECT 1 FROM public.users other WHERE LOWER(other.email) = LOWER(?) AND other.email_verified_at IS NOT NULL AND other.id <> ?)", email, id).
+		Updates(map[string]interface{}{"email_verified_at": time.Now(), "version": gorm.Expr("version + 1")})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	return conn(ctx, r.db).
+		Model(&entity.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"password": password, "version": gorm.Expr("version + 1")}).Error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	Find(ctx context.Context, issuer, subject string) (*entity.UserIdentity, error)
+	Create(ctx context.Context, identity *entity.UserIdentity) error
+	TouchLastLogin(ctx context.Context, id uint, email string) error
+}
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db}
+}
+
+func (r *userIdentityRepository) Find(ctx context.Context, issuer, subject string) (*entity.UserIdentity, error) {
+	identity := new(entity.UserIdentity)
+	if err := conn(ctx, r.db).
+		Where("issuer = ? AND subject = ?", issuer, subject).
+		First(identity).Error; err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	return conn(ctx, r.db).Create(identity).Error
+}
+
+func (r *userIdentityRepository) TouchLastLogin(ctx context.Context, id uint, email string) error {
+	return conn(ctx, r.db).
+		Model(&entity.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error
+}
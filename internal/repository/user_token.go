@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *entity.UserToken) error
+	GetByID(ctx context.Context, id uint) (*entity.UserToken, error)
+	UpdateHash(ctx context.Context, id uint, tokenHash string) (bool, error)
+	FindValid(ctx context.Context, purpose, tokenHash string) (*entity.UserToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateByUserID(ctx context.Context, userID uint, purpose string) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db}
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *entity.UserToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+func (r *userTokenRepository) GetByID(ctx context.Context, id uint) (*entity.UserToken, error) {
+	token := new(entity.UserToken)
+	if err := conn(ctx, r.db).First(token, id).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// UpdateHash mengganti hash token yang masih berlaku. false berarti token
+// sudah dipakai, dibatalkan atau kedaluwarsa.
+func (r *userTokenRepository) UpdateHash(ctx context.Context, id uint, tokenHash string) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.UserToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, time.Now()).
+		Update("token_hash", tokenHash)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindValid mengambil token yang belum dipakai dan belum kedaluwarsa.
+func (r *userTokenRepository) FindValid(ctx context.Context, purpose, tokenHash string) (*entity.UserToken, error) {
+	token := new(entity.UserToken)
+	if err := conn(ctx, r.db).
+		Where("purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", purpose, tokenHash, time.Now()).
+		First(token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// MarkUsed mengembalikan false jika token sudah dipakai oleh request lain.
+func (r *userTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// InvalidateByUserID menandai semua token user dengan purpose tersebut
+// sebagai sudah dipakai.
+func (r *userTokenRepository) InvalidateByUserID(ctx context.Context, userID uint, purpose string) error {
+	return conn(ctx, r.db).
+		Model(&entity.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-list/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *entity.Webhook) error
+	GetByUserID(ctx context.Context, userID uint) ([]entity.Webhook, error)
+	GetByID(ctx context.Context, id uint) (*entity.Webhook, error)
+	Delete(ctx context.Context, id uint) error
+	FindSubscribers(ctx context.Context, userID uint) ([]entity.Webhook, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *entity.Webhook) error {
+	return conn(ctx, r.db).Create(webhook).Error
+}
+
+func (r *webhookRepository) GetByUserID(ctx context.Context, userID uint) ([]entity.Webhook, error) {
+	webhooks := make([]entity.Webhook, 0)
+	if err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id uint) (*entity.Webhook, error) {
+	webhook := new(entity.Webhook)
+	if err := conn(ctx, r.db).First(webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&entity.Webhook{}, id).Error
+}
+
+// FindSubscribers mengembalikan webhook milik userID dan semua webhook
+// global.
+func (r *webhookRepository) FindSubscribers(ctx context.Context, userID uint) ([]entity.Webhook, error) {
+	var webhooks []entity.Webhook
+	if err := conn(ctx, r.db).
+		Where("(user_id = ? AND global = ?) OR global = ?", userID, false, true).
+		Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, deliveries []entity.WebhookDelivery) error
+	GetByWebhookID(ctx context.Context, webhookID uint, limit int) ([]entity.WebhookDelivery, error)
+	GetByID(ctx context.Context, id uint) (*entity.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+	Update(ctx context.Context, delivery *entity.WebhookDelivery) error
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db}
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, deliveries []entity.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(&deliveries).Error
+}
+
+func (r *webhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID uint, limit int) ([]entity.WebhookDelivery, error) {
+	deliveries := make([]entity.WebhookDelivery, 0)
+	if err := conn(ctx, r.db).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, id uint) (*entity.WebhookDelivery, error) {
+	delivery := new(entity.WebhookDelivery)
+	if err := conn(ctx, r.db).First(delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ClaimDue mengambil delivery pending yang sudah waktunya dikirim dan
+// memundurkan next_attempt_at sebesar lease, sehingga replica lain tidak
+// mengirim delivery yang sama. SKIP LOCKED mencegah replica saling menunggu.
+func (r *webhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	var deliveries []entity.WebhookDelivery
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", entity.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+		return tx.Model(&entity.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+func (r *webhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	return conn(ctx, r.db).
+		Model(delivery).
+		Select("status", "attempts", "response_status", "last_error", "next_attempt_at", "delivered_at").
+		Updates(delivery).Error
+}
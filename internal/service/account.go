@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/mailer"
+	"todo-list/pkg/securetoken"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	passwordResetTTL     = time.Hour
+	minPasswordLength    = 8
+	// maksimal email reset password ke satu alamat per jam
+	maxResetEmailsPerHour = 3
+)
+
+// JobSendAccountEmail adalah nama job yang mengirim link verifikasi atau
+// token reset password. Payload-nya hanya ID user_token; token asli dibuat
+// saat job dijalankan agar tidak tersimpan di tabel jobs.
+const JobSendAccountEmail = "send-account-email"
+
+type accountEmailPayload struct {
+	UserTokenID uint `json:"user_token_id"`
+}
+
+var (
+	ErrEmailUnavailable  = errors.New("email delivery is not configured")
+	ErrEmailTaken        = errors.New("email is already used by another account")
+	ErrNoEmail           = errors.New("the account has no email address")
+	ErrEmailVerified     = errors.New("email is already verified")
+	ErrInvalidEmailToken = errors.New("verification link is invalid or has expired")
+	ErrInvalidResetToken = errors.New("reset token is invalid or has expired")
+	ErrPasswordTooShort  = errors.New("password must be at least 8 characters")
+)
+
+// AccountService menangani verifikasi email dan reset password lewat token
+// sekali pakai yang dikirim ke email user.
+type AccountService interface {
+	SendEmailVerification(ctx context.Context, userID int64) error
+	ChangeEmail(ctx context.Context, userID int64, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	SendTokenEmail(ctx context.Context, payload json.RawMessage) error
+}
+
+type accountService struct {
+	userRepository  repository.UserRepository
+	userTokenRepo   repository.UserTokenRepository
+	accessTokenRepo repository.PersonalAccessTokenRepository
+	appPasswordRepo repository.AppPasswordRepository
+	feedTokenRepo   repository.FeedTokenRepository
+	jobService      JobService
+	transactor      repository.Transactor
+	cacheable       cache.Cacheable
+	auditService    AuditService
+	publisher       event.Publisher
+	mailer          mailer.Mailer
+	baseURL         string
+	enabled         bool
+}
+
+// NewAccountService menolak pengiriman email jika m nil.
+func NewAccountService(
+	userRepository repository.UserRepository,
+	userTokenRepo repository.UserTokenRepository,
+	accessTokenRepo repository.PersonalAccessTokenRepository,
+	appPasswordRepo repository.AppPasswordRepository,
+	feedTokenRepo repository.FeedTokenRepository,
+	jobService JobService,
+	transactor repository.Transactor,
+	cacheable cache.Cacheable,
+	auditService AuditService,
+	publisher event.Publisher,
+	m mailer.Mailer,
+	baseURL string,
+) AccountService {
+	return &accountService{
+		userRepository, userTokenRepo, accessTokenRepo, appPasswordRepo, feedTokenRepo,
+		jobService, transactor, cacheable, auditService, publisher, m, baseURL, m != nil,
+	}
+}
+
+// SendEmailVerification membatalkan link verifikasi sebelumnya lalu
+// mengirim link baru.
+func (s *accountService) SendEmailVerification(ctx context.Context, userID int64) error {
+	if !s.enabled {
+		return ErrEmailUnavailable
+	}
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return ErrNoEmail
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailVerified
+	}
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userTokenRepo.InvalidateByUserID(ctx, uint(user.ID), entity.UserTokenEmailVerification); err != nil {
+			return err
+		}
+		return s.issueToken(ctx, user, entity.UserTokenEmailVerification, emailVerificationTTL)
+	})
+}
+
+// ChangeEmail hanya menolak email yang sudah diverifikasi user lain, agar
+// email orang lain tidak bisa dikunci dengan mendaftarkannya tanpa
+// verifikasi.
+func (s *accountService) ChangeEmail(ctx context.Context, userID int64, email string) error {
+	email = normalizeEmail(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" {
+		return ErrInvalidEmail
+	}
+	if other, err := s.userRepository.FindByEmail(ctx, email); err == nil && other.ID != userID && other.EmailVerifiedAt != nil {
+		return ErrEmailTaken
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := s.userRepository.UpdateEmail(ctx, userID, email); err != nil {
+		return err
+	}
+	s.audit(ctx, entity.AuditEmailChanged, userID, nil)
+	if !s.enabled {
+		return nil
+	}
+	return s.SendEmailVerification(ctx, userID)
+}
+
+func (s *accountService) VerifyEmail(ctx context.Context, token string) error {
+	var userID int64
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		userToken, err := s.useToken(ctx, entity.UserTokenEmailVerification, token)
+		if err != nil {
+			return ErrInvalidEmailToken
+		}
+		userID = int64(userToken.UserID)
+		if other, err := s.userRepository.FindByEmail(ctx, userToken.Email); err == nil && other.ID != userID && other.EmailVerifiedAt != nil {
+			return ErrEmailTaken
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		verified, err := s.userRepository.MarkEmailVerified(ctx, userID, userToken.Email)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			// email sudah diganti sejak link dikirim
+			return ErrInvalidEmailToken
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, entity.AuditEmailVerified, userID, nil)
+	return nil
+}
+
+// ForgotPassword tidak memberi tahu apakah email terdaftar, agar endpoint
+// tidak bisa dipakai untuk mencari akun.
+func (s *accountService) ForgotPassword(ctx context.Context, email string) error {
+	if !s.enabled {
+		return ErrEmailUnavailable
+	}
+	email = normalizeEmail(email)
+	user, err := s.userRepository.FindByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	count, err := s.cacheable.Incr("todo-list:password-reset:"+email, time.Hour)
+	if err == nil && count > maxResetEmailsPerHour {
+		log.Printf("password reset for user %d throttled", user.ID)
+		return nil
+	}
+
+	// hanya token reset terakhir yang berlaku
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userTokenRepo.InvalidateByUserID(ctx, uint(user.ID), entity.UserTokenPasswordReset); err != nil {
+			return err
+		}
+		return s.issueToken(ctx, user, entity.UserTokenPasswordReset, passwordResetTTL)
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, entity.AuditPasswordResetRequested, user.ID, nil)
+	return nil
+}
+
+// ResetPassword juga menandai email sebagai terverifikasi karena token
+// hanya bisa didapat dari email tersebut. Personal access token, app
+// password dan feed token ikut dicabut karena bisa saja dibuat oleh orang
+// yang mengetahui password lama.
+func (s *accountService) ResetPassword(ctx context.Context, token, password string) error {
+	if len(password) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	var userID int64
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		userToken, err := s.useToken(ctx, entity.UserTokenPasswordReset, token)
+		if err != nil {
+			return ErrInvalidResetToken
+		}
+		userID = int64(userToken.UserID)
+		if err := s.userRepository.UpdatePassword(ctx, userID, string(hashed)); err != nil {
+			return err
+		}
+		if err := s.userTokenRepo.InvalidateByUserID(ctx, userToken.UserID, entity.UserTokenPasswordReset); err != nil {
+			return err
+		}
+		if err := s.accessTokenRepo.RevokeByUserID(ctx, userToken.UserID); err != nil {
+			return err
+		}
+		if err := s.appPasswordRepo.RevokeByUserID(ctx, userToken.UserID); err != nil {
+			return err
+		}
+		if err := s.feedTokenRepo.RevokeByUserID(ctx, userToken.UserID); err != nil {
+			return err
+		}
+		if _, err := s.userRepository.MarkEmailVerified(ctx, userID, userToken.Email); err != nil {
+			return err
+		}
+		// stream real-time yang masih terbuka ikut ditutup
+		return s.publisher.Publish(ctx, event.Event{Type: event.TypeUserTokensRevoked, UserID: userToken.UserID, OccurredAt: time.Now()})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, entity.AuditPasswordReset, userID, nil)
+	return nil
+}
+
+// issueToken membuat user_token dan menjadwalkan emailnya. Hash awal berasal
+// dari token yang langsung dibuang; token yang dikirim dibuat oleh
+// SendTokenEmail.
+func (s *accountService) issueToken(ctx context.Context, user *entity.User, purpose string, ttl time.Duration) error {
+	_, hash, err := securetoken.Generate(tokenPrefix(purpose))
+	if err != nil {
+		return err
+	}
+	userToken := &entity.UserToken{
+		UserID:    uint(user.ID),
+		Purpose:   purpose,
+		TokenHash: hash,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.userTokenRepo.Create(ctx, userToken); err != nil {
+		return err
+	}
+	_, err = s.jobService.Enqueue(ctx, JobSendAccountEmail, accountEmailPayload{UserTokenID: userToken.ID}, time.Now())
+	return err
+}
+
+// SendTokenEmail menjalankan job JobSendAccountEmail. Token baru dibuat dan
+// hash-nya menggantikan hash sebelumnya, sehingga percobaan ulang job juga
+// membatalkan token dari percobaan yang gagal. Token yang sudah dipakai,
+// dibatalkan atau kedaluwarsa tidak dikirim.
+func (s *accountService) SendTokenEmail(ctx context.Context, payload json.RawMessage) error {
+	if !s.enabled {
+		return ErrEmailUnavailable
+	}
+	var p accountEmailPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	userToken, err := s.userTokenRepo.GetByID(ctx, p.UserTokenID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepository.FindByID(ctx, int64(userToken.UserID))
+	if err != nil {
+		return err
+	}
+	token, hash, err := securetoken.Generate(tokenPrefix(userToken.Purpose))
+	if err != nil {
+		return err
+	}
+	valid, err := s.userTokenRepo.UpdateHash(ctx, userToken.ID, hash)
+	if err != nil || !valid {
+		return err
+	}
+
+	msg := mailer.Message{To: userToken.Email}
+	if userToken.Purpose == entity.UserTokenPasswordReset {
+		msg.Subject = "Reset your password"
+		msg.Body = fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset the password of your account %s. Send the token below to POST %s/api/v1/password/reset together with your new password:\n\n%s\n\nThe token expires in %d minutes and can only be used once. If you did not request this, you can ignore this email.\n",
+			displayName(user), user.Username, s.baseURL, token, int(passwordResetTTL.Minutes()))
+	} else {
+		link := s.baseURL + "/api/v1/email/verify?token=" + url.QueryEscape(token)
+		msg.Subject = "Verify your email address"
+		msg.Body = fmt.Sprintf(
+			"Hi %s,\n\nConfirm that this is your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours. If you did not request this, you can ignore this email.\n",
+			displayName(user), link, int(emailVerificationTTL.Hours()))
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+// useToken memvalidasi token dan menandainya sudah dipakai.
+func (s *accountService) useToken(ctx context.Context, purpose, token string) (*entity.UserToken, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	userToken, err := s.userTokenRepo.FindValid(ctx, purpose, securetoken.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.userTokenRepo.MarkUsed(ctx, userToken.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return userToken, nil
+}
+
+func (s *accountService) audit(ctx context.Context, action string, userID int64, metadata map[string]interface{}) {
+	if err := s.auditService.Record(ctx, action, "user", strconv.FormatInt(userID, 10), metadata); err != nil {
+		log.Printf("failed to record audit log %s: %v", action, err)
+	}
+}
+
+// normalizeEmail menyimpan email dalam huruf kecil agar pencarian dan
+// pengecekan duplikat konsisten.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func displayName(user *entity.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Username
+}
+
+func tokenPrefix(purpose string) string {
+	if purpose == entity.UserTokenPasswordReset {
+		return "reset_"
+	}
+	return "verify_"
+}
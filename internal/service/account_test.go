@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/mailer"
+	"todo-list/pkg/securetoken"
+
+	"gorm.io/gorm"
+)
+
+type fakeAccountUserRepository struct {
+	repository.UserRepository
+	user entity.User
+	// others adalah user lain yang hanya dicari lewat email
+	others []entity.User
+}
+
+func (r *fakeAccountUserRepository) FindByID(ctx context.Context, id int64) (*entity.User, error) {
+	if id != r.user.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *fakeAccountUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	var found *entity.User
+	for _, user := range append([]entity.User{r.user}, r.others...) {
+		if !strings.EqualFold(user.Email, email) {
+			continue
+		}
+		if found == nil || (found.EmailVerifiedAt == nil && user.EmailVerifiedAt != nil) {
+			user := user
+			found = &user
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
+
+func (r *fakeAccountUserRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	r.user.Email = email
+	r.user.EmailVerifiedAt = nil
+	return nil
+}
+
+func (r *fakeAccountUserRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	r.user.Password = password
+	return nil
+}
+
+func (r *fakeAccountUserRepository) MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error) {
+	if r.user.ID != id || r.user.Email != email {
+		return false, nil
+	}
+	now := time.Now()
+	r.user.EmailVerifiedAt = &now
+	return true, nil
+}
+
+type fakeUserTokenRepository struct {
+	repository.UserTokenRepository
+	tokens []entity.UserToken
+}
+
+func (r *fakeUserTokenRepository) Create(ctx context.Context, token *entity.UserToken) error {
+	token.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+func (r *fakeUserTokenRepository) GetByID(ctx context.Context, id uint) (*entity.UserToken, error) {
+	if id == 0 || int(id) > len(r.tokens) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	token := r.tokens[id-1]
+	return &token, nil
+}
+
+func (r *fakeUserTokenRepository) UpdateHash(ctx context.Context, id uint, tokenHash string) (bool, error) {
+	token := &r.tokens[id-1]
+	if token.UsedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	token.TokenHash = tokenHash
+	return true, nil
+}
+
+func (r *fakeUserTokenRepository) FindValid(ctx context.Context, purpose, tokenHash string) (*entity.UserToken, error) {
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(time.Now()) {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	token := &r.tokens[id-1]
+	if token.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+func (r *fakeUserTokenRepository) InvalidateByUserID(ctx context.Context, userID uint, purpose string) error {
+	now := time.Now()
+	for i := range r.tokens {
+		if r.tokens[i].UserID == userID && r.tokens[i].Purpose == purpose && r.tokens[i].UsedAt == nil {
+			r.tokens[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeAccessTokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	revoked []uint
+}
+
+func (r *fakeAccessTokenRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeAppPasswordRepository struct {
+	repository.AppPasswordRepository
+	revoked []uint
+}
+
+func (r *fakeAppPasswordRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeFeedTokenRepository struct {
+	repository.FeedTokenRepository
+	revoked []uint
+}
+
+func (r *fakeFeedTokenRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeAccountJobService struct {
+	JobService
+	jobs []entity.Job
+}
+
+func (s *fakeAccountJobService) Enqueue(ctx context.Context, name string, payload interface{}, runAt time.Time) (*entity.Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := entity.Job{ID: uint(len(s.jobs) + 1), Name: name, Payload: entity.RawJSON(b)}
+	s.jobs = append(s.jobs, job)
+	return &job, nil
+}
+
+type fakeAccountTransactor struct{}
+
+func (fakeAccountTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeAccountCache struct {
+	cache.Cacheable
+}
+
+func (fakeAccountCache) Incr(key string, window time.Duration) (int64, error) {
+	return 1, nil
+}
+
+type fakeAccountAuditService struct {
+	AuditService
+}
+
+func (fakeAccountAuditService) Record(ctx context.Context, action, targetType, targetID string, metadata map[string]interface{}) error {
+	return nil
+}
+
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type accountTest struct {
+	service      AccountService
+	users        *fakeAccountUserRepository
+	tokens       *fakeUserTokenRepository
+	accessTokens *fakeAccessTokenRepository
+	appPasswords *fakeAppPasswordRepository
+	feedTokens   *fakeFeedTokenRepository
+	jobs         *fakeAccountJobService
+	publisher    *fakeTodoPublisher
+	mailer       *fakeMailer
+}
+
+func newAccountTest(user entity.User) *accountTest {
+	at := &accountTest{
+		users:        &fakeAccountUserRepository{user: user},
+		tokens:       &fakeUserTokenRepository{},
+		accessTokens: &fakeAccessTokenRepository{},
+		appPasswords: &fakeAppPasswordRepository{},
+		feedTokens:   &fakeFeedTokenRepository{},
+		jobs:         &fakeAccountJobService{},
+		publisher:    &fakeTodoPublisher{},
+		mailer:       &fakeMailer{},
+	}
+	at.service = NewAccountService(
+		at.users, at.tokens, at.accessTokens, at.appPasswords, at.feedTokens, at.jobs,
+		fakeAccountTransactor{}, fakeAccountCache{}, fakeAccountAuditService{}, at.publisher, at.mailer, "https://todo.example.com",
+	)
+	return at
+}
+
+// sentToken mengambil token dengan prefix tertentu dari email terakhir.
+func (at *accountTest) sentToken(prefix string) string {
+	if len(at.mailer.sent) == 0 {
+		return ""
+	}
+	for _, field := range strings.Fields(at.mailer.sent[len(at.mailer.sent)-1].Body) {
+		if strings.HasPrefix(field, prefix) {
+			return field
+		}
+	}
+	return ""
+}
+
+func TestForgotPasswordKeepsTokenOutOfJobPayload(t *testing.T) {
+	at := newAccountTest(entity.User{ID: 7, Username: "budi", Email: "budi@example.com"})
+	s, tokens, jobs, m := at.service, at.tokens, at.jobs, at.mailer
+
+	if err := s.ForgotPassword(context.Background(), "Budi@Example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.jobs) != 1 || jobs.jobs[0].Name != JobSendAccountEmail {
+		t.Fatalf("unexpected jobs %+v", jobs.jobs)
+	}
+	payload := json.RawMessage(jobs.jobs[0].Payload)
+	if string(payload) != `{"user_token_id":1}` {
+		t.Fatalf("job payload %s", payload)
+	}
+
+	if err := s.SendTokenEmail(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.sent) != 1 || m.sent[0].To != "budi@example.com" {
+		t.Fatalf("unexpected emails %+v", m.sent)
+	}
+	token := at.sentToken("reset_")
+	if token == "" || securetoken.Hash(token) != tokens.tokens[0].TokenHash {
+		t.Fatalf("email does not contain the stored token: %q", m.sent[0].Body)
+	}
+
+	// job yang dijalankan ulang membatalkan token dari percobaan sebelumnya
+	if err := s.SendTokenEmail(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if securetoken.Hash(token) == tokens.tokens[0].TokenHash {
+		t.Error("retried job kept the previous token")
+	}
+
+	// token yang sudah diganti oleh permintaan baru tidak dikirim lagi
+	if err := s.ForgotPassword(context.Background(), "budi@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SendTokenEmail(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.sent) != 2 {
+		t.Errorf("sent %d emails, want 2", len(m.sent))
+	}
+}
+
+func TestResetPasswordRevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	at := newAccountTest(entity.User{ID: 7, Username: "budi", Email: "budi@example.com"})
+	if err := at.service.ForgotPassword(ctx, "budi@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := at.service.SendTokenEmail(ctx, json.RawMessage(at.jobs.jobs[0].Payload)); err != nil {
+		t.Fatal(err)
+	}
+	token := at.sentToken("reset_")
+
+	if err := at.service.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatal(err)
+	}
+	if at.users.user.Password == "" || at.users.user.EmailVerifiedAt == nil {
+		t.Errorf("password or email verification not updated: %+v", at.users.user)
+	}
+	for name, revoked := range map[string][]uint{
+		"access tokens": at.accessTokens.revoked,
+		"app passwords": at.appPasswords.revoked,
+		"feed tokens":   at.feedTokens.revoked,
+	} {
+		if len(revoked) != 1 || revoked[0] != 7 {
+			t.Errorf("%s revoked for users %v, want [7]", name, revoked)
+		}
+	}
+	if len(at.publisher.events) != 1 || at.publisher.events[0].Type != event.TypeUserTokensRevoked || at.publisher.events[0].UserID != 7 {
+		t.Errorf("published %+v, want one %s event", at.publisher.events, event.TypeUserTokensRevoked)
+	}
+
+	if err := at.service.ResetPassword(ctx, token, "another-password"); err != ErrInvalidResetToken {
+		t.Errorf("reused reset token = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestChangeEmailNormalizesAndIgnoresUnverifiedClaims(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+
+	at := newAccountTest(entity.User{ID: 7, Username: "budi"})
+	at.users.others = []entity.User{{ID: 8, Email: "shared@example.com"}}
+	if err := at.service.ChangeEmail(ctx, 7, "  Shared@Example.COM "); err != nil {
+		t.Fatalf("email claimed without verification blocked the change: %v", err)
+	}
+	if at.users.user.Email != "shared@example.com" {
+		t.Errorf("stored email %q, want it lowercased and trimmed", at.users.user.Email)
+	}
+
+	at = newAccountTest(entity.User{ID: 7, Username: "budi"})
+	at.users.others = []entity.User{{ID: 8, Email: "shared@example.com", EmailVerifiedAt: &verifiedAt}}
+	if err := at.service.ChangeEmail(ctx, 7, "shared@example.com"); err != ErrEmailTaken {
+		t.Errorf("ChangeEmail to a verified email of another user = %v, want ErrEmailTaken", err)
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/securetoken"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAppPassword  = errors.New("username or app password invalid")
+	ErrAppPasswordNotFound = errors.New("app password not found")
+	ErrAppPasswordName     = errors.New("name is required and must not exceed 100 characters")
+)
+
+type AppPasswordService interface {
+	CreateAppPassword(ctx context.Context, userID uint, name string) (string, *entity.AppPassword, error)
+	GetAppPasswords(ctx context.Context, userID uint) ([]entity.AppPassword, error)
+	RevokeAppPassword(ctx context.Context, userID, id uint) error
+	Authenticate(ctx context.Context, username, password string) (*entity.User, error)
+}
+
+type appPasswordService struct {
+	appPasswordRepo repository.AppPasswordRepository
+	userRepository  repository.UserRepository
+	auditService    AuditService
+}
+
+func NewAppPasswordService(
+	appPasswordRepo repository.AppPasswordRepository,
+	userRepository repository.UserRepository,
+	auditService AuditService,
+) AppPasswordService {
+	return &appPasswordService{appPasswordRepo, userRepository, auditService}
+}
+
+// CreateAppPassword mengembalikan password dalam bentuk plain text. Password
+// hanya ditampilkan sekali karena yang disimpan hanya hash-nya.
+func (s *appPasswordService) CreateAppPassword(ctx context.Context, userID uint, name string) (string, *entity.AppPassword, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", nil, ErrAppPasswordName
+	}
+	password, hash, err := securetoken.Generate("app_")
+	if err != nil {
+		return "", nil, err
+	}
+	appPassword := &entity.AppPassword{UserID: userID, Name: name, PasswordHash: hash}
+	if err := s.appPasswordRepo.Create(ctx, appPassword); err != nil {
+		return "", nil, err
+	}
+	s.audit(ctx, entity.AuditAppPasswordCreated, userID, map[string]interface{}{
+		"app_password_id": appPassword.ID,
+		"name":            name,
+	})
+	return password, appPassword, nil
+}
+
+func (s *appPasswordService) GetAppPasswords(ctx context.Context, userID uint) ([]entity.AppPassword, error) {
+	return s.appPasswordRepo.GetByUserID(ctx, userID)
+}
+
+func (s *appPasswordService) RevokeAppPassword(ctx context.Context, userID, id uint) error {
+	revoked, err := s.appPasswordRepo.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrAppPasswordNotFound
+	}
+	s.audit(ctx, entity.AuditAppPasswordRevoked, userID, map[string]interface{}{"app_password_id": id})
+	return nil
+}
+
+// Authenticate memeriksa pasangan username dan app password dari HTTP
+// Basic auth.
+func (s *appPasswordService) Authenticate(ctx context.Context, username, password string) (*entity.User, error) {
+	appPassword, err := s.appPasswordRepo.FindActiveByHash(ctx, securetoken.Hash(password))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAppPassword
+		}
+		return nil, err
+	}
+	user, err := s.userRepository.FindByUsername(ctx, username)
+	if err != nil || uint(user.ID) != appPassword.UserID {
+		return nil, ErrInvalidAppPassword
+	}
+	if err := s.appPasswordRepo.TouchLastUsed(ctx, appPassword.ID); err != nil {
+		log.Printf("failed to update last_used_at of app password %d: %v", appPassword.ID, err)
+	}
+	return user, nil
+}
+
+func (s *appPasswordService) audit(ctx context.Context, action string, userID uint, metadata map[string]interface{}) {
+	if err := s.auditService.Record(ctx, action, "user", strconv.FormatUint(uint64(userID), 10), metadata); err != nil {
+		log.Printf("failed to record audit log %s: %v", action, err)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/actor"
+)
+
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt *uint  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+var errStopWalk = errors.New("stop walking audit log")
+
+type AuditService interface {
+	Record(ctx context.Context, action, targetType, targetID string, metadata map[string]interface{}) error
+	Find(ctx context.Context, filter repository.AuditLogFilter) ([]entity.AuditLog, error)
+	Verify(ctx context.Context) (*AuditVerification, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditService{auditRepo}
+}
+
+// Record menyimpan audit log dengan actor, IP, user agent dan request ID
+// yang diambil dari ctx.
+func (s *auditService) Record(ctx context.Context, action, targetType, targetID string, metadata map[string]interface{}) error {
+	log := &entity.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if a, ok := actor.FromContext(ctx); ok {
+		if a.UserID != 0 {
+			actorID := a.UserID
+			log.ActorID = &actorID
+		}
+		log.ActorRole = a.Role
+		log.IP = a.IP
+		log.UserAgent = a.UserAgent
+		log.RequestID = a.RequestID
+	}
+	if metadata != nil {
+		b, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		log.Metadata = entity.RawJSON(b)
+	}
+	return s.auditRepo.Append(ctx, log)
+}
+
+func (s *auditService) Find(ctx context.Context, filter repository.AuditLogFilter) ([]entity.AuditLog, error) {
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 100
+	}
+	return s.auditRepo.Find(ctx, filter)
+}
+
+// Verify menghitung ulang hash chain dari awal dan melaporkan baris pertama
+// yang tidak cocok. Chain juga dibandingkan dengan head yang dicatat saat
+// Append, sehingga penghapusan baris terbaru ikut terdeteksi.
+func (s *auditService) Verify(ctx context.Context) (*AuditVerification, error) {
+	// head dibaca lebih dulu, log yang ditambahkan selama walk berada
+	// setelah head
+	headCount, headHash, headFound, err := s.auditRepo.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditVerification{Valid: true}
+	prevHash := ""
+	hashAtHead := ""
+	err = s.auditRepo.Walk(ctx, func(log *entity.AuditLog) error {
+		result.Checked++
+		if int64(result.Checked) == headCount {
+			hashAtHead = log.Hash
+		}
+		switch {
+		case log.PrevHash != prevHash:
+			result.Reason = "previous hash mismatch, an entry may have been removed"
+		case log.ComputeHash() != log.Hash:
+			result.Reason = "hash mismatch, the entry may have been modified"
+		default:
+			prevHash = log.Hash
+			return nil
+		}
+		id := log.ID
+		result.Valid = false
+		result.BrokenAt = &id
+		return errStopWalk
+	})
+	if err != nil && !errors.Is(err, errStopWalk) {
+		return nil, err
+	}
+	if !result.Valid {
+		return result, nil
+	}
+
+	switch {
+	case !headFound && result.Checked > 0:
+		result.Reason = "chain head is missing"
+	case int64(result.Checked) < headCount:
+		result.Reason = "fewer entries than the recorded head, the newest entries may have been removed"
+	case headCount > 0 && hashAtHead != headHash:
+		result.Reason = "head hash mismatch, the newest entries may have been replaced"
+	default:
+		return result, nil
+	}
+	result.Valid = false
+	return result, nil
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+	"todo-list/internal/digest"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/mailer"
+	"todo-list/pkg/securetoken"
+
+	"gorm.io/gorm"
+)
+
+const (
+	digestBatch       = 100
+	digestMaxTodos    = 50
+	defaultDigestHour = 7
+)
+
+var (
+	ErrDigestUnavailable     = errors.New("email digest is not available because email delivery is not configured")
+	ErrDigestNoEmail         = errors.New("set an email address on your account before subscribing to the digest")
+	ErrDigestEmailUnverified = errors.New("verify the email address on your account before subscribing to the digest")
+	ErrInvalidDigest         = errors.New("frequency must be daily or weekly, hour between 0 and 23 and weekday between 0 (Sunday) and 6")
+	ErrInvalidTimezone       = errors.New("timezone must be a valid IANA time zone, for example Asia/Jakarta")
+	ErrDigestNotSubscribed   = errors.New("not subscribed to the digest")
+	ErrInvalidUnsubscribeURL = errors.New("unsubscribe link is invalid, already used or replaced by a newer digest")
+)
+
+// DigestService mengirim ringkasan todo overdue, due hari ini dan yang baru
+// selesai ke user yang opt-in.
+type DigestService interface {
+	Subscribe(ctx context.Context, userID uint, frequency, timezone string, hour, weekday int) (*entity.DigestSubscription, error)
+	GetSubscription(ctx context.Context, userID uint) (*entity.DigestSubscription, error)
+	Unsubscribe(ctx context.Context, userID uint) error
+	UnsubscribeByToken(ctx context.Context, token string) error
+	Preview(ctx context.Context, userID uint) (*mailer.Message, error)
+	SendDue(ctx context.Context) (int, error)
+}
+
+type digestService struct {
+	digestRepo     repository.DigestRepository
+	todoRepo       repository.TodoRepository
+	userRepository repository.UserRepository
+	jobService     JobService
+	transactor     repository.Transactor
+	baseURL        string
+	enabled        bool
+}
+
+// NewDigestService memakai baseURL untuk membuat link unsubscribe. enabled
+// false berarti email tidak bisa dikirim sehingga subscribe ditolak.
+func NewDigestService(
+	digestRepo repository.DigestRepository,
+	todoRepo repository.TodoRepository,
+	userRepository repository.UserRepository,
+	jobService JobService,
+	transactor repository.Transactor,
+	baseURL string,
+	enabled bool,
+) DigestService {
+	return &digestService{digestRepo, todoRepo, userRepository, jobService, transactor, baseURL, enabled}
+}
+
+func (s *digestService) Subscribe(ctx context.Context, userID uint, frequency, timezone string, hour, weekday int) (*entity.DigestSubscription, error) {
+	if !s.enabled {
+		return nil, ErrDigestUnavailable
+	}
+	if (frequency != entity.DigestDaily && frequency != entity.DigestWeekly) || hour < 0 || hour > 23 || weekday < 0 || weekday > 6 {
+		return nil, ErrInvalidDigest
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	user, err := s.userRepository.FindByID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == "" {
+		return nil, ErrDigestNoEmail
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil, ErrDigestEmailUnverified
+	}
+
+	subscription, err := s.digestRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// token asli baru dikirim bersama digest, di sini hanya mengisi hash
+		// agar unique index terpenuhi
+		_, hash, err := securetoken.Generate("unsub_")
+		if err != nil {
+			return nil, err
+		}
+		subscription = &entity.DigestSubscription{UserID: userID, UnsubscribeTokenHash: hash}
+	} else if err != nil {
+		return nil, err
+	}
+	subscription.Frequency = frequency
+	subscription.Timezone = timezone
+	subscription.Hour = hour
+	subscription.Weekday = weekday
+	subscription.NextSendAt = nextDigestAt(subscription, time.Now())
+	if err := s.digestRepo.Save(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *digestService) GetSubscription(ctx context.Context, userID uint) (*entity.DigestSubscription, error) {
+	subscription, err := s.digestRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDigestNotSubscribed
+	}
+	return subscription, err
+}
+
+func (s *digestService) Unsubscribe(ctx context.Context, userID uint) error {
+	deleted, err := s.digestRepo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDigestNotSubscribed
+	}
+	return nil
+}
+
+func (s *digestService) UnsubscribeByToken(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrInvalidUnsubscribeURL
+	}
+	deleted, err := s.digestRepo.DeleteByUnsubscribeTokenHash(ctx, securetoken.Hash(token))
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrInvalidUnsubscribeURL
+	}
+	return nil
+}
+
+// Preview merender digest dengan isi saat ini tanpa mengirimnya dan tanpa
+// link unsubscribe. User yang belum subscribe melihat versi daily dalam UTC.
+func (s *digestService) Preview(ctx context.Context, userID uint) (*mailer.Message, error) {
+	user, err := s.userRepository.FindByID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := s.digestRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		subscription = &entity.DigestSubscription{UserID: userID, Frequency: entity.DigestDaily, Timezone: "UTC", Hour: defaultDigestHour}
+	} else if err != nil {
+		return nil, err
+	}
+	d, err := s.build(ctx, user, subscription, time.Now(), "")
+	if err != nil {
+		return nil, err
+	}
+	msg, err := digest.Render(d)
+	if err != nil {
+		return nil, err
+	}
+	msg.To = user.Email
+	return msg, nil
+}
+
+// SendDue menjadwalkan email untuk digest yang sudah waktunya. Digest tanpa
+// isi tidak dikirim, tapi jadwal berikutnya tetap dihitung.
+func (s *digestService) SendDue(ctx context.Context) (int, error) {
+	if !s.enabled {
+		return 0, nil
+	}
+	now := time.Now()
+	subscriptions, err := s.digestRepo.FindDue(ctx, now, digestBatch)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	var errs []error
+	for i := range subscriptions {
+		ok, err := s.send(ctx, &subscriptions[i], now)
+		if err != nil {
+			log.Printf("failed to send digest to user %d: %v", subscriptions[i].UserID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (s *digestService) send(ctx context.Context, subscription *entity.DigestSubscription, now time.Time) (bool, error) {
+	next := nextDigestAt(subscription, now)
+	user, err := s.userRepository.FindByID(ctx, int64(subscription.UserID))
+	if err != nil {
+		return false, err
+	}
+	// token unsubscribe diganti setiap pengiriman karena yang disimpan hanya
+	// hash-nya, link dari digest sebelumnya tidak berlaku lagi
+	token, hash, err := securetoken.Generate("unsub_")
+	if err != nil {
+		return false, err
+	}
+	d, err := s.build(ctx, user, subscription, now, token)
+	if err != nil {
+		return false, err
+	}
+	// email bisa diganti (dan belum diverifikasi) setelah subscribe
+	if user.Email == "" || user.EmailVerifiedAt == nil || d.Empty() {
+		_, err := s.digestRepo.UpdateSchedule(ctx, subscription.ID, subscription.NextSendAt, nil, next)
+		return false, err
+	}
+	msg, err := digest.Render(d)
+	if err != nil {
+		return false, err
+	}
+	msg.To = user.Email
+
+	// email hanya dijadwalkan sekali untuk setiap jadwal digest
+	sent := false
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		updated, err := s.digestRepo.UpdateSchedule(ctx, subscription.ID, subscription.NextSendAt, &now, next)
+		if err != nil || !updated {
+			return err
+		}
+		if err := s.digestRepo.UpdateUnsubscribeTokenHash(ctx, subscription.ID, hash); err != nil {
+			return err
+		}
+		if _, err := s.jobService.Enqueue(ctx, JobSendEmail, msg, now); err != nil {
+			return err
+		}
+		sent = true
+		return nil
+	})
+	return sent, err
+}
+
+// build mengumpulkan todo untuk digest. Overdue dan due hari ini dihitung
+// berdasarkan hari di timezone user, todo yang selesai diambil dari 1 hari
+// (daily) atau 7 hari (weekly) terakhir.
+func (s *digestService) build(ctx context.Context, user *entity.User, subscription *entity.DigestSubscription, now time.Time, unsubscribeToken string) (*digest.Digest, error) {
+	loc, err := time.LoadLocation(subscription.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	startOfDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+
+	open, err := s.todoRepo.GetOpenDueBefore(ctx, uint(user.ID), endOfDay, digestMaxTodos)
+	if err != nil {
+		return nil, err
+	}
+	since := now.AddDate(0, 0, -1)
+	if subscription.Frequency == entity.DigestWeekly {
+		since = now.AddDate(0, 0, -7)
+	}
+	completed, err := s.todoRepo.GetCompletedSince(ctx, uint(user.ID), since, digestMaxTodos)
+	if err != nil {
+		return nil, err
+	}
+
+	name := user.FullName
+	if name == "" {
+		name = user.Username
+	}
+	d := &digest.Digest{
+		Name:      name,
+		Frequency: subscription.Frequency,
+		Date:      now,
+		Location:  loc,
+		Completed: completed,
+	}
+	for _, todo := range open {
+		if todo.DueAt.Before(startOfDay) {
+			d.Overdue = append(d.Overdue, todo)
+		} else {
+			d.DueToday = append(d.DueToday, todo)
+		}
+	}
+	if unsubscribeToken != "" {
+		d.UnsubscribeURL = s.baseURL + "/api/v1/digest/unsubscribe?token=" + url.QueryEscape(unsubscribeToken)
+	}
+	return d, nil
+}
+
+// nextDigestAt mencari jam pengiriman berikutnya setelah after di timezone
+// user. time.Date menangani pergantian DST.
+func nextDigestAt(subscription *entity.DigestSubscription, after time.Time) time.Time {
+	loc, err := time.LoadLocation(subscription.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), subscription.Hour, 0, 0, 0, loc)
+	for !next.After(after) ||
+		(subscription.Frequency == entity.DigestWeekly && next.Weekday() != time.Weekday(subscription.Weekday)) {
+		next = time.Date(next.Year(), next.Month(), next.Day()+1, subscription.Hour, 0, 0, 0, loc)
+	}
+	return next
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type fakeDigestRepository struct {
+	repository.DigestRepository
+	subscription *entity.DigestSubscription
+}
+
+func (r *fakeDigestRepository) GetByUserID(ctx context.Context, userID uint) (*entity.DigestSubscription, error) {
+	if r.subscription == nil || r.subscription.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.subscription, nil
+}
+
+func (r *fakeDigestRepository) Save(ctx context.Context, subscription *entity.DigestSubscription) error {
+	r.subscription = subscription
+	return nil
+}
+
+func (r *fakeDigestRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]entity.DigestSubscription, error) {
+	if r.subscription == nil || r.subscription.NextSendAt.After(now) {
+		return nil, nil
+	}
+	return []entity.DigestSubscription{*r.subscription}, nil
+}
+
+func (r *fakeDigestRepository) UpdateSchedule(ctx context.Context, id uint, prevNextSendAt time.Time, lastSentAt *time.Time, nextSendAt time.Time) (bool, error) {
+	r.subscription.LastSentAt = lastSentAt
+	r.subscription.NextSendAt = nextSendAt
+	return true, nil
+}
+
+func (r *fakeDigestRepository) UpdateUnsubscribeTokenHash(ctx context.Context, id uint, hash string) error {
+	r.subscription.UnsubscribeTokenHash = hash
+	return nil
+}
+
+// fakeDigestTodoRepository selalu mengembalikan satu todo agar digest tidak
+// kosong.
+type fakeDigestTodoRepository struct {
+	repository.TodoRepository
+}
+
+func (r *fakeDigestTodoRepository) GetOpenDueBefore(ctx context.Context, userID uint, before time.Time, limit int) ([]entity.Todo, error) {
+	dueAt := before.Add(-48 * time.Hour)
+	return []entity.Todo{{ID: 1, UserID: userID, Title: "overdue", DueAt: &dueAt}}, nil
+}
+
+func (r *fakeDigestTodoRepository) GetCompletedSince(ctx context.Context, userID uint, since time.Time, limit int) ([]entity.Todo, error) {
+	return nil, nil
+}
+
+func newDigestTest(user entity.User) (DigestService, *fakeDigestRepository, *fakeJobService) {
+	digests := &fakeDigestRepository{}
+	jobs := &fakeJobService{}
+	s := NewDigestService(digests, &fakeDigestTodoRepository{}, &fakeAccountUserRepository{user: user}, jobs, fakeTodoTransactor{}, "https://todo.example.com", true)
+	return s, digests, jobs
+}
+
+func TestDigestSubscribeRequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newDigestTest(entity.User{ID: 1, Email: "a@example.com"})
+	if _, err := s.Subscribe(ctx, 1, entity.DigestDaily, "UTC", 7, 0); !errors.Is(err, ErrDigestEmailUnverified) {
+		t.Errorf("Subscribe with unverified email = %v, want ErrDigestEmailUnverified", err)
+	}
+
+	verifiedAt := time.Now()
+	s, _, _ = newDigestTest(entity.User{ID: 1, Email: "a@example.com", EmailVerifiedAt: &verifiedAt})
+	if _, err := s.Subscribe(ctx, 1, entity.DigestDaily, "UTC", 7, 0); err != nil {
+		t.Errorf("Subscribe with verified email: %v", err)
+	}
+}
+
+func TestDigestSkipsUnverifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	for _, c := range []struct {
+		name       string
+		verifiedAt *time.Time
+		want       int
+	}{
+		{"unverified", nil, 0},
+		{"verified", &verifiedAt, 1},
+	} {
+		s, digests, jobs := newDigestTest(entity.User{ID: 1, Email: "a@example.com", EmailVerifiedAt: c.verifiedAt})
+		due := time.Now().Add(-time.Minute)
+		digests.subscription = &entity.DigestSubscription{ID: 1, UserID: 1, Frequency: entity.DigestDaily, Timezone: "UTC", NextSendAt: due}
+
+		sent, err := s.SendDue(context.Background())
+		if err != nil || sent != c.want || len(jobs.enqueued) != c.want {
+			t.Errorf("%s: SendDue = %d, %v with %d emails; want %d", c.name, sent, err, len(jobs.enqueued), c.want)
+		}
+		if !digests.subscription.NextSendAt.After(due) {
+			t.Errorf("%s: next_send_at was not advanced", c.name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/securetoken"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidFeedToken = errors.New("feed token is invalid or has been revoked")
+
+// FeedService mengelola token feed kalender. Token ini terpisah dari JWT
+// karena aplikasi kalender tidak bisa mengirim Bearer token.
+type FeedService interface {
+	CreateFeedToken(ctx context.Context, userID uint) (string, error)
+	RevokeFeedToken(ctx context.Context, userID uint) error
+	UserIDByFeedToken(ctx context.Context, token string) (uint, error)
+	FeedURL(token string) string
+}
+
+type feedService struct {
+	feedTokenRepo repository.FeedTokenRepository
+	transactor    repository.Transactor
+	baseURL       string
+}
+
+// NewFeedService memakai baseURL untuk membuat URL feed.
+func NewFeedService(feedTokenRepo repository.FeedTokenRepository, transactor repository.Transactor, baseURL string) FeedService {
+	return &feedService{feedTokenRepo, transactor, baseURL}
+}
+
+// CreateFeedToken membuat token baru dan mencabut token lama, sehingga
+// setiap user hanya punya satu URL feed yang aktif.
+func (s *feedService) CreateFeedToken(ctx context.Context, userID uint) (string, error) {
+	token, hash, err := securetoken.Generate("feed_")
+	if err != nil {
+		return "", err
+	}
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.feedTokenRepo.RevokeByUserID(ctx, userID); err != nil {
+			return err
+		}
+		return s.feedTokenRepo.Create(ctx, &entity.FeedToken{UserID: userID, TokenHash: hash})
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *feedService) RevokeFeedToken(ctx context.Context, userID uint) error {
+	return s.feedTokenRepo.RevokeByUserID(ctx, userID)
+}
+
+func (s *feedService) UserIDByFeedToken(ctx context.Context, token string) (uint, error) {
+	feedToken, err := s.feedTokenRepo.FindActiveByHash(ctx, securetoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrInvalidFeedToken
+		}
+		return 0, err
+	}
+	return feedToken.UserID, nil
+}
+
+func (s *feedService) FeedURL(token string) string {
+	return s.baseURL + "/api/v1/feeds/" + url.PathEscape(token) + "/todos.ics"
+}
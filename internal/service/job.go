@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+)
+
+const (
+	jobBatch        = 20
+	maxJobPageLimit = 200
+	maxJobBackoff   = 6 * time.Hour
+)
+
+var ErrInvalidJobStatus = errors.New("status must be one of pending, running, succeeded or failed")
+
+// JobHandler menjalankan satu job. Payload adalah JSON yang diberikan saat
+// job di-enqueue.
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobService menyimpan job di Postgres. Job dijalankan oleh RunDue di worker,
+// row locking memastikan setiap job hanya dijalankan satu replica.
+type JobService interface {
+	Enqueue(ctx context.Context, name string, payload interface{}, runAt time.Time) (*entity.Job, error)
+	EnqueueUnique(ctx context.Context, key, name string, payload interface{}, runAt time.Time) (bool, error)
+	GetJobs(ctx context.Context, filter repository.JobFilter) ([]entity.Job, error)
+	RunDue(ctx context.Context, handlers map[string]JobHandler) (int, error)
+	PurgeFinished(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type jobService struct {
+	jobRepo     repository.JobRepository
+	maxAttempts int
+	backoffBase time.Duration
+	lease       time.Duration
+}
+
+func NewJobService(jobRepo repository.JobRepository, maxAttempts int, backoffBase, lease time.Duration) JobService {
+	return &jobService{jobRepo, maxAttempts, backoffBase, lease}
+}
+
+func (s *jobService) newJob(name string, payload interface{}, runAt time.Time) (*entity.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Job{
+		Name:        name,
+		Payload:     entity.RawJSON(data),
+		Status:      entity.JobPending,
+		MaxAttempts: s.maxAttempts,
+		RunAt:       runAt,
+	}, nil
+}
+
+// Enqueue menjadwalkan job sekali jalan pada runAt. Jika ctx berada di dalam
+// transaksi, job ikut transaksi tersebut.
+func (s *jobService) Enqueue(ctx context.Context, name string, payload interface{}, runAt time.Time) (*entity.Job, error) {
+	job, err := s.newJob(name, payload, runAt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// EnqueueUnique mengembalikan false jika job dengan key yang sama sudah
+// pernah di-enqueue.
+func (s *jobService) EnqueueUnique(ctx context.Context, key, name string, payload interface{}, runAt time.Time) (bool, error) {
+	job, err := s.newJob(name, payload, runAt)
+	if err != nil {
+		return false, err
+	}
+	job.UniqueKey = &key
+	return s.jobRepo.Create(ctx, job)
+}
+
+func (s *jobService) GetJobs(ctx context.Context, filter repository.JobFilter) ([]entity.Job, error) {
+	switch filter.Status {
+	case "", entity.JobPending, entity.JobRunning, entity.JobSucceeded, entity.JobFailed:
+	default:
+		return nil, ErrInvalidJobStatus
+	}
+	if filter.Limit <= 0 || filter.Limit > maxJobPageLimit {
+		filter.Limit = maxJobPageLimit
+	}
+	return s.jobRepo.Find(ctx, filter)
+}
+
+// RunDue menjalankan job yang sudah waktunya dan mengembalikan jumlah yang
+// berhasil. Job yang gagal dicoba ulang dengan backoff eksponensial sampai
+// MaxAttempts.
+func (s *jobService) RunDue(ctx context.Context, handlers map[string]JobHandler) (int, error) {
+	jobs, err := s.jobRepo.ClaimDue(ctx, jobBatch, s.lease)
+	if err != nil {
+		return 0, err
+	}
+	succeeded := 0
+	for i := range jobs {
+		job := &jobs[i]
+		err := runJob(ctx, handlers[job.Name], job)
+		now := time.Now()
+		switch {
+		case err == nil:
+			job.Status = entity.JobSucceeded
+			job.LastError = ""
+			job.FinishedAt = &now
+		case job.Attempts >= job.MaxAttempts:
+			job.Status = entity.JobFailed
+			job.LastError = truncate(err.Error(), 1000)
+			job.FinishedAt = &now
+		default:
+			job.Status = entity.JobPending
+			job.LastError = truncate(err.Error(), 1000)
+			job.RunAt = now.Add(s.backoff(job.Attempts))
+		}
+		if err != nil {
+			log.Printf("job %s #%d failed (attempt %d/%d): %v", job.Name, job.ID, job.Attempts, job.MaxAttempts, err)
+		}
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			if errors.Is(err, repository.ErrJobLeaseLost) {
+				log.Printf("job %s #%d: %v", job.Name, job.ID, err)
+				continue
+			}
+			return succeeded, err
+		}
+		if job.Status == entity.JobSucceeded {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// runJob mengubah panic dari handler menjadi error agar job lain tetap
+// berjalan.
+func runJob(ctx context.Context, handler JobHandler, job *entity.Job) (err error) {
+	if handler == nil {
+		return fmt.Errorf("no handler registered for job %s", job.Name)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+func (s *jobService) backoff(attempts int) time.Duration {
+	delay := s.backoffBase
+	for i := 1; i < attempts && delay < maxJobBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxJobBackoff {
+		delay = maxJobBackoff
+	}
+	return delay
+}
+
+func (s *jobService) PurgeFinished(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.jobRepo.DeleteFinishedBefore(ctx, time.Now().Add(-retention))
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+)
+
+type fakeJobRepository struct {
+	repository.JobRepository
+	due []entity.Job
+	// lost berisi id job yang sudah diklaim ulang replica lain
+	lost    map[uint]bool
+	updated []entity.Job
+}
+
+func (r *fakeJobRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.Job, error) {
+	return r.due, nil
+}
+
+func (r *fakeJobRepository) Update(ctx context.Context, job *entity.Job) error {
+	if r.lost[job.ID] {
+		return repository.ErrJobLeaseLost
+	}
+	r.updated = append(r.updated, *job)
+	return nil
+}
+
+func TestRunDueSkipsJobsWithLostLease(t *testing.T) {
+	now := time.Now()
+	repo := &fakeJobRepository{
+		due: []entity.Job{
+			{ID: 1, Name: "ok", Status: entity.JobRunning, Attempts: 1, MaxAttempts: 3, StartedAt: &now},
+			{ID: 2, Name: "ok", Status: entity.JobRunning, Attempts: 1, MaxAttempts: 3, StartedAt: &now},
+		},
+		lost: map[uint]bool{1: true},
+	}
+	s := NewJobService(repo, 3, time.Second, time.Minute)
+
+	succeeded, err := s.RunDue(context.Background(), map[string]JobHandler{
+		"ok": func(ctx context.Context, payload json.RawMessage) error { return nil },
+	})
+	if err != nil || succeeded != 1 {
+		t.Fatalf("RunDue = %d, %v; want 1 succeeded", succeeded, err)
+	}
+	if len(repo.updated) != 1 || repo.updated[0].ID != 2 {
+		t.Errorf("updated jobs = %v, want only job 2", repo.updated)
+	}
+}
+
+func TestRunDueFailsJobOnLastAttempt(t *testing.T) {
+	now := time.Now()
+	repo := &fakeJobRepository{due: []entity.Job{
+		{ID: 1, Name: "broken", Status: entity.JobRunning, Attempts: 3, MaxAttempts: 3, StartedAt: &now},
+	}}
+	s := NewJobService(repo, 3, time.Second, time.Minute)
+
+	if _, err := s.RunDue(context.Background(), map[string]JobHandler{
+		"broken": func(ctx context.Context, payload json.RawMessage) error { return errors.New("boom") },
+	}); err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	if job := repo.updated[0]; job.Status != entity.JobFailed || job.FinishedAt == nil {
+		t.Errorf("job status = %s, finished_at = %v; want failed", job.Status, job.FinishedAt)
+	}
+}
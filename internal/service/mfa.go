@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/securetoken"
+	"todo-list/pkg/totp"
+
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew menerima kode satu periode sebelum dan sesudahnya
+	totpSkew = 1
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled    = errors.New("start two-factor enrollment first")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+	ErrMFARequired       = errors.New("two-factor authentication is required for admins and cannot be disabled")
+)
+
+// MFAEnrollment berisi secret yang harus dimasukkan ke authenticator app,
+// baik manual, lewat otpauth URI atau QR code (PNG dalam data URI).
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode string `json:"qr_code"`
+}
+
+type MFAStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"`
+	ConfirmedAt       *time.Time `json:"confirmed_at"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+// MFAService mengelola 2FA berbasis TOTP (RFC 6238) dan recovery code.
+type MFAService interface {
+	Status(ctx context.Context, userID uint) (*MFAStatus, error)
+	Enroll(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	Confirm(ctx context.Context, userID uint, code string) ([]string, error)
+	Disable(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	Verify(ctx context.Context, userID uint, code string) error
+	Enabled(ctx context.Context, userID uint) (bool, error)
+	Required(ctx context.Context, role string) (bool, error)
+	AdminRequired(ctx context.Context) (bool, error)
+	SetAdminRequired(ctx context.Context, required bool) error
+}
+
+type mfaService struct {
+	mfaRepository     repository.MFARepository
+	settingRepository repository.SettingRepository
+	userRepository    repository.UserRepository
+	transactor        repository.Transactor
+	auditService      AuditService
+	issuer            string
+}
+
+func NewMFAService(
+	mfaRepository repository.MFARepository,
+	settingRepository repository.SettingRepository,
+	userRepository repository.UserRepository,
+	transactor repository.Transactor,
+	auditService AuditService,
+	issuer string,
+) MFAService {
+	return &mfaService{mfaRepository, settingRepository, userRepository, transactor, auditService, issuer}
+}
+
+func (s *mfaService) Status(ctx context.Context, userID uint) (*MFAStatus, error) {
+	user, err := s.userRepository.FindByID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	status := new(MFAStatus)
+	if status.Required, err = s.Required(ctx, user.Role); err != nil {
+		return nil, err
+	}
+	secret, err := s.mfaRepository.FindTOTP(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && secret.ConfirmedAt == nil) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled = true
+	status.ConfirmedAt = secret.ConfirmedAt
+	if status.RecoveryCodesLeft, err = s.mfaRepository.CountRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Enroll membuat secret baru yang belum aktif. Memanggil Enroll lagi
+// sebelum Confirm akan mengganti secret sebelumnya.
+func (s *mfaService) Enroll(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	if enabled, err := s.Enabled(ctx, userID); err != nil {
+		return nil, err
+	} else if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	user, err := s.userRepository.FindByID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepository.SaveTOTP(ctx, &entity.UserTOTP{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	account := user.Username
+	if user.Email != "" {
+		account = user.Email
+	}
+	uri := totp.URI(s.issuer, account, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm mengaktifkan 2FA setelah user membuktikan authenticator-nya
+// menghasilkan kode yang benar, lalu mengembalikan recovery code. Recovery
+// code hanya ditampilkan sekali.
+func (s *mfaService) Confirm(ctx context.Context, userID uint, code string) ([]string, error) {
+	secret, err := s.mfaRepository.FindTOTP(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	step, ok := totp.Validate(secret.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		confirmed, err := s.mfaRepository.ConfirmTOTP(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrMFAAlreadyEnabled
+		}
+		codes, err = s.replaceRecoveryCodes(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, entity.AuditMFAEnabled, userID, nil)
+	return codes, nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.userRepository.FindByID(ctx, int64(userID))
+	if err != nil {
+		return err
+	}
+	if required, err := s.Required(ctx, user.Role); err != nil {
+		return err
+	} else if required {
+		return ErrMFARequired
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := s.mfaRepository.DeleteTOTP(ctx, userID); err != nil {
+		return err
+	}
+	s.audit(ctx, entity.AuditMFADisabled, userID, nil)
+	return nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, entity.AuditMFARecoveryCodesRegenerated, userID, nil)
+	return codes, nil
+}
+
+// Verify menerima kode TOTP atau recovery code. Keduanya hanya bisa dipakai
+// sekali.
+func (s *mfaService) Verify(ctx context.Context, userID uint, code string) error {
+	secret, err := s.mfaRepository.FindTOTP(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && secret.ConfirmedAt == nil) {
+		return ErrMFANotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		used, err := s.mfaRepository.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.mfaRepository.UseRecoveryCode(ctx, userID, securetoken.Hash(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	s.audit(ctx, entity.AuditMFARecoveryCodeUsed, userID, nil)
+	return nil
+}
+
+func (s *mfaService) Enabled(ctx context.Context, userID uint) (bool, error) {
+	secret, err := s.mfaRepository.FindTOTP(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return secret.ConfirmedAt != nil, nil
+}
+
+func (s *mfaService) Required(ctx context.Context, role string) (bool, error) {
+	if role != "admin" {
+		return false, nil
+	}
+	return s.AdminRequired(ctx)
+}
+
+func (s *mfaService) AdminRequired(ctx context.Context) (bool, error) {
+	value, _, err := s.settingRepository.Get(ctx, entity.SettingRequireAdminMFA)
+	if err != nil {
+		return false, err
+	}
+	return value == "true", nil
+}
+
+func (s *mfaService) SetAdminRequired(ctx context.Context, required bool) error {
+	if err := s.settingRepository.Set(ctx, entity.SettingRequireAdminMFA, strconv.FormatBool(required)); err != nil {
+		return err
+	}
+	if err := s.auditService.Record(ctx, entity.AuditMFAPolicyChanged, "setting", entity.SettingRequireAdminMFA, map[string]interface{}{
+		"required": required,
+	}); err != nil {
+		log.Printf("failed to record audit log %s: %v", entity.AuditMFAPolicyChanged, err)
+	}
+	return nil
+}
+
+func (s *mfaService) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, securetoken.Hash(normalizeRecoveryCode(code)))
+	}
+	if err := s.mfaRepository.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) audit(ctx context.Context, action string, userID uint, metadata map[string]interface{}) {
+	if err := s.auditService.Record(ctx, action, "user", strconv.FormatUint(uint64(userID), 10), metadata); err != nil {
+		log.Printf("failed to record audit log %s: %v", action, err)
+	}
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCode membuat kode 10 karakter (50 bit) dengan format
+// xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	var b bytes.Buffer
+	for _, r := range strings.ToLower(code) {
+		if r != '-' && r != ' ' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/token"
+	"todo-list/pkg/totp"
+
+	"gorm.io/gorm"
+)
+
+type fakeMFARepository struct {
+	repository.MFARepository
+	secrets map[uint]*entity.UserTOTP
+	// recovery berisi recovery code per hash
+	recovery map[string]*entity.RecoveryCode
+}
+
+func newFakeMFARepository() *fakeMFARepository {
+	return &fakeMFARepository{secrets: map[uint]*entity.UserTOTP{}, recovery: map[string]*entity.RecoveryCode{}}
+}
+
+func (r *fakeMFARepository) FindTOTP(ctx context.Context, userID uint) (*entity.UserTOTP, error) {
+	secret, ok := r.secrets[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *secret
+	return &copied, nil
+}
+
+func (r *fakeMFARepository) SaveTOTP(ctx context.Context, secret *entity.UserTOTP) error {
+	copied := *secret
+	r.secrets[secret.UserID] = &copied
+	return nil
+}
+
+func (r *fakeMFARepository) ConfirmTOTP(ctx context.Context, userID uint, step int64) (bool, error) {
+	secret, ok := r.secrets[userID]
+	if !ok || secret.ConfirmedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	secret.ConfirmedAt = &now
+	secret.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeMFARepository) UseStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	secret, ok := r.secrets[userID]
+	if !ok || secret.ConfirmedAt == nil || secret.LastUsedStep >= step {
+		return false, nil
+	}
+	secret.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeMFARepository) DeleteTOTP(ctx context.Context, userID uint) error {
+	delete(r.secrets, userID)
+	return nil
+}
+
+func (r *fakeMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	for hash, code := range r.recovery {
+		if code.UserID == userID {
+			delete(r.recovery, hash)
+		}
+	}
+	for _, hash := range hashes {
+		r.recovery[hash] = &entity.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return nil
+}
+
+func (r *fakeMFARepository) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	code, ok := r.recovery[hash]
+	if !ok || code.UserID != userID || code.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	return true, nil
+}
+
+type fakeSettingRepository struct {
+	repository.SettingRepository
+	values map[string]string
+}
+
+func (r *fakeSettingRepository) Get(ctx context.Context, key string) (string, bool, error) {
+	value, ok := r.values[key]
+	return value, ok, nil
+}
+
+func (r *fakeSettingRepository) Set(ctx context.Context, key, value string) error {
+	r.values[key] = value
+	return nil
+}
+
+type fakeMFACache struct {
+	cache.Cacheable
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (c *fakeMFACache) Set(key string, value interface{}, duration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = fmt.Sprint(value)
+	return nil
+}
+
+func (c *fakeMFACache) Get(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *fakeMFACache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+func (c *fakeMFACache) Incr(key string, window time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count, _ := strconv.ParseInt(c.values[key], 10, 64)
+	count++
+	c.values[key] = strconv.FormatInt(count, 10)
+	return count, nil
+}
+
+type fakeTokenUseCase struct{}
+
+func (fakeTokenUseCase) GenerateAccessToken(claims token.JwtCustomClaims) (string, error) {
+	return "access-token", nil
+}
+
+func newMFATest(role string) (UserService, MFAService, *fakeMFARepository, *fakeSettingRepository) {
+	users := &fakeAccountUserRepository{user: entity.User{ID: 7, Username: "budi", Role: role}}
+	repo := newFakeMFARepository()
+	settings := &fakeSettingRepository{values: map[string]string{}}
+	mfa := NewMFAService(repo, settings, users, fakeAccountTransactor{}, fakeAccountAuditService{}, "todo-list")
+	userService := NewUserService(users, fakeTokenUseCase{}, &fakeMFACache{values: map[string]string{}}, fakeAccountAuditService{}, &fakeTodoPublisher{}, fakeAccountTransactor{}, mfa)
+	return userService, mfa, repo, settings
+}
+
+// enableMFA mendaftarkan 2FA untuk user 7 dan mengembalikan secret serta
+// recovery code-nya.
+func enableMFA(t *testing.T, mfa MFAService) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, err := mfa.Enroll(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// step sebelumnya dipakai untuk konfirmasi agar kode saat ini masih
+	// bisa dipakai untuk login
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now())-1)
+	codes, err := mfa.Confirm(ctx, 7, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enrollment.Secret, codes
+}
+
+// wrongCode mengembalikan kode 6 digit yang tidak diterima dalam skew.
+func wrongCode(secret string) string {
+	valid := map[string]bool{}
+	step := totp.Step(time.Now())
+	for i := step - 2; i <= step+2; i++ {
+		code, _ := totp.Code(secret, i)
+		valid[code] = true
+	}
+	for n := 0; ; n++ {
+		code := fmt.Sprintf("%06d", n)
+		if !valid[code] {
+			return code
+		}
+	}
+}
+
+func challenge(t *testing.T, users UserService) string {
+	t.Helper()
+	result, err := users.CompleteLogin(context.Background(), &entity.User{ID: 7, Username: "budi"})
+	if err != nil || !result.MFARequired || result.ChallengeToken == "" {
+		t.Fatalf("CompleteLogin = %+v, %v; want a challenge", result, err)
+	}
+	return result.ChallengeToken
+}
+
+func TestVerifyMFALimitsAttempts(t *testing.T) {
+	ctx := context.Background()
+	users, mfa, _, _ := newMFATest("user")
+	secret, _ := enableMFA(t, mfa)
+	challengeToken := challenge(t, users)
+
+	for i := 1; i <= maxMFAAttempts; i++ {
+		if _, err := users.VerifyMFA(ctx, challengeToken, wrongCode(secret)); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d = %v, want ErrInvalidMFACode", i, err)
+		}
+	}
+	// percobaan keenam ditolak walaupun kodenya benar
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	if _, err := users.VerifyMFA(ctx, challengeToken, code); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Errorf("attempt %d = %v, want ErrInvalidMFAChallenge", maxMFAAttempts+1, err)
+	}
+}
+
+func TestVerifyMFAChallengeIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	users, mfa, _, _ := newMFATest("user")
+	_, recoveryCodes := enableMFA(t, mfa)
+	challengeToken := challenge(t, users)
+
+	result, err := users.VerifyMFA(ctx, challengeToken, recoveryCodes[0])
+	if err != nil || result.Token == "" {
+		t.Fatalf("VerifyMFA = %+v, %v; want an access token", result, err)
+	}
+	if _, err := users.VerifyMFA(ctx, challengeToken, recoveryCodes[1]); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Errorf("reused challenge = %v, want ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestRecoveryCodeIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	users, mfa, _, _ := newMFATest("user")
+	_, recoveryCodes := enableMFA(t, mfa)
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+
+	if _, err := users.VerifyMFA(ctx, challenge(t, users), recoveryCodes[0]); err != nil {
+		t.Fatalf("first use of recovery code: %v", err)
+	}
+	if _, err := users.VerifyMFA(ctx, challenge(t, users), recoveryCodes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("second use of recovery code = %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestEnrollMFARefusesWhenEnabled(t *testing.T) {
+	ctx := context.Background()
+	users, mfa, repo, _ := newMFATest("user")
+	secret, _ := enableMFA(t, mfa)
+
+	if _, err := users.EnrollMFA(ctx, challenge(t, users)); !errors.Is(err, ErrMFAAlreadyEnabled) {
+		t.Errorf("EnrollMFA with 2FA enabled = %v, want ErrMFAAlreadyEnabled", err)
+	}
+	if repo.secrets[7].Secret != secret {
+		t.Error("EnrollMFA replaced the confirmed secret")
+	}
+}
+
+func TestAdminMFAEnforcement(t *testing.T) {
+	ctx := context.Background()
+	users, mfa, _, settings := newMFATest("admin")
+
+	// tanpa kebijakan, admin tanpa 2FA langsung mendapat access token
+	result, err := users.CompleteLogin(ctx, &entity.User{ID: 7, Username: "budi", Role: "admin"})
+	if err != nil || result.Token == "" {
+		t.Fatalf("CompleteLogin without policy = %+v, %v", result, err)
+	}
+
+	if err := mfa.SetAdminRequired(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if settings.values[entity.SettingRequireAdminMFA] != "true" {
+		t.Fatalf("setting = %q", settings.values[entity.SettingRequireAdminMFA])
+	}
+	result, err = users.CompleteLogin(ctx, &entity.User{ID: 7, Username: "budi", Role: "admin"})
+	if err != nil || result.Token != "" || !result.EnrollmentRequired {
+		t.Fatalf("CompleteLogin with policy = %+v, %v; want an enrollment challenge", result, err)
+	}
+
+	// kode pertama dari challenge sekaligus mengaktifkan 2FA
+	enrollment, err := users.EnrollMFA(ctx, result.ChallengeToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	verified, err := users.VerifyMFA(ctx, result.ChallengeToken, code)
+	if err != nil || verified.Token == "" || len(verified.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("VerifyMFA = %+v, %v; want a token and recovery codes", verified, err)
+	}
+
+	if err := mfa.Disable(ctx, 7, verified.RecoveryCodes[0]); !errors.Is(err, ErrMFARequired) {
+		t.Errorf("Disable for admin = %v, want ErrMFARequired", err)
+	}
+	if enabled, _ := mfa.Enabled(ctx, 7); !enabled {
+		t.Error("2FA was disabled for an admin")
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/mailer"
+)
+
+// JobSendEmail adalah nama job yang mengirim email lewat SMTP. Payload-nya
+// mailer.Message.
+const JobSendEmail = "send-email"
+
+const maxNotificationPageLimit = 100
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationChannel mengirim notifikasi lewat satu media. Send dipanggil
+// di dalam transaksi, jadi channel yang butuh I/O ke luar sebaiknya hanya
+// menjadwalkan pengiriman (misalnya lewat job) agar bisa di-retry.
+type NotificationChannel interface {
+	Name() string
+	Send(ctx context.Context, user *entity.User, notification *entity.Notification) error
+}
+
+type NotificationService interface {
+	HasChannel(name string) bool
+	Notify(ctx context.Context, user *entity.User, notification *entity.Notification, channels []string) error
+	GetNotifications(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]entity.Notification, int64, error)
+	SetRead(ctx context.Context, userID, id uint, read bool) error
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+}
+
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	channels         map[string]NotificationChannel
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository, channels ...NotificationChannel) NotificationService {
+	s := &notificationService{notificationRepo, map[string]NotificationChannel{}}
+	for _, channel := range channels {
+		s.channels[channel.Name()] = channel
+	}
+	return s
+}
+
+func (s *notificationService) HasChannel(name string) bool {
+	_, ok := s.channels[name]
+	return ok
+}
+
+// Notify mengirim notifikasi ke channel yang diminta. Channel in_app selalu
+// dijalankan lebih dulu agar notification.ID terisi untuk channel lain.
+func (s *notificationService) Notify(ctx context.Context, user *entity.User, notification *entity.Notification, channels []string) error {
+	ordered := make([]string, 0, len(channels))
+	for _, name := range channels {
+		if name == entity.NotificationChannelInApp {
+			ordered = append([]string{name}, ordered...)
+		} else {
+			ordered = append(ordered, name)
+		}
+	}
+	for _, name := range ordered {
+		channel, ok := s.channels[name]
+		if !ok {
+			return fmt.Errorf("notification channel %s is not configured", name)
+		}
+		if err := channel.Send(ctx, user, notification); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// GetNotifications juga mengembalikan jumlah notifikasi yang belum dibaca.
+func (s *notificationService) GetNotifications(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]entity.Notification, int64, error) {
+	if limit <= 0 || limit > maxNotificationPageLimit {
+		limit = maxNotificationPageLimit
+	}
+	notifications, err := s.notificationRepo.GetByUserID(ctx, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, unread, nil
+}
+
+func (s *notificationService) SetRead(ctx context.Context, userID, id uint, read bool) error {
+	found, err := s.notificationRepo.SetRead(ctx, userID, id, read)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	return s.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+type inAppChannel struct {
+	notificationRepo repository.NotificationRepository
+}
+
+// NewInAppChannel menyimpan notifikasi ke tabel notifications yang dibaca
+// lewat GET /notifications.
+func NewInAppChannel(notificationRepo repository.NotificationRepository) NotificationChannel {
+	return &inAppChannel{notificationRepo}
+}
+
+func (c *inAppChannel) Name() string {
+	return entity.NotificationChannelInApp
+}
+
+func (c *inAppChannel) Send(ctx context.Context, user *entity.User, notification *entity.Notification) error {
+	return c.notificationRepo.Create(ctx, notification)
+}
+
+type emailChannel struct {
+	jobService JobService
+}
+
+// NewEmailChannel menjadwalkan job send-email sehingga kegagalan SMTP
+// di-retry oleh scheduler. User tanpa email yang sudah diverifikasi
+// dilewati.
+func NewEmailChannel(jobService JobService) NotificationChannel {
+	return &emailChannel{jobService}
+}
+
+func (c *emailChannel) Name() string {
+	return entity.NotificationChannelEmail
+}
+
+func (c *emailChannel) Send(ctx context.Context, user *entity.User, notification *entity.Notification) error {
+	if user.Email == "" || user.EmailVerifiedAt == nil {
+		return nil
+	}
+	msg := mailer.Message{To: user.Email, Subject: notification.Title, Body: notification.Body}
+	_, err := c.jobService.Enqueue(ctx, JobSendEmail, msg, time.Now())
+	return err
+}
+
+type webhookChannel struct {
+	publisher event.Publisher
+}
+
+// NewWebhookChannel mengirim notifikasi sebagai event todo.reminder ke
+// webhook milik user yang berlangganan event tersebut.
+func NewWebhookChannel(publisher event.Publisher) NotificationChannel {
+	return &webhookChannel{publisher}
+}
+
+func (c *webhookChannel) Name() string {
+	return entity.NotificationChannelWebhook
+}
+
+func (c *webhookChannel) Send(ctx context.Context, user *entity.User, notification *entity.Notification) error {
+	e := event.Event{
+		Type:         notification.Type,
+		UserID:       notification.UserID,
+		Notification: notification,
+		OccurredAt:   time.Now(),
+	}
+	if notification.TodoID != nil {
+		e.TodoID = *notification.TodoID
+	}
+	return c.publisher.Publish(ctx, e)
+}
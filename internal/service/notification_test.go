@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+)
+
+type fakeJobService struct {
+	JobService
+	enqueued []string
+}
+
+func (s *fakeJobService) Enqueue(ctx context.Context, name string, payload interface{}, runAt time.Time) (*entity.Job, error) {
+	s.enqueued = append(s.enqueued, name)
+	return &entity.Job{Name: name}, nil
+}
+
+func TestEmailChannelRequiresVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	cases := []struct {
+		name string
+		user entity.User
+		want int
+	}{
+		{"no email", entity.User{}, 0},
+		{"unverified", entity.User{Email: "a@example.com"}, 0},
+		{"verified", entity.User{Email: "a@example.com", EmailVerifiedAt: &verifiedAt}, 1},
+	}
+	for _, c := range cases {
+		jobs := &fakeJobService{}
+		err := NewEmailChannel(jobs).Send(context.Background(), &c.user, &entity.Notification{Title: "Reminder"})
+		if err != nil || len(jobs.enqueued) != c.want {
+			t.Errorf("%s: enqueued %v, %v; want %d emails", c.name, jobs.enqueued, err, c.want)
+		}
+	}
+}
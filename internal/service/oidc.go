@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/oidc"
+	"todo-list/pkg/securetoken"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCDisabled       = errors.New("single sign-on is not configured")
+	ErrInvalidOIDCState   = errors.New("login state is invalid or has expired, start the login again")
+	ErrOIDCLoginFailed    = errors.New("the identity provider did not confirm the login")
+	ErrOIDCNotProvisioned = errors.New("no account is linked to this identity, ask an admin to create one")
+)
+
+// oidcState disimpan di redis selama user login di identity provider.
+type oidcState struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+// OIDCService menangani login lewat identity provider OpenID Connect.
+// State dari AuthURL harus disimpan di browser (cookie) dan dibandingkan
+// dengan state di callback sebelum memanggil Callback.
+type OIDCService interface {
+	AuthURL(ctx context.Context) (authURL, state string, err error)
+	Callback(ctx context.Context, state, code string) (*LoginResult, error)
+}
+
+type oidcService struct {
+	provider           *oidc.Provider
+	identityRepository repository.UserIdentityRepository
+	userRepository     repository.UserRepository
+	userService        UserService
+	auditService       AuditService
+	cacheable          cache.Cacheable
+	roleClaim          string
+	adminValues        []string
+	autoProvision      bool
+	linkByUsername     bool
+	stateTTL           time.Duration
+}
+
+// NewOIDCService dengan provider nil berarti login OIDC dimatikan.
+// roleClaim kosong berarti role user tidak diatur oleh identity provider.
+// linkByUsername hanya boleh aktif jika preferred_username dari identity
+// provider tidak bisa diubah sendiri oleh user.
+func NewOIDCService(
+	provider *oidc.Provider,
+	identityRepository repository.UserIdentityRepository,
+	userRepository repository.UserRepository,
+	userService UserService,
+	auditService AuditService,
+	cacheable cache.Cacheable,
+	roleClaim string,
+	adminValues []string,
+	autoProvision bool,
+	linkByUsername bool,
+	stateTTL time.Duration,
+) OIDCService {
+	return &oidcService{provider, identityRepository, userRepository, userService, auditService, cacheable, roleClaim, adminValues, autoProvision, linkByUsername, stateTTL}
+}
+
+// AuthURL menyiapkan state, nonce dan code verifier PKCE lalu mengembalikan
+// URL login identity provider.
+func (s *oidcService) AuthURL(ctx context.Context) (string, string, error) {
+	if s.provider == nil {
+		return "", "", ErrOIDCDisabled
+	}
+	state, _, err := securetoken.Generate("")
+	if err != nil {
+		return "", "", err
+	}
+	nonce, _, err := securetoken.Generate("")
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oidc.GenerateVerifier()
+	data, err := json.Marshal(oidcState{Verifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.cacheable.Set(oidcStateKey(state), data, s.stateTTL); err != nil {
+		return "", "", err
+	}
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+func (s *oidcService) Callback(ctx context.Context, state, code string) (*LoginResult, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+	key := oidcStateKey(state)
+	raw := s.cacheable.Get(key)
+	if state == "" || raw == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	// state hanya bisa dipakai sekali
+	if err := s.cacheable.Delete(key); err != nil {
+		return nil, err
+	}
+	var st oidcState
+	if err := json.Unmarshal([]byte(raw), &st); err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	identity, err := s.provider.Exchange(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		log.Printf("oidc login failed: %v", err)
+		return nil, ErrOIDCLoginFailed
+	}
+	user, err := s.resolveUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if role, ok := s.mappedRole(identity); ok && role != user.Role {
+		// role diubah oleh sinkronisasi dari identity provider, bukan oleh
+		// user yang sedang login
+		if err := s.userService.UpdateRole(actor.System(ctx), user.ID, 0, role); err != nil {
+			return nil, err
+		}
+		user.Role = role
+	}
+	return s.userService.CompleteLogin(ctx, user)
+}
+
+// resolveUser mencari user yang sudah terhubung dengan identity. Identity
+// baru dihubungkan ke user yang cocok (lihat matchUser), atau dibuatkan user
+// baru jika autoProvision aktif.
+func (s *oidcService) resolveUser(ctx context.Context, identity *oidc.Identity) (*entity.User, error) {
+	linked, err := s.identityRepository.Find(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		if err := s.identityRepository.TouchLastLogin(ctx, linked.ID, identity.Email); err != nil {
+			log.Printf("failed to update last_login_at of identity %d: %v", linked.ID, err)
+		}
+		return s.userRepository.FindByID(ctx, int64(linked.UserID))
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user, err := s.matchUser(ctx, identity)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !s.autoProvision {
+			return nil, ErrOIDCNotProvisioned
+		}
+		user, err = s.provision(ctx, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.identityRepository.Create(ctx, &entity.UserIdentity{
+		UserID:      uint(user.ID),
+		Issuer:      identity.Issuer,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, entity.AuditIdentityLinked, "user", strconv.FormatInt(user.ID, 10), map[string]interface{}{
+		"issuer":  identity.Issuer,
+		"subject": identity.Subject,
+	}); err != nil {
+		log.Printf("failed to record audit log %s: %v", entity.AuditIdentityLinked, err)
+	}
+	return user, nil
+}
+
+// matchUser hanya mencocokkan email yang sudah diverifikasi di kedua sisi,
+// agar akun tidak bisa diambil alih dengan mendaftarkan email atau username
+// orang lain di salah satu sisi. Username hanya dicocokkan jika
+// linkByUsername aktif.
+func (s *oidcService) matchUser(ctx context.Context, identity *oidc.Identity) (*entity.User, error) {
+	if identity.Email != "" && identity.EmailVerified {
+		user, err := s.userRepository.FindByEmail(ctx, identity.Email)
+		if err == nil && user.EmailVerifiedAt != nil {
+			return user, nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	if s.linkByUsername && identity.Username != "" {
+		return s.userRepository.FindByUsername(ctx, identity.Username)
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// provision membuat user baru dengan password acak. User tetap bisa memakai
+// reset password jika ingin login tanpa identity provider.
+func (s *oidcService) provision(ctx context.Context, identity *oidc.Identity) (*entity.User, error) {
+	username, err := s.availableUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	password, _, err := securetoken.Generate("")
+	if err != nil {
+		return nil, err
+	}
+	role, ok := s.mappedRole(identity)
+	if !ok {
+		role = "user"
+	}
+	req := &entity.UserReg{
+		Username: username,
+		Password: password,
+		Role:     role,
+		FullName: identity.Name,
+	}
+	// email yang belum diverifikasi identity provider tidak dipakai, begitu
+	// juga email milik user lain yang belum terverifikasi (lihat matchUser)
+	if identity.EmailVerified && identity.Email != "" {
+		_, err := s.userRepository.FindByEmail(ctx, identity.Email)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			req.Email = identity.Email
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.userService.Register(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.Email != "" {
+		if _, err := s.userRepository.MarkEmailVerified(ctx, req.ID, req.Email); err != nil {
+			return nil, err
+		}
+	}
+	return s.userRepository.FindByID(ctx, req.ID)
+}
+
+// availableUsername memakai preferred_username, bagian lokal email atau
+// subject, ditambah angka jika sudah dipakai.
+func (s *oidcService) availableUsername(ctx context.Context, identity *oidc.Identity) (string, error) {
+	base := identity.Username
+	if base == "" && identity.Email != "" {
+		base = identity.Email[:strings.Index(identity.Email+"@", "@")]
+	}
+	if base == "" {
+		base = "oidc-" + identity.Subject
+	}
+	candidate := base
+	for i := 2; i <= 100; i++ {
+		_, err := s.userRepository.FindByUsername(ctx, candidate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", errors.New("could not find an available username")
+}
+
+// mappedRole membaca role dari klaim roleClaim, yang bisa berupa string
+// atau array string. User menjadi admin jika salah satu nilainya ada di
+// adminValues, selain itu role-nya user.
+func (s *oidcService) mappedRole(identity *oidc.Identity) (string, bool) {
+	if s.roleClaim == "" {
+		return "", false
+	}
+	var values []string
+	switch v := identity.Claims[s.roleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+	for _, value := range values {
+		for _, admin := range s.adminValues {
+			if value == admin {
+				return "admin", true
+			}
+		}
+	}
+	return "user", true
+}
+
+func oidcStateKey(state string) string {
+	return "todo-list:oidc-state:" + securetoken.Hash(state)
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oauth2-proxy/mockoidc"
+	"gorm.io/gorm"
+)
+
+type fakeOIDCUserRepository struct {
+	repository.UserRepository
+	users []entity.User
+}
+
+func (r *fakeOIDCUserRepository) find(match func(entity.User) bool) (*entity.User, error) {
+	for _, user := range r.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOIDCUserRepository) FindByID(ctx context.Context, id int64) (*entity.User, error) {
+	return r.find(func(u entity.User) bool { return u.ID == id })
+}
+
+func (r *fakeOIDCUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return r.find(func(u entity.User) bool { return u.Email == email })
+}
+
+func (r *fakeOIDCUserRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return r.find(func(u entity.User) bool { return u.Username == username })
+}
+
+func (r *fakeOIDCUserRepository) MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error) {
+	for i := range r.users {
+		if r.users[i].ID == id && r.users[i].Email == email {
+			now := time.Now()
+			r.users[i].EmailVerifiedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeOIDCUserService struct {
+	UserService
+	users *fakeOIDCUserRepository
+	// roleActors adalah actor dari setiap perubahan role
+	roleActors []actor.Actor
+}
+
+func (s *fakeOIDCUserService) Register(ctx context.Context, req *entity.UserReg) error {
+	req.ID = int64(len(s.users.users) + 1)
+	s.users.users = append(s.users.users, entity.User{ID: req.ID, Username: req.Username, Role: req.Role, FullName: req.FullName, Email: req.Email})
+	return nil
+}
+
+func (s *fakeOIDCUserService) UpdateRole(ctx context.Context, userID int64, version uint, role string) error {
+	a, _ := actor.FromContext(ctx)
+	s.roleActors = append(s.roleActors, a)
+	for i := range s.users.users {
+		if s.users.users[i].ID == userID {
+			s.users.users[i].Role = role
+		}
+	}
+	return nil
+}
+
+func (s *fakeOIDCUserService) CompleteLogin(ctx context.Context, user *entity.User) (*LoginResult, error) {
+	return &LoginResult{Token: "token-" + user.Username}, nil
+}
+
+type fakeUserIdentityRepository struct {
+	repository.UserIdentityRepository
+	identities []entity.UserIdentity
+}
+
+func (r *fakeUserIdentityRepository) Find(ctx context.Context, issuer, subject string) (*entity.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserIdentityRepository) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeUserIdentityRepository) TouchLastLogin(ctx context.Context, id uint, email string) error {
+	return nil
+}
+
+type fakeOIDCCache struct {
+	cache.Cacheable
+	values map[string]string
+}
+
+func (c *fakeOIDCCache) Set(key string, value interface{}, duration time.Duration) error {
+	c.values[key] = string(value.([]byte))
+	return nil
+}
+
+func (c *fakeOIDCCache) Get(key string) string {
+	return c.values[key]
+}
+
+func (c *fakeOIDCCache) Delete(key string) error {
+	delete(c.values, key)
+	return nil
+}
+
+type oidcTest struct {
+	provider    *mockoidc.MockOIDC
+	service     OIDCService
+	users       *fakeOIDCUserRepository
+	identities  *fakeUserIdentityRepository
+	userService *fakeOIDCUserService
+}
+
+// newOIDCTest menjalankan identity provider palsu di 127.0.0.1. verified
+// adalah user lokal dengan email terverifikasi, unverified belum.
+func newOIDCTest(t *testing.T, autoProvision, linkByUsername bool) *oidcTest {
+	t.Helper()
+	return newOIDCRoleTest(t, autoProvision, linkByUsername, "", nil)
+}
+
+// newOIDCRoleTest sama dengan newOIDCTest dengan role yang dibaca dari
+// roleClaim.
+func newOIDCRoleTest(t *testing.T, autoProvision, linkByUsername bool, roleClaim string, adminValues []string) *oidcTest {
+	t.Helper()
+	m, err := mockoidc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Shutdown() })
+
+	verifiedAt := time.Now()
+	users := &fakeOIDCUserRepository{users: []entity.User{
+		{ID: 1, Username: "verified", Role: "user", Email: "verified@example.com", EmailVerifiedAt: &verifiedAt},
+		{ID: 2, Username: "unverified", Role: "user", Email: "unverified@example.com"},
+	}}
+	identities := &fakeUserIdentityRepository{}
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       m.Issuer(),
+		ClientID:     m.ClientID,
+		ClientSecret: m.ClientSecret,
+		RedirectURL:  "http://localhost/api/v1/oidc/callback",
+		Scopes:       []string{"openid", "profile", "email", "groups"},
+	})
+	userService := &fakeOIDCUserService{users: users}
+	s := NewOIDCService(provider, identities, users, userService, fakeAccountAuditService{},
+		&fakeOIDCCache{values: map[string]string{}}, roleClaim, adminValues, autoProvision, linkByUsername, time.Minute)
+	return &oidcTest{m, s, users, identities, userService}
+}
+
+// authorize memulai login dan mengikuti redirect identity provider sampai
+// ke redirect URL, lalu mengembalikan state dan code dari callback.
+func (tt *oidcTest) authorize(t *testing.T, user mockoidc.User) (state, code string) {
+	t.Helper()
+	authURL, state, err := tt.service.AuthURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt.provider.QueueUser(user)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("callback state %q, want %q", got, state)
+	}
+	return state, location.Query().Get("code")
+}
+
+func (tt *oidcTest) login(t *testing.T, user mockoidc.User) (*LoginResult, error) {
+	t.Helper()
+	state, code := tt.authorize(t, user)
+	return tt.service.Callback(context.Background(), state, code)
+}
+
+func TestOIDCLinksVerifiedEmail(t *testing.T) {
+	tt := newOIDCTest(t, false, false)
+	user := &mockoidc.MockUser{Subject: "sub-1", Email: "verified@example.com", EmailVerified: true, PreferredUsername: "someone"}
+
+	result, err := tt.login(t, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Token != "token-verified" {
+		t.Errorf("logged in as %q", result.Token)
+	}
+	if len(tt.identities.identities) != 1 || tt.identities.identities[0].UserID != 1 || tt.identities.identities[0].Issuer != tt.provider.Issuer() {
+		t.Fatalf("unexpected identities %+v", tt.identities.identities)
+	}
+
+	// login berikutnya memakai identity yang sudah terhubung
+	user.Email = "changed@example.com"
+	if result, err := tt.login(t, user); err != nil || result.Token != "token-verified" {
+		t.Errorf("second login = %+v, %v", result, err)
+	}
+}
+
+// stringVerifiedUser mengirim email_verified sebagai string seperti
+// beberapa identity provider.
+type stringVerifiedUser struct {
+	*mockoidc.MockUser
+	verified string
+}
+
+func (u *stringVerifiedUser) Claims(scope []string, claims *mockoidc.IDTokenClaims) (jwt.Claims, error) {
+	return &struct {
+		*mockoidc.IDTokenClaims
+		Email         string `json:"email"`
+		EmailVerified string `json:"email_verified"`
+	}{claims, u.Email, u.verified}, nil
+}
+
+func TestOIDCAcceptsEmailVerifiedAsString(t *testing.T) {
+	tt := newOIDCTest(t, false, false)
+	result, err := tt.login(t, &stringVerifiedUser{&mockoidc.MockUser{Subject: "sub-1", Email: "verified@example.com"}, "true"})
+	if err != nil || result.Token != "token-verified" {
+		t.Fatalf("login = %+v, %v; want the verified user", result, err)
+	}
+
+	tt = newOIDCTest(t, false, false)
+	if _, err := tt.login(t, &stringVerifiedUser{&mockoidc.MockUser{Subject: "sub-1", Email: "verified@example.com"}, "false"}); !errors.Is(err, ErrOIDCNotProvisioned) {
+		t.Errorf("login with email_verified \"false\" = %v, want ErrOIDCNotProvisioned", err)
+	}
+}
+
+func TestOIDCRoleMappingUsesSystemActor(t *testing.T) {
+	tt := newOIDCRoleTest(t, false, false, "groups", []string{"admins"})
+	user := &mockoidc.MockUser{Subject: "sub-1", Email: "verified@example.com", EmailVerified: true, Groups: []string{"admins"}}
+	if _, err := tt.login(t, user); err != nil {
+		t.Fatal(err)
+	}
+	if tt.users.users[0].Role != "admin" {
+		t.Errorf("role = %q, want admin", tt.users.users[0].Role)
+	}
+	if len(tt.userService.roleActors) != 1 || tt.userService.roleActors[0].Role != actor.RoleSystem || tt.userService.roleActors[0].UserID != 0 {
+		t.Errorf("role changed by %+v, want the system actor", tt.userService.roleActors)
+	}
+}
+
+func TestOIDCDoesNotLinkUnverifiedEmailOrUsername(t *testing.T) {
+	tests := []struct {
+		name string
+		user *mockoidc.MockUser
+	}{
+		{"email not verified by the provider", &mockoidc.MockUser{Subject: "sub-1", Email: "verified@example.com"}},
+		{"email not verified locally", &mockoidc.MockUser{Subject: "sub-2", Email: "unverified@example.com", EmailVerified: true}},
+		{"username only", &mockoidc.MockUser{Subject: "sub-3", PreferredUsername: "verified"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tt := newOIDCTest(t, false, false)
+			if _, err := tt.login(t, test.user); !errors.Is(err, ErrOIDCNotProvisioned) {
+				t.Errorf("login = %v, want ErrOIDCNotProvisioned", err)
+			}
+			if len(tt.identities.identities) != 0 {
+				t.Errorf("identity was linked: %+v", tt.identities.identities)
+			}
+		})
+	}
+}
+
+func TestOIDCProvisionsInsteadOfLinkingByUsername(t *testing.T) {
+	tt := newOIDCTest(t, true, false)
+	result, err := tt.login(t, &mockoidc.MockUser{Subject: "sub-1", PreferredUsername: "verified"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Token != "token-verified2" {
+		t.Errorf("logged in as %q, want a new user", result.Token)
+	}
+	if len(tt.users.users) != 3 || tt.identities.identities[0].UserID != 3 {
+		t.Errorf("unexpected users %+v", tt.users.users)
+	}
+}
+
+func TestOIDCProvisionSkipsEmailOfAnotherUser(t *testing.T) {
+	tt := newOIDCTest(t, true, false)
+	_, err := tt.login(t, &mockoidc.MockUser{Subject: "sub-1", Email: "unverified@example.com", EmailVerified: true, PreferredUsername: "eve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tt.users.users) != 3 || tt.users.users[2].Username != "eve" || tt.users.users[2].Email != "" {
+		t.Errorf("unexpected users %+v", tt.users.users)
+	}
+	if tt.users.users[1].EmailVerifiedAt != nil {
+		t.Error("email of the existing user was marked as verified")
+	}
+}
+
+func TestOIDCLinkByUsername(t *testing.T) {
+	tt := newOIDCTest(t, false, true)
+	result, err := tt.login(t, &mockoidc.MockUser{Subject: "sub-1", PreferredUsername: "unverified"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Token != "token-unverified" {
+		t.Errorf("logged in as %q", result.Token)
+	}
+}
+
+func TestOIDCRejectsInvalidState(t *testing.T) {
+	tt := newOIDCTest(t, true, false)
+	user := &mockoidc.MockUser{Subject: "sub-1", Email: "verified@example.com", EmailVerified: true}
+
+	state, code := tt.authorize(t, user)
+	if _, err := tt.service.Callback(context.Background(), "unknown", code); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("unknown state: %v", err)
+	}
+	if _, err := tt.service.Callback(context.Background(), state, code); err != nil {
+		t.Fatal(err)
+	}
+	// state hanya bisa dipakai sekali
+	if _, err := tt.service.Callback(context.Background(), state, code); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("reused state: %v", err)
+	}
+
+	// code yang tidak dikenal identity provider
+	state, _ = tt.authorize(t, user)
+	if _, err := tt.service.Callback(context.Background(), state, "invalid"); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Errorf("invalid code: %v", err)
+	}
+}
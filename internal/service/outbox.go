@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/cache"
+)
+
+const (
+	outboxBatch      = 100
+	outboxLease      = time.Minute
+	outboxMaxBackoff = time.Hour
+	// outboxDedupTTL harus lebih lama dari jeda retry terpanjang
+	outboxDedupTTL = 24 * time.Hour
+)
+
+type outboxPublisher struct {
+	outboxRepo repository.OutboxRepository
+}
+
+// NewOutboxPublisher menyimpan event ke tabel outbox. Jika ctx berada di
+// dalam transaksi, event ikut transaksi tersebut.
+func NewOutboxPublisher(outboxRepo repository.OutboxRepository) event.Publisher {
+	return &outboxPublisher{outboxRepo}
+}
+
+func (p *outboxPublisher) Publish(ctx context.Context, e event.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return p.outboxRepo.Add(ctx, &entity.OutboxMessage{EventType: e.Type, Payload: entity.RawJSON(payload)})
+}
+
+// OutboxSink adalah tujuan relay. Name dipakai sebagai kunci deduplikasi,
+// sehingga retry hanya mengirim ulang ke sink yang sebelumnya gagal.
+type OutboxSink struct {
+	Name      string
+	Publisher event.Publisher
+}
+
+// OutboxRelay mengirim message outbox ke semua sink dengan jaminan
+// at-least-once. Message yang gagal dicoba ulang dengan backoff eksponensial.
+type OutboxRelay interface {
+	Relay(ctx context.Context) (int, error)
+	PurgePublished(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type outboxRelay struct {
+	outboxRepo repository.OutboxRepository
+	cacheable  cache.Cacheable
+	sinks      []OutboxSink
+}
+
+func NewOutboxRelay(outboxRepo repository.OutboxRepository, cacheable cache.Cacheable, sinks ...OutboxSink) OutboxRelay {
+	return &outboxRelay{outboxRepo, cacheable, sinks}
+}
+
+// Relay mengembalikan jumlah message yang berhasil dikirim ke semua sink.
+func (r *outboxRelay) Relay(ctx context.Context) (int, error) {
+	messages, err := r.outboxRepo.ClaimDue(ctx, outboxBatch, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for _, message := range messages {
+		if err := r.publish(ctx, message); err != nil {
+			attempts := message.Attempts + 1
+			log.Printf("failed to relay outbox message %d (attempt %d): %v", message.ID, attempts, err)
+			next := time.Now().Add(outboxBackoff(attempts))
+			if err := r.outboxRepo.MarkFailed(ctx, message.ID, attempts, truncate(err.Error(), 1000), next); err != nil {
+				return published, err
+			}
+			continue
+		}
+		if err := r.outboxRepo.MarkPublished(ctx, message.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (r *outboxRelay) publish(ctx context.Context, message entity.OutboxMessage) error {
+	var e event.Event
+	if err := json.Unmarshal([]byte(message.Payload), &e); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sink := range r.sinks {
+		key := fmt.Sprintf("todo-list:outbox:%d:%s", message.ID, sink.Name)
+		if r.cacheable.Get(key) != "" {
+			// sudah terkirim pada percobaan sebelumnya
+			continue
+		}
+		if err := sink.Publisher.Publish(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name, err))
+			continue
+		}
+		if err := r.cacheable.Set(key, "1", outboxDedupTTL); err != nil {
+			log.Printf("failed to mark outbox message %d as sent to %s: %v", message.ID, sink.Name, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *outboxRelay) PurgePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	return r.outboxRepo.DeletePublishedBefore(ctx, time.Now().Add(-retention))
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 0; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxBackoff {
+		delay = outboxMaxBackoff
+	}
+	return delay
+}
+
+type cacheInvalidator struct {
+	cacheable cache.Cacheable
+}
+
+// NewCacheInvalidator menghapus cache list todo atau user setiap kali ada
+// event yang mengubahnya.
+func NewCacheInvalidator(cacheable cache.Cacheable) event.Publisher {
+	return &cacheInvalidator{cacheable}
+}
+
+func (c *cacheInvalidator) Publish(ctx context.Context, e event.Event) error {
+	switch e.Type {
+	case event.TypeUserRegistered, event.TypeUserRoleChanged:
+		return c.cacheable.Delete("todo-list:users:find-all")
+	case event.TypeUserTokensRevoked:
+		return nil
+	}
+	return c.cacheable.Delete("todo-list:todos:get-todos")
+}
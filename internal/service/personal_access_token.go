@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/securetoken"
+	"todo-list/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTokenLifetimeDays = 30
+	maxTokenLifetimeDays     = 365
+	maxActiveTokens          = 50
+	// last_used_at cukup diperbarui sekali per menit
+	tokenTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidAccessToken  = errors.New("personal access token is invalid, expired or revoked")
+	ErrAccessTokenNotFound = errors.New("personal access token not found")
+	ErrAccessTokenName     = errors.New("name is required and must not exceed 100 characters")
+	ErrAccessTokenLifetime = fmt.Errorf("expires_in_days must be between 1 and %d", maxTokenLifetimeDays)
+	ErrAccessTokenLimit    = fmt.Errorf("a user can have at most %d active personal access tokens", maxActiveTokens)
+	ErrInvalidScope        = errors.New("scopes must contain at least one of todos:read, todos:write, notifications:read, notifications:write, webhooks:read, webhooks:write, admin:read or admin:write (admin only)")
+)
+
+// tokenScopes adalah scope yang bisa diberikan. Nilai true berarti scope
+// hanya untuk admin.
+var tokenScopes = map[string]bool{
+	entity.ScopeTodosRead:          false,
+	entity.ScopeTodosWrite:         false,
+	entity.ScopeNotificationsRead:  false,
+	entity.ScopeNotificationsWrite: false,
+	entity.ScopeWebhooksRead:       false,
+	entity.ScopeWebhooksWrite:      false,
+	entity.ScopeAdminRead:          true,
+	entity.ScopeAdminWrite:         true,
+}
+
+type PersonalAccessTokenService interface {
+	CreateToken(ctx context.Context, userID uint, name string, scopes []string, expiresInDays int) (string, *entity.PersonalAccessToken, error)
+	GetTokens(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, userID, id uint) error
+	AuthenticateToken(ctx context.Context, rawToken string) (*token.JwtCustomClaims, []string, error)
+}
+
+type personalAccessTokenService struct {
+	tokenRepository repository.PersonalAccessTokenRepository
+	userRepository  repository.UserRepository
+	auditService    AuditService
+	publisher       event.Publisher
+}
+
+func NewPersonalAccessTokenService(
+	tokenRepository repository.PersonalAccessTokenRepository,
+	userRepository repository.UserRepository,
+	auditService AuditService,
+	publisher event.Publisher,
+) PersonalAccessTokenService {
+	return &personalAccessTokenService{tokenRepository, userRepository, auditService, publisher}
+}
+
+// CreateToken mengembalikan token dalam bentuk plain text. Token hanya
+// ditampilkan sekali karena yang disimpan hanya hash-nya. expiresInDays 0
+// berarti memakai masa berlaku default.
+func (s *personalAccessTokenService) CreateToken(ctx context.Context, userID uint, name string, scopes []string, expiresInDays int) (string, *entity.PersonalAccessToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", nil, ErrAccessTokenName
+	}
+	if expiresInDays == 0 {
+		expiresInDays = defaultTokenLifetimeDays
+	}
+	if expiresInDays < 1 || expiresInDays > maxTokenLifetimeDays {
+		return "", nil, ErrAccessTokenLifetime
+	}
+	user, err := s.userRepository.FindByID(ctx, int64(userID))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		adminOnly, ok := tokenScopes[scope]
+		if !ok || (adminOnly && user.Role != "admin") {
+			return "", nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	active, err := s.tokenRepository.CountActive(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if active >= maxActiveTokens {
+		return "", nil, ErrAccessTokenLimit
+	}
+
+	raw, hash, err := securetoken.Generate(token.PersonalAccessTokenPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+	accessToken := &entity.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		Hint:      raw[:len(token.PersonalAccessTokenPrefix)+4],
+		TokenHash: hash,
+		Scopes:    unique,
+		ExpiresAt: time.Now().AddDate(0, 0, expiresInDays),
+	}
+	if err := s.tokenRepository.Create(ctx, accessToken); err != nil {
+		return "", nil, err
+	}
+	s.audit(ctx, entity.AuditAccessTokenCreated, userID, map[string]interface{}{
+		"token_id": accessToken.ID,
+		"name":     name,
+		"scopes":   unique,
+	})
+	return raw, accessToken, nil
+}
+
+func (s *personalAccessTokenService) GetTokens(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error) {
+	return s.tokenRepository.GetByUserID(ctx, userID)
+}
+
+func (s *personalAccessTokenService) RevokeToken(ctx context.Context, userID, id uint) error {
+	revoked, err := s.tokenRepository.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrAccessTokenNotFound
+	}
+	s.audit(ctx, entity.AuditAccessTokenRevoked, userID, map[string]interface{}{"token_id": id})
+
+	// stream real-time yang dibuka dengan token ini ikut ditutup
+	e := event.Event{Type: event.TypeUserTokensRevoked, UserID: userID, OccurredAt: time.Now()}
+	if a, ok := actor.FromContext(ctx); ok {
+		e.ActorID = a.UserID
+	}
+	return s.publisher.Publish(ctx, e)
+}
+
+// AuthenticateToken membuat claims dari data user terbaru, sehingga
+// perubahan role langsung berlaku untuk token yang sudah dibuat.
+func (s *personalAccessTokenService) AuthenticateToken(ctx context.Context, rawToken string) (*token.JwtCustomClaims, []string, error) {
+	accessToken, err := s.tokenRepository.FindActiveByHash(ctx, securetoken.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAccessToken
+		}
+		return nil, nil, err
+	}
+	user, err := s.userRepository.FindByID(ctx, int64(accessToken.UserID))
+	if err != nil {
+		return nil, nil, ErrInvalidAccessToken
+	}
+
+	a, _ := actor.FromContext(ctx)
+	if err := s.tokenRepository.TouchLastUsed(ctx, accessToken.ID, a.IP, time.Now().Add(-tokenTouchInterval)); err != nil {
+		log.Printf("failed to update last_used_at of personal access token %d: %v", accessToken.ID, err)
+	}
+	return &token.JwtCustomClaims{
+		UserID:   uint(user.ID),
+		Username: user.Username,
+		Role:     user.Role,
+		FullName: user.FullName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "todo-list",
+			ExpiresAt: jwt.NewNumericDate(accessToken.ExpiresAt),
+		},
+	}, accessToken.Scopes, nil
+}
+
+func (s *personalAccessTokenService) audit(ctx context.Context, action string, userID uint, metadata map[string]interface{}) {
+	if err := s.auditService.Record(ctx, action, "user", strconv.FormatUint(uint64(userID), 10), metadata); err != nil {
+		log.Printf("failed to record audit log %s: %v", action, err)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/securetoken"
+	"todo-list/pkg/token"
+
+	"gorm.io/gorm"
+)
+
+type fakePersonalAccessTokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	tokens []entity.PersonalAccessToken
+}
+
+func (r *fakePersonalAccessTokenRepository) active(t entity.PersonalAccessToken) bool {
+	return t.RevokedAt == nil && t.ExpiresAt.After(time.Now())
+}
+
+func (r *fakePersonalAccessTokenRepository) Create(ctx context.Context, t *entity.PersonalAccessToken) error {
+	t.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, *t)
+	return nil
+}
+
+func (r *fakePersonalAccessTokenRepository) CountActive(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	for _, t := range r.tokens {
+		if t.UserID == userID && r.active(t) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakePersonalAccessTokenRepository) FindActiveByHash(ctx context.Context, hash string) (*entity.PersonalAccessToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == hash && r.active(t) {
+			return &t, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id uint) (int64, error) {
+	for i := range r.tokens {
+		if r.tokens[i].ID == id && r.tokens[i].UserID == userID && r.tokens[i].RevokedAt == nil {
+			now := time.Now()
+			r.tokens[i].RevokedAt = &now
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *fakePersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id uint, ip string, before time.Time) error {
+	return nil
+}
+
+func newPersonalAccessTokenTest(role string) (PersonalAccessTokenService, *fakePersonalAccessTokenRepository, *fakeAccountUserRepository, *fakeTodoPublisher) {
+	tokens := &fakePersonalAccessTokenRepository{}
+	users := &fakeAccountUserRepository{user: entity.User{ID: 7, Username: "budi", Role: role}}
+	publisher := &fakeTodoPublisher{}
+	return NewPersonalAccessTokenService(tokens, users, fakeAccountAuditService{}, publisher), tokens, users, publisher
+}
+
+func TestCreateTokenStoresOnlyHash(t *testing.T) {
+	ctx := context.Background()
+	s, tokens, _, _ := newPersonalAccessTokenTest("user")
+
+	raw, created, err := s.CreateToken(ctx, 7, "ci", []string{entity.ScopeTodosRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, token.PersonalAccessTokenPrefix) {
+		t.Errorf("token %q does not start with %s", raw, token.PersonalAccessTokenPrefix)
+	}
+	stored := tokens.tokens[0]
+	if stored.TokenHash != securetoken.Hash(raw) || stored.TokenHash == raw {
+		t.Errorf("stored hash %q is not the hash of the token", stored.TokenHash)
+	}
+	if !strings.HasPrefix(raw, stored.Hint) || len(stored.Hint) >= len(raw) {
+		t.Errorf("hint %q reveals too much of the token", stored.Hint)
+	}
+	if created.ExpiresAt.Sub(time.Now().AddDate(0, 0, defaultTokenLifetimeDays)).Abs() > time.Minute {
+		t.Errorf("default expiry %s, want %d days", created.ExpiresAt, defaultTokenLifetimeDays)
+	}
+
+	claims, scopes, err := s.AuthenticateToken(ctx, raw)
+	if err != nil || claims.UserID != 7 || len(scopes) != 1 || scopes[0] != entity.ScopeTodosRead {
+		t.Errorf("AuthenticateToken = %+v, %v, %v", claims, scopes, err)
+	}
+}
+
+func TestCreateTokenLifetimeCap(t *testing.T) {
+	ctx := context.Background()
+	s, _, _, _ := newPersonalAccessTokenTest("user")
+
+	_, created, err := s.CreateToken(ctx, 7, "yearly", []string{entity.ScopeTodosRead}, maxTokenLifetimeDays)
+	if err != nil {
+		t.Fatalf("token valid for %d days: %v", maxTokenLifetimeDays, err)
+	}
+	if created.ExpiresAt.Sub(time.Now().AddDate(0, 0, maxTokenLifetimeDays)).Abs() > time.Minute {
+		t.Errorf("expiry %s, want %d days", created.ExpiresAt, maxTokenLifetimeDays)
+	}
+	for _, days := range []int{maxTokenLifetimeDays + 1, -1} {
+		if _, _, err := s.CreateToken(ctx, 7, "invalid", []string{entity.ScopeTodosRead}, days); !errors.Is(err, ErrAccessTokenLifetime) {
+			t.Errorf("expires_in_days %d = %v, want ErrAccessTokenLifetime", days, err)
+		}
+	}
+}
+
+func TestCreateTokenLimit(t *testing.T) {
+	ctx := context.Background()
+	s, tokens, _, _ := newPersonalAccessTokenTest("user")
+
+	for i := 0; i < maxActiveTokens; i++ {
+		if _, _, err := s.CreateToken(ctx, 7, "ci", []string{entity.ScopeTodosRead}, 0); err != nil {
+			t.Fatalf("token %d: %v", i+1, err)
+		}
+	}
+	if _, _, err := s.CreateToken(ctx, 7, "ci", []string{entity.ScopeTodosRead}, 0); !errors.Is(err, ErrAccessTokenLimit) {
+		t.Fatalf("token %d = %v, want ErrAccessTokenLimit", maxActiveTokens+1, err)
+	}
+
+	// token yang dicabut atau kedaluwarsa tidak dihitung
+	if err := s.RevokeToken(ctx, 7, 1); err != nil {
+		t.Fatal(err)
+	}
+	tokens.tokens[1].ExpiresAt = time.Now().Add(-time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.CreateToken(ctx, 7, "ci", []string{entity.ScopeTodosRead}, 0); err != nil {
+			t.Errorf("token after freeing a slot: %v", err)
+		}
+	}
+}
+
+func TestAuthenticateTokenRereadsRoleAndRejectsRevokedOrExpired(t *testing.T) {
+	ctx := context.Background()
+	s, tokens, users, publisher := newPersonalAccessTokenTest("admin")
+
+	raw, created, err := s.CreateToken(ctx, 7, "ci", []string{entity.ScopeAdminRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.user.Role = "user"
+	claims, _, err := s.AuthenticateToken(ctx, raw)
+	if err != nil || claims.Role != "user" {
+		t.Errorf("AuthenticateToken after demotion = %+v, %v; want role user", claims, err)
+	}
+
+	expired, _, err := s.CreateToken(ctx, 7, "expired", []string{entity.ScopeTodosRead}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens.tokens[1].ExpiresAt = time.Now().Add(-time.Second)
+	if _, _, err := s.AuthenticateToken(ctx, expired); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Errorf("expired token = %v, want ErrInvalidAccessToken", err)
+	}
+
+	if err := s.RevokeToken(ctx, 7, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.AuthenticateToken(ctx, raw); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Errorf("revoked token = %v, want ErrInvalidAccessToken", err)
+	}
+	if len(publisher.events) != 1 || publisher.events[0].Type != event.TypeUserTokensRevoked {
+		t.Errorf("published %+v, want one %s event", publisher.events, event.TypeUserTokensRevoked)
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+)
+
+const (
+	reminderBatch       = 100
+	maxRemindersPerTodo = 10
+	maxReminderLeadTime = 30 * 24 * 60
+	reminderDueAtFormat = "Mon, 02 Jan 2006 15:04 MST"
+)
+
+var (
+	ErrReminderNotFound       = errors.New("reminder not found")
+	ErrInvalidReminder        = errors.New("set either remind_at in the future or minutes_before between 0 and 43200 on a todo with due_at")
+	ErrInvalidReminderChannel = errors.New("channels must contain only configured channels: in_app, email or webhook")
+	ErrTooManyReminders       = errors.New("a todo can have at most 10 reminders")
+)
+
+type ReminderService interface {
+	CreateReminder(ctx context.Context, userID, todoID uint, remindAt *time.Time, minutesBefore *int, channels []string) (*entity.Reminder, error)
+	GetReminders(ctx context.Context, userID, todoID uint) ([]entity.Reminder, error)
+	DeleteReminder(ctx context.Context, userID, todoID, id uint) error
+	SendDueReminders(ctx context.Context) (int, error)
+}
+
+type reminderService struct {
+	reminderRepo        repository.ReminderRepository
+	todoRepo            repository.TodoRepository
+	userRepository      repository.UserRepository
+	transactor          repository.Transactor
+	notificationService NotificationService
+}
+
+func NewReminderService(
+	reminderRepo repository.ReminderRepository,
+	todoRepo repository.TodoRepository,
+	userRepository repository.UserRepository,
+	transactor repository.Transactor,
+	notificationService NotificationService,
+) ReminderService {
+	return &reminderService{reminderRepo, todoRepo, userRepository, transactor, notificationService}
+}
+
+func (s *reminderService) getOwnedTodo(ctx context.Context, userID, todoID uint) (*entity.Todo, error) {
+	todo, err := s.todoRepo.GetByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return nil, errors.New("unauthorized or not found")
+	}
+	return todo, nil
+}
+
+// CreateReminder menerima tepat satu dari remindAt atau minutesBefore.
+// minutesBefore hanya untuk todo yang punya due_at. Channel default adalah
+// in_app.
+func (s *reminderService) CreateReminder(ctx context.Context, userID, todoID uint, remindAt *time.Time, minutesBefore *int, channels []string) (*entity.Reminder, error) {
+	if (remindAt == nil) == (minutesBefore == nil) ||
+		(remindAt != nil && !remindAt.After(time.Now())) ||
+		(minutesBefore != nil && (*minutesBefore < 0 || *minutesBefore > maxReminderLeadTime)) {
+		return nil, ErrInvalidReminder
+	}
+	if len(channels) == 0 {
+		channels = []string{entity.NotificationChannelInApp}
+	}
+	seen := map[string]bool{}
+	for _, channel := range channels {
+		if !s.notificationService.HasChannel(channel) || seen[channel] {
+			return nil, ErrInvalidReminderChannel
+		}
+		seen[channel] = true
+	}
+
+	todo, err := s.getOwnedTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	// reminder relatif tanpa due_at tidak akan pernah terkirim
+	if minutesBefore != nil && todo.DueAt == nil {
+		return nil, ErrInvalidReminder
+	}
+	existing, err := s.reminderRepo.GetByTodoID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxRemindersPerTodo {
+		return nil, ErrTooManyReminders
+	}
+
+	reminder := &entity.Reminder{
+		TodoID:        todoID,
+		UserID:        userID,
+		RemindAt:      remindAt,
+		MinutesBefore: minutesBefore,
+		Channels:      channels,
+	}
+	if err := s.reminderRepo.Create(ctx, reminder); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func (s *reminderService) GetReminders(ctx context.Context, userID, todoID uint) ([]entity.Reminder, error) {
+	if _, err := s.getOwnedTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return s.reminderRepo.GetByTodoID(ctx, todoID)
+}
+
+func (s *reminderService) DeleteReminder(ctx context.Context, userID, todoID, id uint) error {
+	if _, err := s.getOwnedTodo(ctx, userID, todoID); err != nil {
+		return err
+	}
+	reminder, err := s.reminderRepo.GetByID(ctx, id)
+	if err != nil || reminder.TodoID != todoID {
+		return ErrReminderNotFound
+	}
+	return s.reminderRepo.Delete(ctx, id)
+}
+
+// SendDueReminders mengirim reminder yang sudah waktunya dan mengembalikan
+// jumlah yang terkirim. Reminder yang gagal tidak ditandai terkirim sehingga
+// dicoba lagi pada pemanggilan berikutnya.
+func (s *reminderService) SendDueReminders(ctx context.Context) (int, error) {
+	reminders, err := s.reminderRepo.FindDue(ctx, time.Now(), reminderBatch)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	var errs []error
+	for i := range reminders {
+		if err := s.send(ctx, &reminders[i]); err != nil {
+			log.Printf("failed to send reminder %d: %v", reminders[i].ID, err)
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (s *reminderService) send(ctx context.Context, reminder *entity.Reminder) error {
+	todo, err := s.todoRepo.GetByID(ctx, reminder.TodoID)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepository.FindByID(ctx, int64(reminder.UserID))
+	if err != nil {
+		return err
+	}
+	notification := reminderNotification(todo)
+
+	// notifikasi, job email dan delivery webhook hanya tersimpan jika
+	// reminder berhasil ditandai terkirim
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		marked, err := s.reminderRepo.MarkSent(ctx, reminder.ID, time.Now())
+		if err != nil || !marked {
+			return err
+		}
+		return s.notificationService.Notify(ctx, user, notification, reminder.Channels)
+	})
+}
+
+func reminderNotification(todo *entity.Todo) *entity.Notification {
+	todoID := todo.ID
+	body := todo.Title
+	if todo.DueAt != nil {
+		body = fmt.Sprintf("%s is due %s.", todo.Title, todo.DueAt.UTC().Format(reminderDueAtFormat))
+	}
+	return &entity.Notification{
+		UserID: todo.UserID,
+		TodoID: &todoID,
+		Type:   entity.NotificationTodoReminder,
+		Title:  "Reminder: " + truncate(todo.Title, 280),
+		Body:   body,
+	}
+}
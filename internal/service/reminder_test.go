@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/repository"
+)
+
+type fakeReminderTodoRepository struct {
+	repository.TodoRepository
+	todos map[uint]entity.Todo
+}
+
+func (r *fakeReminderTodoRepository) GetByID(ctx context.Context, id uint) (*entity.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &todo, nil
+}
+
+type fakeReminderRepository struct {
+	repository.ReminderRepository
+	reminders []entity.Reminder
+}
+
+func (r *fakeReminderRepository) Create(ctx context.Context, reminder *entity.Reminder) error {
+	reminder.ID = uint(len(r.reminders) + 1)
+	r.reminders = append(r.reminders, *reminder)
+	return nil
+}
+
+func (r *fakeReminderRepository) GetByTodoID(ctx context.Context, todoID uint) ([]entity.Reminder, error) {
+	var reminders []entity.Reminder
+	for _, reminder := range r.reminders {
+		if reminder.TodoID == todoID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return reminders, nil
+}
+
+type fakeNotificationService struct {
+	NotificationService
+}
+
+func (fakeNotificationService) HasChannel(name string) bool {
+	return name == entity.NotificationChannelInApp
+}
+
+func TestCreateReminder(t *testing.T) {
+	due := time.Now().Add(48 * time.Hour)
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	minutes := func(n int) *int { return &n }
+
+	tests := []struct {
+		name          string
+		todoID        uint
+		remindAt      *time.Time
+		minutesBefore *int
+		channels      []string
+		err           error
+	}{
+		{"absolute", 2, &future, nil, nil, nil},
+		{"relative to due_at", 1, nil, minutes(30), nil, nil},
+		{"relative without due_at", 2, nil, minutes(30), nil, ErrInvalidReminder},
+		{"neither", 1, nil, nil, nil, ErrInvalidReminder},
+		{"both", 1, &future, minutes(30), nil, ErrInvalidReminder},
+		{"in the past", 1, &past, nil, nil, ErrInvalidReminder},
+		{"negative lead time", 1, nil, minutes(-1), nil, ErrInvalidReminder},
+		{"lead time too long", 1, nil, minutes(maxReminderLeadTime + 1), nil, ErrInvalidReminder},
+		{"unknown channel", 1, &future, nil, []string{"sms"}, ErrInvalidReminderChannel},
+		{"duplicate channel", 1, &future, nil, []string{"in_app", "in_app"}, ErrInvalidReminderChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewReminderService(
+				&fakeReminderRepository{},
+				&fakeReminderTodoRepository{todos: map[uint]entity.Todo{
+					1: {ID: 1, UserID: 7, DueAt: &due},
+					2: {ID: 2, UserID: 7},
+				}},
+				nil, nil, fakeNotificationService{},
+			)
+			reminder, err := s.CreateReminder(context.Background(), 7, tt.todoID, tt.remindAt, tt.minutesBefore, tt.channels)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("CreateReminder error = %v, want %v", err, tt.err)
+			}
+			if err == nil && (reminder.ID == 0 || len(reminder.Channels) != 1 || reminder.Channels[0] != entity.NotificationChannelInApp) {
+				t.Errorf("unexpected reminder %+v", reminder)
+			}
+		})
+	}
+}
@@ -4,51 +4,226 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
+	"unicode/utf8"
 	"todo-list/internal/entity"
+	"todo-list/internal/event"
 	"todo-list/internal/repository"
+	"todo-list/internal/todofmt"
 	"todo-list/pkg/cache"
+	"todo-list/pkg/rank"
 	"todo-list/pkg/token"
 )
 
+var (
+	ErrMissingAnchor      = errors.New("either before_id or after_id is required")
+	ErrPreconditionFailed = errors.New("the todo has been modified, fetch the latest version and retry")
+	ErrTitleTooLong       = fmt.Errorf("title must not exceed %d characters", entity.MaxTitleLength)
+	ErrDescriptionTooLong = fmt.Errorf("description must not exceed %d characters", entity.MaxDescriptionLength)
+	ErrInvalidPriority    = errors.New("priority must be a single letter from A to Z")
+	ErrInvalidProject     = fmt.Errorf("project must be a single word of at most %d characters", entity.MaxProjectLength)
+	ErrInvalidTag         = fmt.Errorf("tags must be single words of at most %d characters without commas", entity.MaxTagLength)
+)
+
 type TodoService interface {
-	CreateTodo(ctx context.Context,userID uint, title string) (*entity.Todo, error)
+	CreateTodo(ctx context.Context,userID uint, title, description string) (*entity.Todo, error)
 	GetTodos(ctx context.Context) ([]entity.Todo, error)
 	GetTodosByUserID(ctx context.Context,userID uint) ([]entity.Todo, error)
-	UpdateTodo(ctx context.Context,userID, todoID uint, title string, done bool) error
-	DeleteTodo(ctx context.Context,userID, todoID uint) error
+	UpdateTodo(ctx context.Context,userID, todoID, version uint, title, description string, done bool) error
+	DeleteTodo(ctx context.Context,userID, todoID, version uint) error
+	GetTrash(ctx context.Context, userID uint) ([]entity.Todo, error)
+	RestoreTodo(ctx context.Context, userID, todoID uint) error
+	PurgeTodo(ctx context.Context, userID, todoID uint) error
+	PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int64, error)
+	GetArchive(ctx context.Context, userID uint) ([]entity.Todo, error)
+	ArchiveTodo(ctx context.Context, userID, todoID, version uint) error
+	UnarchiveTodo(ctx context.Context, userID, todoID, version uint) error
+	AutoArchive(ctx context.Context, after time.Duration) (int64, error)
+	MoveTodo(ctx context.Context, userID, todoID uint, beforeID, afterID *uint) (*entity.Todo, error)
+	RebalancePositions(ctx context.Context, maxLength int) (int, error)
+	GetTodoHistory(ctx context.Context, userID, todoID uint) ([]entity.TodoEvent, error)
+	RevertTodo(ctx context.Context, userID, todoID, version, eventID uint) (*entity.Todo, error)
+	BulkUpdate(ctx context.Context, userID uint, action string, ids []uint, args BulkArgs) ([]BulkResult, error)
+	GetTodo(ctx context.Context, userID, todoID uint) (*entity.Todo, error)
+	PatchTodo(ctx context.Context, userID, todoID, version uint, patch entity.TodoPatch) (*entity.Todo, error)
+	ExportTodos(ctx context.Context, userID uint, fn func(todo *entity.Todo) error) error
+	ImportTodos(ctx context.Context, userID uint, records []todofmt.Record, commit bool) (*ImportReport, error)
+	GetTodoByResourceName(ctx context.Context, userID uint, name string) (*entity.Todo, error)
+	PutTodoResource(ctx context.Context, userID uint, name, uid string, input entity.Todo, version uint, createOnly bool) (*entity.Todo, bool, error)
+	GetSyncToken(ctx context.Context, userID uint) (uint, error)
+	GetTodoChanges(ctx context.Context, userID, since uint) ([]TodoChange, uint, error)
 }
 
 type todoService struct {
 	repo repository.TodoRepository
+	eventRepo      repository.TodoEventRepository
+	transactor     repository.Transactor
 	tokenUseCase   token.TokenUseCase
 	cacheable      cache.Cacheable
+	auditService   AuditService
+	publisher      event.Publisher
 }
 
 func NewTodoService(
 	repo repository.TodoRepository, 
+	eventRepo repository.TodoEventRepository,
+	transactor repository.Transactor,
 	tokenUseCase token.TokenUseCase,
 	cacheable cache.Cacheable,
+	auditService AuditService,
+	publisher event.Publisher,
 	) TodoService {
-	return &todoService{repo, tokenUseCase, cacheable}
+	return &todoService{repo, eventRepo, transactor, tokenUseCase, cacheable, auditService, publisher}
 }
 
-func (s *todoService) CreateTodo(ctx context.Context,userID uint, title string) (*entity.Todo, error) {
-	todo := &entity.Todo{UserID: userID, Title: title}
-	err := s.repo.Create(ctx, todo)
+func validateTodo(title, description string) error {
+	if utf8.RuneCountInString(title) > entity.MaxTitleLength {
+		return ErrTitleTooLong
+	}
+	if utf8.RuneCountInString(description) > entity.MaxDescriptionLength {
+		return ErrDescriptionTooLong
+	}
+	return nil
+}
+
+// normalizeTags memvalidasi tags dan membuang tag yang duplikat.
+func normalizeTags(tags entity.StringList) (entity.StringList, error) {
+	var normalized entity.StringList
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if !entity.ValidTag(tag) {
+			return nil, ErrInvalidTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// setDone mengubah status done sekaligus mengisi atau mengosongkan
+// completed_at. Todo yang dibuka kembali juga dikeluarkan dari arsip.
+func setDone(todo *entity.Todo, done bool) {
+	if done && !todo.Done {
+		now := time.Now()
+		todo.CompletedAt = &now
+	}
+	if !done && todo.Done {
+		todo.CompletedAt = nil
+		todo.ArchivedAt = nil
+	}
+	todo.Done = done
+}
+
+// changedColumns mengembalikan kolom yang berbeda antara before dan after
+// sehingga update tidak menimpa kolom lain.
+func changedColumns(before, after entity.Todo) map[string]interface{} {
+	columns := map[string]interface{}{}
+	if before.Title != after.Title {
+		columns["title"] = after.Title
+	}
+	if before.Description != after.Description {
+		columns["description"] = after.Description
+	}
+	if before.Done != after.Done {
+		columns["done"] = after.Done
+	}
+	if before.Priority != after.Priority {
+		columns["priority"] = after.Priority
+	}
+	if !equalTime(before.DueAt, after.DueAt) {
+		columns["due_at"] = after.DueAt
+	}
+	if before.Project != after.Project {
+		columns["project"] = after.Project
+	}
+	if !before.Tags.Equal(after.Tags) {
+		columns["tags"] = after.Tags
+	}
+	if before.DueAllDay != after.DueAllDay {
+		columns["due_all_day"] = after.DueAllDay
+	}
+	if !equalTime(before.CompletedAt, after.CompletedAt) {
+		columns["completed_at"] = after.CompletedAt
+	}
+	if !equalTime(before.ArchivedAt, after.ArchivedAt) {
+		columns["archived_at"] = after.ArchivedAt
+	}
+	return columns
+}
+
+// normalizeDue menyimpan due all-day sebagai tengah malam UTC dari
+// tanggalnya. Todo tanpa due tidak bisa all-day.
+func normalizeDue(todo *entity.Todo) {
+	if todo.DueAt == nil {
+		todo.DueAllDay = false
+		return
+	}
+	if todo.DueAllDay {
+		due := todo.DueAt.UTC()
+		due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+		todo.DueAt = &due
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// checkVersion memastikan version dari If-Match (0 jika tidak dikirim)
+// masih sama dengan version todo saat ini.
+func checkVersion(todo *entity.Todo, version uint) error {
+	if version != 0 && version != todo.Version {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+func (s *todoService) saveChanges(ctx context.Context, before entity.Todo, after *entity.Todo) error {
+	columns := changedColumns(before, *after)
+	if len(columns) == 0 {
+		return nil
+	}
+	if err := s.repo.Update(ctx, after.ID, before.Version, columns); err != nil {
+		return err
+	}
+	after.Version = before.Version + 1
+	return nil
+}
+
+func (s *todoService) CreateTodo(ctx context.Context,userID uint, title, description string) (*entity.Todo, error) {
+	if err := validateTodo(title, description); err != nil {
+		return nil, err
+	}
+	last, err := s.repo.GetLastPosition(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	keyGetTodos := "todo-list:todos:get-todos"
-	err = s.cacheable.Delete(keyGetTodos) // Menghapus cache lama
+	position, err := rank.Between(last, "")
 	if err != nil {
-		return nil, errors.New("falied deleting key cache")
+		return nil, err
+	}
+	todo := &entity.Todo{UserID: userID, Title: title, Description: description, Position: position}
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventCreated, diffTodo(entity.Todo{}, *todo))
+	})
+	if err != nil {
+		return nil, err
 	}
 	return todo, err
 }
 
 func (s *todoService) GetTodos(ctx context.Context) (result []entity.Todo, err error) {
 	//tambahkan cache redis di service nya
+	// cache dihapus oleh cacheInvalidator di outbox relay setiap ada event todo
 	keyGetTodos := "todo-list:todos:get-todos"
 	data := s.cacheable.Get(keyGetTodos)
 	if data == ""{
@@ -74,59 +249,391 @@ func (s *todoService) GetTodos(ctx context.Context) (result []entity.Todo, err e
 	return result, nil
 }
 
-func (s *todoService) GetTodosByUserID(ctx context.Context,userID uint) (result []entity.Todo, err error) {
-	keyGetTodos := "todo-list:todos:get-todos"
-	data := s.cacheable.Get(keyGetTodos)
-	if data == ""{
-		result, err = s.repo.GetByUserID(ctx, userID)
-		if err != nil{
-			return nil, err
+// GetTodosByUserID tidak memakai cache karena hasilnya berbeda per user.
+func (s *todoService) GetTodosByUserID(ctx context.Context,userID uint) ([]entity.Todo, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+
+func (s *todoService) UpdateTodo(ctx context.Context, userID, todoID, version uint, title, description string, done bool) error {
+	if err := validateTodo(title, description); err != nil {
+		return err
+	}
+	todo, err := s.repo.GetByID(ctx, todoID)
+	if err != nil || (todo.UserID != userID) {
+		return errors.New("unauthorized or not found")
+	}
+	if err := checkVersion(todo, version); err != nil {
+		return err
+	}
+	before := *todo
+	todo.Title = title
+	todo.Description = description
+	setDone(todo, done)
+	
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.saveChanges(ctx, before, todo); err != nil {
+			return err
 		}
-		marshalledData, err := json.Marshal(result)
-		if err != nil {
-			return nil, err
+		changes := diffTodo(before, *todo)
+		if len(changes) == 0 {
+			return nil
 		}
+		return s.recordEvent(ctx, todo, entity.TodoEventUpdated, changes)
+	})
+}
 
-		err = s.cacheable.Set(keyGetTodos, marshalledData, 5*time.Minute)
-		if err != nil {
-			return nil, err
+func (s *todoService) GetTodo(ctx context.Context, userID, todoID uint) (*entity.Todo, error) {
+	todo, err := s.repo.GetByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return nil, errors.New("unauthorized or not found")
+	}
+	return todo, nil
+}
+
+// PatchTodo hanya mengubah field yang diisi pada patch.
+func (s *todoService) PatchTodo(ctx context.Context, userID, todoID, version uint, patch entity.TodoPatch) (*entity.Todo, error) {
+	todo, err := s.repo.GetByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return nil, errors.New("unauthorized or not found")
+	}
+	if err := checkVersion(todo, version); err != nil {
+		return nil, err
+	}
+
+	before := *todo
+	if patch.Title != nil {
+		todo.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		todo.Description = *patch.Description
+	}
+	if patch.Done != nil {
+		setDone(todo, *patch.Done)
+	}
+	if patch.Priority != nil {
+		if !entity.ValidPriority(*patch.Priority) {
+			return nil, ErrInvalidPriority
 		}
-	}else{
-		err = json.Unmarshal([]byte(data), &result)
+		todo.Priority = *patch.Priority
+	}
+	if patch.DueAt != nil {
+		todo.DueAt = patch.DueAt
+		if patch.DueAt.IsZero() {
+			todo.DueAt = nil
+		}
+	}
+	if patch.DueAllDay != nil {
+		todo.DueAllDay = *patch.DueAllDay
+	}
+	normalizeDue(todo)
+	if patch.Project != nil {
+		if !entity.ValidProject(*patch.Project) {
+			return nil, ErrInvalidProject
+		}
+		todo.Project = *patch.Project
+	}
+	if patch.Tags != nil {
+		tags, err := normalizeTags(*patch.Tags)
 		if err != nil {
 			return nil, err
 		}
+		todo.Tags = tags
+	}
+	if err := validateTodo(todo.Title, todo.Description); err != nil {
+		return nil, err
+	}
+	changes := diffTodo(before, *todo)
+	if len(changes) == 0 {
+		return todo, nil
 	}
-	return result, nil
-}
 
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.saveChanges(ctx, before, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventUpdated, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := todo.RenderDescription(); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
 
-func (s *todoService) UpdateTodo(ctx context.Context, userID, todoID uint, title string, done bool) error {
+func (s *todoService) DeleteTodo(ctx context.Context,userID, todoID, version uint) error {
 	todo, err := s.repo.GetByID(ctx, todoID)
-	if err != nil || (todo.UserID != userID) {
+	if err != nil || ( todo.UserID != userID) {
 		return errors.New("unauthorized or not found")
 	}
-	todo.Title = title
-	todo.Done = done
-	
-	keyGetTodos := "todo-list:todos:get-todos"
-	err = s.cacheable.Delete(keyGetTodos) // Menghapus cache lama
+	if err := checkVersion(todo, version); err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// todo bisa diubah request lain setelah checkVersion
+		if err := s.repo.Delete(ctx, todoID, todo.Version); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventDeleted, nil)
+	})
+}
+
+func (s *todoService) GetTrash(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	return s.repo.GetTrashByUserID(ctx, userID)
+}
+
+func (s *todoService) RestoreTodo(ctx context.Context, userID, todoID uint) error {
+	todo, err := s.repo.GetTrashedByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return errors.New("unauthorized or not found")
+	}
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, todoID); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventRestored, nil)
+	})
+}
+
+// PurgeTodo menghapus permanen todo yang sudah berada di trash.
+func (s *todoService) PurgeTodo(ctx context.Context, userID, todoID uint) error {
+	todo, err := s.repo.GetTrashedByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return errors.New("unauthorized or not found")
+	}
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Purge(ctx, todoID); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventPurged, nil)
+	})
+}
+
+func (s *todoService) PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	var purged []entity.Todo
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if purged, err = s.repo.PurgeDeletedBefore(ctx, time.Now().Add(-retention)); err != nil {
+			return err
+		}
+		for i := range purged {
+			if err := s.recordEvent(ctx, &purged[i], entity.TodoEventPurged, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New("falied deleting key cache")
+		return 0, err
 	}
-	return s.repo.Update(ctx, todo)
+	return int64(len(purged)), nil
+}
+
+func (s *todoService) GetArchive(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	return s.repo.GetArchivedByUserID(ctx, userID)
 }
 
-func (s *todoService) DeleteTodo(ctx context.Context,userID, todoID uint) error {
+func (s *todoService) ArchiveTodo(ctx context.Context, userID, todoID, version uint) error {
 	todo, err := s.repo.GetByID(ctx, todoID)
-	if err != nil || ( todo.UserID != userID) {
+	if err != nil || todo.UserID != userID {
 		return errors.New("unauthorized or not found")
 	}
+	if err := checkVersion(todo, version); err != nil {
+		return err
+	}
+	if todo.ArchivedAt != nil {
+		return nil
+	}
+	before := *todo
+	now := time.Now()
+	todo.ArchivedAt = &now
 
-	keyGetTodos := "todo-list:todos:get-todos"
-	err = s.cacheable.Delete(keyGetTodos) // Menghapus cache lama
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.saveChanges(ctx, before, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventArchived, diffTodo(before, *todo))
+	})
+}
+
+func (s *todoService) UnarchiveTodo(ctx context.Context, userID, todoID, version uint) error {
+	todo, err := s.repo.GetByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return errors.New("unauthorized or not found")
+	}
+	if err := checkVersion(todo, version); err != nil {
+		return err
+	}
+	if todo.ArchivedAt == nil {
+		return nil
+	}
+	before := *todo
+	todo.ArchivedAt = nil
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.saveChanges(ctx, before, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventUnarchived, diffTodo(before, *todo))
+	})
+}
+
+// AutoArchive mengarsipkan todo yang sudah selesai lebih lama dari after.
+func (s *todoService) AutoArchive(ctx context.Context, after time.Duration) (int64, error) {
+	var todos []entity.Todo
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if todos, err = s.repo.ArchiveCompletedBefore(ctx, time.Now().Add(-after)); err != nil {
+			return err
+		}
+		for i := range todos {
+			before := todos[i]
+			before.ArchivedAt = nil
+			if err := s.recordEvent(ctx, &todos[i], entity.TodoEventArchived, diffTodo(before, todos[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(todos)), nil
+}
+
+// MoveTodo memindahkan todo sebelum beforeID dan/atau sesudah afterID.
+// Hanya kolom position milik todo yang dipindah yang diubah.
+func (s *todoService) MoveTodo(ctx context.Context, userID, todoID uint, beforeID, afterID *uint) (*entity.Todo, error) {
+	if beforeID == nil && afterID == nil {
+		return nil, ErrMissingAnchor
+	}
+	var todo *entity.Todo
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// kunci todo dan anchor agar position tidak berubah sebelum update
+		ids := []uint{todoID}
+		for _, anchor := range []*uint{beforeID, afterID} {
+			if anchor != nil {
+				ids = append(ids, *anchor)
+			}
+		}
+		locked, err := s.repo.LockByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for i := range locked {
+			if locked[i].ID == todoID && locked[i].UserID == userID {
+				todo = &locked[i]
+			}
+		}
+		if todo == nil {
+			return errors.New("unauthorized or not found")
+		}
+
+		position, err := s.positionBetween(ctx, userID, beforeID, afterID)
+		if errors.Is(err, rank.ErrInvalidRange) {
+			// anchor memiliki position yang sama (mis. data lama), rebalance lalu coba lagi
+			if err = s.rebalance(ctx, userID); err != nil {
+				return err
+			}
+			if todo, err = s.repo.GetByID(ctx, todoID); err != nil {
+				return err
+			}
+			position, err = s.positionBetween(ctx, userID, beforeID, afterID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.savePosition(ctx, todo, position)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+func (s *todoService) positionBetween(ctx context.Context, userID uint, beforeID, afterID *uint) (string, error) {
+	var lower, upper string
+	if afterID != nil {
+		anchor, err := s.repo.GetByID(ctx, *afterID)
+		if err != nil || anchor.UserID != userID {
+			return "", errors.New("unauthorized or not found")
+		}
+		lower = anchor.Position
+	}
+	if beforeID != nil {
+		anchor, err := s.repo.GetByID(ctx, *beforeID)
+		if err != nil || anchor.UserID != userID {
+			return "", errors.New("unauthorized or not found")
+		}
+		upper = anchor.Position
+		if upper == "" {
+			return "", rank.ErrInvalidRange
+		}
+	}
+
+	var err error
+	switch {
+	case afterID == nil:
+		lower, err = s.repo.GetPositionBefore(ctx, userID, upper)
+	case beforeID == nil:
+		upper, err = s.repo.GetPositionAfter(ctx, userID, lower)
+	}
+	if err != nil {
+		return "", err
+	}
+	return rank.Between(lower, upper)
+}
+
+// rebalance menulis ulang position seluruh todo milik user dengan key yang
+// berjarak merata tanpa mengubah urutannya.
+func (s *todoService) rebalance(ctx context.Context, userID uint) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		todos, err := s.repo.LockByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		keys := rank.Spread(len(todos))
+		for i := range todos {
+			if todos[i].Position == keys[i] {
+				continue
+			}
+			if err := s.savePosition(ctx, &todos[i], keys[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// savePosition dipanggil di dalam transaksi, event moved hanya mencatat
+// position karena urutan tidak ikut di-revert.
+func (s *todoService) savePosition(ctx context.Context, todo *entity.Todo, position string) error {
+	if err := s.repo.Update(ctx, todo.ID, todo.Version, map[string]interface{}{"position": position}); err != nil {
+		return err
+	}
+	changes := entity.FieldChanges{"position": {Old: todo.Position, New: position}}
+	todo.Position = position
+	todo.Version++
+	return s.recordEvent(ctx, todo, entity.TodoEventMoved, changes)
+}
+
+// RebalancePositions menulis ulang position milik user yang key-nya sudah
+// melebihi maxLength. Mengembalikan jumlah user yang di-rebalance.
+func (s *todoService) RebalancePositions(ctx context.Context, maxLength int) (int, error) {
+	userIDs, err := s.repo.GetUserIDsWithLongPositions(ctx, maxLength)
 	if err != nil {
-		return errors.New("falied deleting key cache")
+		return 0, err
+	}
+	for _, userID := range userIDs {
+		if err := s.rebalance(ctx, userID); err != nil {
+			return 0, err
+		}
 	}
-	return s.repo.Delete(ctx, todoID)
+	return len(userIDs), nil
 }
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"todo-list/internal/entity"
+)
+
+const (
+	BulkComplete      = "complete"
+	BulkUncomplete    = "uncomplete"
+	BulkDelete        = "delete"
+	BulkMoveToProject = "move-to-project"
+	BulkAddTag        = "add-tag"
+
+	MaxBulkItems = 500
+)
+
+var (
+	ErrBulkUnknownAction = errors.New("unknown bulk action")
+	ErrBulkEmpty         = errors.New("ids must not be empty")
+	ErrBulkTooMany       = errors.New("too many ids in a single bulk request")
+)
+
+// BulkArgs berisi argumen action. Project dipakai move-to-project (kosong
+// berarti dikeluarkan dari project), Tag dipakai add-tag.
+type BulkArgs struct {
+	Project string
+	Tag     string
+}
+
+type BulkResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkUpdate menjalankan action terhadap banyak todo dalam satu transaksi.
+// Todo yang bukan milik user atau gagal diubah dilewati dan dilaporkan per
+// item; perubahan item lain tetap disimpan.
+func (s *todoService) BulkUpdate(ctx context.Context, userID uint, action string, ids []uint, args BulkArgs) ([]BulkResult, error) {
+	switch action {
+	case BulkComplete, BulkUncomplete, BulkDelete:
+	case BulkMoveToProject:
+		if !entity.ValidProject(args.Project) {
+			return nil, ErrInvalidProject
+		}
+	case BulkAddTag:
+		if !entity.ValidTag(args.Tag) {
+			return nil, ErrInvalidTag
+		}
+	default:
+		return nil, ErrBulkUnknownAction
+	}
+	if len(ids) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	if len(ids) > MaxBulkItems {
+		return nil, ErrBulkTooMany
+	}
+
+	results := make([]BulkResult, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			todo, err := s.repo.GetByID(ctx, id)
+			if err != nil || todo.UserID != userID {
+				results = append(results, BulkResult{ID: id, Status: "failed", Error: "unauthorized or not found"})
+				continue
+			}
+			// savepoint per item agar item yang gagal tidak membatalkan transaksi
+			err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				return s.applyBulkAction(ctx, todo, action, args)
+			})
+			if err != nil {
+				results = append(results, BulkResult{ID: id, Status: "failed", Error: err.Error()})
+				continue
+			}
+			results = append(results, BulkResult{ID: id, Status: "ok"})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *todoService) applyBulkAction(ctx context.Context, todo *entity.Todo, action string, args BulkArgs) error {
+	if action == BulkDelete {
+		if err := s.repo.Delete(ctx, todo.ID, todo.Version); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventDeleted, nil)
+	}
+
+	before := *todo
+	switch action {
+	case BulkComplete, BulkUncomplete:
+		setDone(todo, action == BulkComplete)
+	case BulkMoveToProject:
+		todo.Project = args.Project
+	case BulkAddTag:
+		if !todo.HasTag(args.Tag) {
+			// slice baru agar before.Tags tidak ikut berubah
+			todo.Tags = append(append(entity.StringList{}, todo.Tags...), args.Tag)
+		}
+	}
+	changes := diffTodo(before, *todo)
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := s.saveChanges(ctx, before, todo); err != nil {
+		return err
+	}
+	return s.recordEvent(ctx, todo, entity.TodoEventUpdated, changes)
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"todo-list/internal/entity"
+	"todo-list/pkg/rank"
+
+	"gorm.io/gorm"
+)
+
+var ErrResourceNotFound = errors.New("calendar object not found")
+
+// TodoChange adalah perubahan satu todo sejak sync token tertentu. Todo nil
+// berarti todo sudah dihapus.
+type TodoChange struct {
+	TodoID       uint
+	ResourceName string
+	Todo         *entity.Todo
+}
+
+// GetTodoByResourceName mencari todo berdasarkan nama calendar object.
+// Nama pilihan client didahulukan, lalu nama bawaan "<id>.ics".
+func (s *todoService) GetTodoByResourceName(ctx context.Context, userID uint, name string) (*entity.Todo, error) {
+	todo, err := s.repo.GetByCalDAVName(ctx, userID, name)
+	if err == nil {
+		return todo, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	id, err := strconv.ParseUint(strings.TrimSuffix(name, ".ics"), 10, 32)
+	if err != nil || !strings.HasSuffix(name, ".ics") {
+		return nil, ErrResourceNotFound
+	}
+	todo, err = s.repo.GetByID(ctx, uint(id))
+	if err != nil || todo.UserID != userID || todo.CalDAVName != "" {
+		return nil, ErrResourceNotFound
+	}
+	return todo, nil
+}
+
+// PutTodoResource membuat atau mengganti todo dari VTODO yang dikirim client
+// CalDAV. version berasal dari If-Match dan createOnly dari
+// If-None-Match: *. Mengembalikan true jika todo baru dibuat.
+func (s *todoService) PutTodoResource(ctx context.Context, userID uint, name, uid string, input entity.Todo, version uint, createOnly bool) (*entity.Todo, bool, error) {
+	if !entity.ValidPriority(input.Priority) {
+		return nil, false, ErrInvalidPriority
+	}
+	if err := validateTodo(input.Title, input.Description); err != nil {
+		return nil, false, err
+	}
+
+	todo, err := s.GetTodoByResourceName(ctx, userID, name)
+	if err != nil && !errors.Is(err, ErrResourceNotFound) {
+		return nil, false, err
+	}
+	if todo == nil {
+		if version != 0 {
+			return nil, false, ErrPreconditionFailed
+		}
+		todo, err = s.createTodoResource(ctx, userID, name, uid, input)
+		return todo, err == nil, err
+	}
+	if createOnly {
+		return nil, false, ErrPreconditionFailed
+	}
+	if err := checkVersion(todo, version); err != nil {
+		return nil, false, err
+	}
+
+	before := *todo
+	todo.Title = input.Title
+	todo.Description = input.Description
+	todo.Priority = input.Priority
+	todo.DueAt = input.DueAt
+	todo.DueAllDay = input.DueAllDay
+	normalizeDue(todo)
+	setDone(todo, input.Done)
+	if input.Done && input.CompletedAt != nil {
+		todo.CompletedAt = input.CompletedAt
+	}
+	changes := diffTodo(before, *todo)
+	if len(changes) == 0 {
+		return todo, false, nil
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.saveChanges(ctx, before, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventUpdated, changes)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return todo, false, nil
+}
+
+func (s *todoService) createTodoResource(ctx context.Context, userID uint, name, uid string, input entity.Todo) (*entity.Todo, error) {
+	last, err := s.repo.GetLastPosition(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	position, err := rank.Between(last, "")
+	if err != nil {
+		return nil, err
+	}
+	todo := &entity.Todo{
+		UserID:      userID,
+		Title:       input.Title,
+		Description: input.Description,
+		Priority:    input.Priority,
+		DueAt:       input.DueAt,
+		DueAllDay:   input.DueAllDay,
+		Position:    position,
+		ICalUID:     uid,
+		CalDAVName:  name,
+	}
+	normalizeDue(todo)
+	setDone(todo, input.Done)
+	if input.Done && input.CompletedAt != nil {
+		todo.CompletedAt = input.CompletedAt
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventCreated, diffTodo(entity.Todo{}, *todo))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// GetSyncToken mengembalikan ID event terakhir milik user sebagai sync token.
+func (s *todoService) GetSyncToken(ctx context.Context, userID uint) (uint, error) {
+	return s.eventRepo.GetLatestIDByUserID(ctx, userID)
+}
+
+// GetTodoChanges mengembalikan todo yang berubah setelah sync token since
+// beserta sync token terbaru. Perubahan dibaca dari todo_events.
+func (s *todoService) GetTodoChanges(ctx context.Context, userID, since uint) ([]TodoChange, uint, error) {
+	events, err := s.eventRepo.GetByUserIDAfter(ctx, userID, since)
+	if err != nil {
+		return nil, 0, err
+	}
+	latest := since
+	changed := map[uint]bool{}
+	var ids []uint
+	for _, event := range events {
+		latest = event.ID
+		if !changed[event.TodoID] {
+			changed[event.TodoID] = true
+			ids = append(ids, event.TodoID)
+		}
+	}
+
+	changes := make([]TodoChange, 0, len(ids))
+	for _, id := range ids {
+		change := TodoChange{TodoID: id, ResourceName: strconv.FormatUint(uint64(id), 10) + ".ics"}
+		todo, err := s.repo.GetByID(ctx, id)
+		switch {
+		case err == nil:
+			change.Todo = todo
+			change.ResourceName = todo.ResourceName()
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// todo di trash masih menyimpan nama resource-nya
+			if trashed, err := s.repo.GetTrashedByID(ctx, id); err == nil {
+				change.ResourceName = trashed.ResourceName()
+			}
+		default:
+			return nil, 0, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, latest, nil
+}
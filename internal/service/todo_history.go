@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/pkg/actor"
+)
+
+var ErrEventNotFound = errors.New("history event not found")
+
+// diffTodo membandingkan field todo yang bisa diubah user.
+func diffTodo(before, after entity.Todo) entity.FieldChanges {
+	changes := entity.FieldChanges{}
+	if before.Title != after.Title {
+		changes["title"] = entity.FieldChange{Old: before.Title, New: after.Title}
+	}
+	if before.Description != after.Description {
+		changes["description"] = entity.FieldChange{Old: before.Description, New: after.Description}
+	}
+	if before.Done != after.Done {
+		changes["done"] = entity.FieldChange{Old: before.Done, New: after.Done}
+	}
+	if before.Priority != after.Priority {
+		changes["priority"] = entity.FieldChange{Old: before.Priority, New: after.Priority}
+	}
+	if !equalTime(before.DueAt, after.DueAt) {
+		changes["due_at"] = entity.FieldChange{Old: before.DueAt, New: after.DueAt}
+	}
+	if !equalTime(before.CompletedAt, after.CompletedAt) {
+		changes["completed_at"] = entity.FieldChange{Old: before.CompletedAt, New: after.CompletedAt}
+	}
+	if !equalTime(before.ArchivedAt, after.ArchivedAt) {
+		changes["archived_at"] = entity.FieldChange{Old: before.ArchivedAt, New: after.ArchivedAt}
+	}
+	if before.DueAllDay != after.DueAllDay {
+		changes["due_all_day"] = entity.FieldChange{Old: before.DueAllDay, New: after.DueAllDay}
+	}
+	if before.Project != after.Project {
+		changes["project"] = entity.FieldChange{Old: before.Project, New: after.Project}
+	}
+	if !before.Tags.Equal(after.Tags) {
+		changes["tags"] = entity.FieldChange{Old: []string(before.Tags), New: []string(after.Tags)}
+	}
+	return changes
+}
+
+// applyField mengisi field todo dari nilai history (hasil decode JSON).
+func applyField(todo *entity.Todo, field string, value interface{}) {
+	switch field {
+	case "title":
+		if v, ok := value.(string); ok {
+			todo.Title = v
+		}
+	case "description":
+		if v, ok := value.(string); ok {
+			todo.Description = v
+		}
+	case "done":
+		// completed_at dikembalikan dari history-nya sendiri, bukan dari setDone
+		if v, ok := value.(bool); ok {
+			todo.Done = v
+		}
+	case "completed_at":
+		todo.CompletedAt = historyTime(value)
+	case "archived_at":
+		todo.ArchivedAt = historyTime(value)
+	case "priority":
+		if v, ok := value.(string); ok {
+			todo.Priority = v
+		}
+	case "due_at":
+		todo.DueAt = historyTime(value)
+	case "due_all_day":
+		if v, ok := value.(bool); ok {
+			todo.DueAllDay = v
+		}
+	case "project":
+		if v, ok := value.(string); ok {
+			todo.Project = v
+		}
+	case "tags":
+		todo.Tags = nil
+		if v, ok := value.([]interface{}); ok {
+			for _, tag := range v {
+				if tag, ok := tag.(string); ok {
+					todo.Tags = append(todo.Tags, tag)
+				}
+			}
+		}
+	}
+}
+
+func historyTime(value interface{}) *time.Time {
+	if v, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func (s *todoService) recordEvent(ctx context.Context, todo *entity.Todo, action string, changes entity.FieldChanges) error {
+	todoEvent := &entity.TodoEvent{
+		TodoID:  todo.ID,
+		UserID:  todo.UserID,
+		Action:  action,
+		Changes: changes,
+	}
+	if a, ok := actor.FromContext(ctx); ok {
+		todoEvent.ActorID = a.UserID
+		todoEvent.AsAdmin = a.Role == "admin" && a.UserID != todo.UserID
+	}
+	if err := s.eventRepo.Create(ctx, todoEvent); err != nil {
+		return err
+	}
+	// publisher menulis ke outbox pada transaksi yang sama
+	if err := s.publisher.Publish(ctx, event.FromTodoEvent(todoEvent, *todo)); err != nil {
+		return err
+	}
+
+	// aksi admin terhadap todo milik user lain juga masuk audit log
+	if todoEvent.AsAdmin {
+		return s.auditService.Record(ctx, entity.AuditAdminTodo+action, "todo", strconv.FormatUint(uint64(todo.ID), 10), map[string]interface{}{
+			"owner_id": todo.UserID,
+			"changes":  changes,
+		})
+	}
+	return nil
+}
+
+func (s *todoService) GetTodoHistory(ctx context.Context, userID, todoID uint) ([]entity.TodoEvent, error) {
+	todo, err := s.repo.GetByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return nil, errors.New("unauthorized or not found")
+	}
+	return s.eventRepo.GetByTodoID(ctx, todoID)
+}
+
+// RevertTodo mengembalikan todo ke kondisi tepat setelah event eventID
+// dengan membatalkan perubahan dari event-event sesudahnya.
+func (s *todoService) RevertTodo(ctx context.Context, userID, todoID, version, eventID uint) (*entity.Todo, error) {
+	todo, err := s.repo.GetByID(ctx, todoID)
+	if err != nil || todo.UserID != userID {
+		return nil, errors.New("unauthorized or not found")
+	}
+	if err := checkVersion(todo, version); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetByID(ctx, eventID)
+	if err != nil || event.TodoID != todoID {
+		return nil, ErrEventNotFound
+	}
+	newer, err := s.eventRepo.GetNewerThan(ctx, todoID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *todo
+	for _, e := range newer {
+		for field, change := range e.Changes {
+			applyField(todo, field, change.Old)
+		}
+	}
+	// event lama belum mencatat completed_at
+	if !todo.Done {
+		todo.CompletedAt = nil
+		todo.ArchivedAt = nil
+	} else if todo.CompletedAt == nil {
+		now := time.Now()
+		todo.CompletedAt = &now
+	}
+	normalizeDue(todo)
+	changes := diffTodo(before, *todo)
+	if len(changes) == 0 {
+		return todo, nil
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.saveChanges(ctx, before, todo); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, todo, entity.TodoEventReverted, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
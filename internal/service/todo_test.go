@@ -0,0 +1,925 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/cache"
+
+	"gorm.io/gorm"
+)
+
+// fakeTodoRepository menyimpan todo di memory, termasuk yang sudah di trash.
+type fakeTodoRepository struct {
+	repository.TodoRepository
+	todos  map[uint]*entity.Todo
+	nextID uint
+	// stale berisi id yang diubah request lain setelah dibaca, sehingga
+	// GetByID mengembalikan version lama.
+	stale map[uint]bool
+}
+
+func (r *fakeTodoRepository) Create(ctx context.Context, todo *entity.Todo) error {
+	r.nextID++
+	todo.ID = r.nextID
+	if todo.Version == 0 {
+		todo.Version = 1
+	}
+	stored := *todo
+	r.todos[todo.ID] = &stored
+	return nil
+}
+
+func (r *fakeTodoRepository) find(match func(todo *entity.Todo) bool) []entity.Todo {
+	var todos []entity.Todo
+	for _, todo := range r.todos {
+		if match(todo) {
+			todos = append(todos, *todo)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+	return todos
+}
+
+func (r *fakeTodoRepository) GetAll(ctx context.Context) ([]entity.Todo, error) {
+	return r.find(func(todo *entity.Todo) bool { return !todo.DeletedAt.Valid }), nil
+}
+
+func (r *fakeTodoRepository) GetByID(ctx context.Context, id uint) (*entity.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *todo
+	if r.stale[id] {
+		found.Version--
+	}
+	return &found, nil
+}
+
+func (r *fakeTodoRepository) GetByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	return r.find(func(todo *entity.Todo) bool {
+		return todo.UserID == userID && !todo.DeletedAt.Valid && todo.ArchivedAt == nil
+	}), nil
+}
+
+func (r *fakeTodoRepository) GetLastPosition(ctx context.Context, userID uint) (string, error) {
+	last := ""
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.Position > last {
+			last = todo.Position
+		}
+	}
+	return last, nil
+}
+
+func (r *fakeTodoRepository) Update(ctx context.Context, id, version uint, columns map[string]interface{}) error {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid || todo.Version != version {
+		return repository.ErrVersionConflict
+	}
+	timeValue := func(v interface{}) *time.Time {
+		t, _ := v.(*time.Time)
+		return t
+	}
+	for column, value := range columns {
+		switch column {
+		case "title":
+			todo.Title = value.(string)
+		case "description":
+			todo.Description = value.(string)
+		case "done":
+			todo.Done = value.(bool)
+		case "project":
+			todo.Project = value.(string)
+		case "position":
+			todo.Position = value.(string)
+		case "tags":
+			todo.Tags = value.(entity.StringList)
+		case "completed_at":
+			todo.CompletedAt = timeValue(value)
+		case "archived_at":
+			todo.ArchivedAt = timeValue(value)
+		}
+	}
+	todo.Version++
+	return nil
+}
+
+func (r *fakeTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid || todo.Version != version {
+		return repository.ErrVersionConflict
+	}
+	todo.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *fakeTodoRepository) GetTrashByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	return r.find(func(todo *entity.Todo) bool {
+		return todo.UserID == userID && todo.DeletedAt.Valid
+	}), nil
+}
+
+func (r *fakeTodoRepository) GetTrashedByID(ctx context.Context, id uint) (*entity.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *todo
+	if r.stale[id] {
+		found.Version--
+	}
+	return &found, nil
+}
+
+func (r *fakeTodoRepository) Restore(ctx context.Context, id uint) error {
+	todo := r.todos[id]
+	todo.DeletedAt = gorm.DeletedAt{}
+	todo.Version++
+	return nil
+}
+
+func (r *fakeTodoRepository) Purge(ctx context.Context, id uint) error {
+	delete(r.todos, id)
+	return nil
+}
+
+func (r *fakeTodoRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]entity.Todo, error) {
+	purged := r.find(func(todo *entity.Todo) bool {
+		return todo.DeletedAt.Valid && todo.DeletedAt.Time.Before(cutoff)
+	})
+	for _, todo := range purged {
+		delete(r.todos, todo.ID)
+	}
+	return purged, nil
+}
+
+func (r *fakeTodoRepository) GetArchivedByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	return r.find(func(todo *entity.Todo) bool {
+		return todo.UserID == userID && !todo.DeletedAt.Valid && todo.ArchivedAt != nil
+	}), nil
+}
+
+func (r *fakeTodoRepository) ArchiveCompletedBefore(ctx context.Context, cutoff time.Time) ([]entity.Todo, error) {
+	now := time.Now()
+	archived := r.find(func(todo *entity.Todo) bool {
+		return todo.Done && todo.ArchivedAt == nil && !todo.DeletedAt.Valid && todo.CompletedAt.Before(cutoff)
+	})
+	for i := range archived {
+		todo := r.todos[archived[i].ID]
+		todo.ArchivedAt = &now
+		todo.Version++
+		archived[i] = *todo
+	}
+	return archived, nil
+}
+
+func (r *fakeTodoRepository) LockByIDs(ctx context.Context, ids []uint) ([]entity.Todo, error) {
+	wanted := map[uint]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	return r.find(func(todo *entity.Todo) bool { return wanted[todo.ID] && !todo.DeletedAt.Valid }), nil
+}
+
+func (r *fakeTodoRepository) LockByUserID(ctx context.Context, userID uint) ([]entity.Todo, error) {
+	todos := r.find(func(todo *entity.Todo) bool { return todo.UserID == userID && !todo.DeletedAt.Valid })
+	sort.SliceStable(todos, func(i, j int) bool { return todos[i].Position < todos[j].Position })
+	return todos, nil
+}
+
+func (r *fakeTodoRepository) GetPositionAfter(ctx context.Context, userID uint, position string) (string, error) {
+	next := ""
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.Position > position && (next == "" || todo.Position < next) {
+			next = todo.Position
+		}
+	}
+	return next, nil
+}
+
+func (r *fakeTodoRepository) GetPositionBefore(ctx context.Context, userID uint, position string) (string, error) {
+	prev := ""
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.Position < position && todo.Position > prev {
+			prev = todo.Position
+		}
+	}
+	return prev, nil
+}
+
+func (r *fakeTodoRepository) GetUserIDsWithLongPositions(ctx context.Context, maxLength int) ([]uint, error) {
+	seen := map[uint]bool{}
+	var userIDs []uint
+	for _, todo := range r.find(func(todo *entity.Todo) bool { return len(todo.Position) > maxLength }) {
+		if !seen[todo.UserID] {
+			seen[todo.UserID] = true
+			userIDs = append(userIDs, todo.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+type fakeTodoEventRepository struct {
+	repository.TodoEventRepository
+	events []entity.TodoEvent
+}
+
+func (r *fakeTodoEventRepository) Create(ctx context.Context, e *entity.TodoEvent) error {
+	e.ID = uint(len(r.events) + 1)
+	e.CreatedAt = time.Now()
+	// simpan seperti kolom jsonb agar nilai changes sama dengan hasil decode
+	stored := *e
+	value, err := e.Changes.Value()
+	if err != nil {
+		return err
+	}
+	stored.Changes = nil
+	if err := stored.Changes.Scan(value); err != nil {
+		return err
+	}
+	r.events = append(r.events, stored)
+	return nil
+}
+
+func (r *fakeTodoEventRepository) GetByID(ctx context.Context, id uint) (*entity.TodoEvent, error) {
+	if id == 0 || int(id) > len(r.events) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	e := r.events[id-1]
+	return &e, nil
+}
+
+func (r *fakeTodoEventRepository) GetByTodoID(ctx context.Context, todoID uint) ([]entity.TodoEvent, error) {
+	var events []entity.TodoEvent
+	for _, e := range r.events {
+		if e.TodoID == todoID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeTodoEventRepository) GetNewerThan(ctx context.Context, todoID, eventID uint) ([]entity.TodoEvent, error) {
+	var events []entity.TodoEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if e := r.events[i]; e.TodoID == todoID && e.ID > eventID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeTodoEventRepository) actions(todoID uint) []string {
+	var actions []string
+	for _, e := range r.events {
+		if e.TodoID == todoID {
+			actions = append(actions, e.Action)
+		}
+	}
+	return actions
+}
+
+type fakeTodoTransactor struct{}
+
+func (fakeTodoTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeTodoCache struct {
+	cache.Cacheable
+	values map[string]string
+}
+
+func (c *fakeTodoCache) Get(key string) string {
+	return c.values[key]
+}
+
+func (c *fakeTodoCache) Set(key string, value interface{}, duration time.Duration) error {
+	switch v := value.(type) {
+	case []byte:
+		c.values[key] = string(v)
+	case string:
+		c.values[key] = v
+	}
+	return nil
+}
+
+func (c *fakeTodoCache) Delete(key string) error {
+	delete(c.values, key)
+	return nil
+}
+
+type fakeTodoPublisher struct {
+	events []event.Event
+}
+
+func (p *fakeTodoPublisher) Publish(ctx context.Context, e event.Event) error {
+	p.events = append(p.events, e)
+	return nil
+}
+
+type todoTest struct {
+	service *todoService
+	todos   *fakeTodoRepository
+	events  *fakeTodoEventRepository
+	cache   *fakeTodoCache
+}
+
+func newTodoTest() *todoTest {
+	tt := &todoTest{
+		todos:  &fakeTodoRepository{todos: map[uint]*entity.Todo{}},
+		events: &fakeTodoEventRepository{},
+		cache:  &fakeTodoCache{values: map[string]string{}},
+	}
+	tt.service = NewTodoService(tt.todos, tt.events, fakeTodoTransactor{}, nil, tt.cache, nil, &fakeTodoPublisher{}).(*todoService)
+	return tt
+}
+
+func (tt *todoTest) create(t *testing.T, userID uint, title string) *entity.Todo {
+	t.Helper()
+	todo, err := tt.service.CreateTodo(context.Background(), userID, title, "")
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	return todo
+}
+
+func (tt *todoTest) delete(t *testing.T, todo *entity.Todo) {
+	t.Helper()
+	if err := tt.service.DeleteTodo(context.Background(), todo.UserID, todo.ID, 0); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+}
+
+func todoIDs(todos []entity.Todo) []uint {
+	ids := make([]uint, 0, len(todos))
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+func equalIDs(got []uint, want ...uint) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDeletedTodoOnlyVisibleInTrash(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	kept := tt.create(t, 1, "kept")
+	deleted := tt.create(t, 1, "deleted")
+	other := tt.create(t, 2, "other user")
+	tt.delete(t, deleted)
+	tt.delete(t, other)
+
+	todos, err := tt.service.GetTodosByUserID(ctx, 1)
+	if err != nil || !equalIDs(todoIDs(todos), kept.ID) {
+		t.Errorf("GetTodosByUserID = %v, %v; want only %d", todoIDs(todos), err, kept.ID)
+	}
+	trash, err := tt.service.GetTrash(ctx, 1)
+	if err != nil || !equalIDs(todoIDs(trash), deleted.ID) {
+		t.Errorf("GetTrash = %v, %v; want only %d", todoIDs(trash), err, deleted.ID)
+	}
+	if _, err := tt.service.GetTodo(ctx, 1, deleted.ID); err == nil {
+		t.Error("GetTodo returned a trashed todo")
+	}
+	if err := tt.service.DeleteTodo(ctx, 1, deleted.ID, 0); err == nil {
+		t.Error("deleting a trashed todo again succeeded")
+	}
+}
+
+func TestRestoreTodo(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "restore me")
+
+	if err := tt.service.RestoreTodo(ctx, 1, todo.ID); err == nil {
+		t.Error("restoring a todo that is not in the trash succeeded")
+	}
+	tt.delete(t, todo)
+	if err := tt.service.RestoreTodo(ctx, 2, todo.ID); err == nil {
+		t.Error("another user restored the todo")
+	}
+	if err := tt.service.RestoreTodo(ctx, 1, todo.ID); err != nil {
+		t.Fatalf("RestoreTodo: %v", err)
+	}
+
+	if _, err := tt.service.GetTodo(ctx, 1, todo.ID); err != nil {
+		t.Errorf("restored todo not found: %v", err)
+	}
+	if trash, _ := tt.service.GetTrash(ctx, 1); len(trash) != 0 {
+		t.Errorf("trash still contains %v", todoIDs(trash))
+	}
+	want := []string{entity.TodoEventCreated, entity.TodoEventDeleted, entity.TodoEventRestored}
+	if got := tt.events.actions(todo.ID); len(got) != len(want) || got[2] != want[2] {
+		t.Errorf("events %v, want %v", got, want)
+	}
+}
+
+func TestPurgeTodoOnlyFromTrash(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "purge me")
+
+	if err := tt.service.PurgeTodo(ctx, 1, todo.ID); err == nil {
+		t.Fatal("purging a todo that is not in the trash succeeded")
+	}
+	if _, ok := tt.todos.todos[todo.ID]; !ok {
+		t.Fatal("active todo was purged")
+	}
+
+	tt.delete(t, todo)
+	if err := tt.service.PurgeTodo(ctx, 2, todo.ID); err == nil {
+		t.Error("another user purged the todo")
+	}
+	if err := tt.service.PurgeTodo(ctx, 1, todo.ID); err != nil {
+		t.Fatalf("PurgeTodo: %v", err)
+	}
+	if _, ok := tt.todos.todos[todo.ID]; ok {
+		t.Error("todo still exists after purge")
+	}
+	if err := tt.service.RestoreTodo(ctx, 1, todo.ID); err == nil {
+		t.Error("restoring a purged todo succeeded")
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	expired := tt.create(t, 1, "expired")
+	recent := tt.create(t, 1, "recent")
+	tt.delete(t, expired)
+	tt.delete(t, recent)
+	tt.todos.todos[expired.ID].DeletedAt.Time = time.Now().Add(-31 * 24 * time.Hour)
+
+	purged, err := tt.service.PurgeExpiredTrash(ctx, 30*24*time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeExpiredTrash = %d, %v; want 1", purged, err)
+	}
+	trash, _ := tt.service.GetTrash(ctx, 1)
+	if !equalIDs(todoIDs(trash), recent.ID) {
+		t.Errorf("trash %v, want only %d", todoIDs(trash), recent.ID)
+	}
+}
+
+func TestGetTodosByUserIDIsNotSharedBetweenUsers(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	mine := tt.create(t, 1, "mine")
+	theirs := tt.create(t, 2, "theirs")
+
+	if _, err := tt.service.GetTodos(ctx); err != nil {
+		t.Fatalf("GetTodos: %v", err)
+	}
+	for userID, want := range map[uint]uint{1: mine.ID, 2: theirs.ID} {
+		todos, err := tt.service.GetTodosByUserID(ctx, userID)
+		if err != nil || !equalIDs(todoIDs(todos), want) {
+			t.Errorf("GetTodosByUserID(%d) = %v, %v; want only %d", userID, todoIDs(todos), err, want)
+		}
+	}
+}
+
+func TestArchivedTodoExcludedFromList(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	open := tt.create(t, 1, "open")
+	archived := tt.create(t, 1, "archived")
+
+	if err := tt.service.ArchiveTodo(ctx, 1, archived.ID, 0); err != nil {
+		t.Fatalf("ArchiveTodo: %v", err)
+	}
+	todos, _ := tt.service.GetTodosByUserID(ctx, 1)
+	if !equalIDs(todoIDs(todos), open.ID) {
+		t.Errorf("GetTodosByUserID = %v, want only %d", todoIDs(todos), open.ID)
+	}
+	archive, _ := tt.service.GetArchive(ctx, 1)
+	if !equalIDs(todoIDs(archive), archived.ID) {
+		t.Errorf("GetArchive = %v, want only %d", todoIDs(archive), archived.ID)
+	}
+
+	if err := tt.service.UnarchiveTodo(ctx, 1, archived.ID, 0); err != nil {
+		t.Fatalf("UnarchiveTodo: %v", err)
+	}
+	todos, _ = tt.service.GetTodosByUserID(ctx, 1)
+	if !equalIDs(todoIDs(todos), open.ID, archived.ID) {
+		t.Errorf("GetTodosByUserID after unarchive = %v", todoIDs(todos))
+	}
+}
+
+func TestCompletedAtSetAndCleared(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "finish me")
+
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, todo.Title, "", true); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	stored := tt.todos.todos[todo.ID]
+	if stored.CompletedAt == nil || time.Since(*stored.CompletedAt) > time.Minute {
+		t.Fatalf("completed_at = %v, want now", stored.CompletedAt)
+	}
+	completedAt := *stored.CompletedAt
+
+	// menyimpan ulang todo yang sudah selesai tidak mengubah completed_at
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "renamed", "", true); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if !stored.CompletedAt.Equal(completedAt) {
+		t.Errorf("completed_at changed to %v", stored.CompletedAt)
+	}
+
+	if err := tt.service.ArchiveTodo(ctx, 1, todo.ID, 0); err != nil {
+		t.Fatalf("ArchiveTodo: %v", err)
+	}
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "renamed", "", false); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if stored.CompletedAt != nil || stored.ArchivedAt != nil {
+		t.Errorf("reopened todo has completed_at %v, archived_at %v; want both cleared", stored.CompletedAt, stored.ArchivedAt)
+	}
+}
+
+func TestAutoArchiveCutoff(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	old := tt.create(t, 1, "done last week")
+	recent := tt.create(t, 1, "done yesterday")
+	open := tt.create(t, 1, "still open")
+	for todo, age := range map[*entity.Todo]time.Duration{old: 8 * 24 * time.Hour, recent: 24 * time.Hour} {
+		completedAt := time.Now().Add(-age)
+		stored := tt.todos.todos[todo.ID]
+		stored.Done = true
+		stored.CompletedAt = &completedAt
+	}
+
+	archived, err := tt.service.AutoArchive(ctx, 7*24*time.Hour)
+	if err != nil || archived != 1 {
+		t.Fatalf("AutoArchive = %d, %v; want 1", archived, err)
+	}
+	archive, _ := tt.service.GetArchive(ctx, 1)
+	if !equalIDs(todoIDs(archive), old.ID) {
+		t.Errorf("GetArchive = %v, want only %d", todoIDs(archive), old.ID)
+	}
+	todos, _ := tt.service.GetTodosByUserID(ctx, 1)
+	if !equalIDs(todoIDs(todos), recent.ID, open.ID) {
+		t.Errorf("GetTodosByUserID = %v, want %d and %d", todoIDs(todos), recent.ID, open.ID)
+	}
+}
+
+func TestUpdateRecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "draft")
+
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "final", "", true); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	// update tanpa perubahan tidak menambah history
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "final", "", true); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+
+	history, err := tt.service.GetTodoHistory(ctx, 1, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].Action != entity.TodoEventCreated || history[1].Action != entity.TodoEventUpdated {
+		t.Fatalf("history = %v, want created and updated", tt.events.actions(todo.ID))
+	}
+	changes := history[1].Changes
+	if changes["title"].Old != "draft" || changes["title"].New != "final" {
+		t.Errorf("title change = %+v", changes["title"])
+	}
+	if changes["done"].Old != false || changes["done"].New != true {
+		t.Errorf("done change = %+v", changes["done"])
+	}
+	if _, ok := changes["completed_at"]; !ok {
+		t.Error("completed_at change not recorded")
+	}
+	if _, err := tt.service.GetTodoHistory(ctx, 2, todo.ID); err == nil {
+		t.Error("GetTodoHistory by another user succeeded")
+	}
+}
+
+func TestRevertTodo(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "first")
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "first", "", true); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	completedAt := *tt.todos.todos[todo.ID].CompletedAt
+	target := uint(len(tt.events.events))
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "second", "", false); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	version := tt.todos.todos[todo.ID].Version
+
+	if _, err := tt.service.RevertTodo(ctx, 1, todo.ID, version-1, target); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("RevertTodo with stale version = %v, want ErrPreconditionFailed", err)
+	}
+	other := tt.create(t, 1, "other")
+	if _, err := tt.service.RevertTodo(ctx, 1, other.ID, 0, target); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("RevertTodo with event of another todo = %v, want ErrEventNotFound", err)
+	}
+	if _, err := tt.service.RevertTodo(ctx, 2, todo.ID, 0, target); err == nil {
+		t.Error("RevertTodo by another user succeeded")
+	}
+
+	reverted, err := tt.service.RevertTodo(ctx, 1, todo.ID, version, target)
+	if err != nil {
+		t.Fatalf("RevertTodo: %v", err)
+	}
+	if reverted.Title != "first" || !reverted.Done || reverted.Version != version+1 {
+		t.Errorf("reverted todo = %q done=%v version=%d", reverted.Title, reverted.Done, reverted.Version)
+	}
+	// completed_at dikembalikan dari history, bukan waktu revert
+	if stored := tt.todos.todos[todo.ID]; stored.CompletedAt == nil || !stored.CompletedAt.Equal(completedAt) {
+		t.Errorf("completed_at = %v, want %v", stored.CompletedAt, completedAt)
+	}
+	actions := tt.events.actions(todo.ID)
+	if actions[len(actions)-1] != entity.TodoEventReverted {
+		t.Errorf("actions = %v, want reverted last", actions)
+	}
+}
+
+func (tt *todoTest) bulk(t *testing.T, action string, ids []uint, args BulkArgs) map[uint]BulkResult {
+	t.Helper()
+	results, err := tt.service.BulkUpdate(context.Background(), 1, action, ids, args)
+	if err != nil {
+		t.Fatalf("BulkUpdate(%s): %v", action, err)
+	}
+	byID := make(map[uint]BulkResult, len(results))
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+	return byID
+}
+
+func TestBulkComplete(t *testing.T) {
+	tt := newTodoTest()
+	a := tt.create(t, 1, "a")
+	b := tt.create(t, 1, "b")
+
+	results := tt.bulk(t, BulkComplete, []uint{a.ID, b.ID, a.ID}, BulkArgs{})
+	if len(results) != 2 {
+		t.Fatalf("results = %v, want one per unique id", results)
+	}
+	for _, todo := range []*entity.Todo{a, b} {
+		stored := tt.todos.todos[todo.ID]
+		if results[todo.ID].Status != "ok" || !stored.Done || stored.CompletedAt == nil {
+			t.Errorf("todo %d: result %+v, done=%v completed_at=%v", todo.ID, results[todo.ID], stored.Done, stored.CompletedAt)
+		}
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	tt := newTodoTest()
+	a := tt.create(t, 1, "a")
+	b := tt.create(t, 1, "b")
+
+	tt.bulk(t, BulkDelete, []uint{a.ID}, BulkArgs{})
+	trash, _ := tt.service.GetTrash(context.Background(), 1)
+	if !equalIDs(todoIDs(trash), a.ID) {
+		t.Errorf("GetTrash = %v, want only %d", todoIDs(trash), a.ID)
+	}
+	todos, _ := tt.service.GetTodosByUserID(context.Background(), 1)
+	if !equalIDs(todoIDs(todos), b.ID) {
+		t.Errorf("GetTodosByUserID = %v, want only %d", todoIDs(todos), b.ID)
+	}
+}
+
+func TestBulkMoveToProject(t *testing.T) {
+	tt := newTodoTest()
+	a := tt.create(t, 1, "a")
+
+	tt.bulk(t, BulkMoveToProject, []uint{a.ID}, BulkArgs{Project: "work"})
+	if project := tt.todos.todos[a.ID].Project; project != "work" {
+		t.Errorf("project = %q, want work", project)
+	}
+	if _, err := tt.service.BulkUpdate(context.Background(), 1, BulkMoveToProject, []uint{a.ID}, BulkArgs{Project: "two words"}); !errors.Is(err, ErrInvalidProject) {
+		t.Errorf("invalid project = %v, want ErrInvalidProject", err)
+	}
+}
+
+func TestBulkAddTag(t *testing.T) {
+	tt := newTodoTest()
+	a := tt.create(t, 1, "a")
+
+	tt.bulk(t, BulkAddTag, []uint{a.ID}, BulkArgs{Tag: "urgent"})
+	version := tt.todos.todos[a.ID].Version
+	// tag yang sudah ada tidak ditambah dua kali
+	tt.bulk(t, BulkAddTag, []uint{a.ID}, BulkArgs{Tag: "urgent"})
+	stored := tt.todos.todos[a.ID]
+	if len(stored.Tags) != 1 || stored.Tags[0] != "urgent" || stored.Version != version {
+		t.Errorf("tags = %v version = %d, want [urgent] at version %d", stored.Tags, stored.Version, version)
+	}
+}
+
+func TestBulkSkipsTodosOfOtherUsers(t *testing.T) {
+	tt := newTodoTest()
+	mine := tt.create(t, 1, "mine")
+	theirs := tt.create(t, 2, "theirs")
+
+	results := tt.bulk(t, BulkComplete, []uint{mine.ID, theirs.ID, 404}, BulkArgs{})
+	if results[mine.ID].Status != "ok" {
+		t.Errorf("own todo result = %+v", results[mine.ID])
+	}
+	for _, id := range []uint{theirs.ID, 404} {
+		if results[id].Status != "failed" {
+			t.Errorf("todo %d result = %+v, want failed", id, results[id])
+		}
+	}
+	if tt.todos.todos[theirs.ID].Done {
+		t.Error("todo of another user was completed")
+	}
+}
+
+func TestBulkReportsFailedItemAndContinues(t *testing.T) {
+	tt := newTodoTest()
+	a := tt.create(t, 1, "a")
+	b := tt.create(t, 1, "b")
+	tt.todos.stale = map[uint]bool{a.ID: true}
+
+	results := tt.bulk(t, BulkComplete, []uint{a.ID, b.ID}, BulkArgs{})
+	if results[a.ID].Status != "failed" || results[a.ID].Error != repository.ErrVersionConflict.Error() {
+		t.Errorf("conflicting todo result = %+v", results[a.ID])
+	}
+	if results[b.ID].Status != "ok" || !tt.todos.todos[b.ID].Done {
+		t.Errorf("other todo result = %+v, done=%v", results[b.ID], tt.todos.todos[b.ID].Done)
+	}
+}
+
+func TestArchiveRequiresCurrentVersion(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "archive me")
+	if err := tt.service.UpdateTodo(ctx, 1, todo.ID, 0, "renamed", "", false); err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+
+	if err := tt.service.ArchiveTodo(ctx, 1, todo.ID, todo.Version); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("ArchiveTodo with stale version = %v, want ErrPreconditionFailed", err)
+	}
+	if tt.todos.todos[todo.ID].ArchivedAt != nil {
+		t.Fatal("todo archived despite stale version")
+	}
+	version := tt.todos.todos[todo.ID].Version
+	if err := tt.service.ArchiveTodo(ctx, 1, todo.ID, version); err != nil {
+		t.Fatalf("ArchiveTodo: %v", err)
+	}
+	if err := tt.service.UnarchiveTodo(ctx, 1, todo.ID, version); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("UnarchiveTodo with stale version = %v, want ErrPreconditionFailed", err)
+	}
+}
+
+func (tt *todoTest) lastAction(todoID uint) string {
+	actions := tt.events.actions(todoID)
+	if len(actions) == 0 {
+		return ""
+	}
+	return actions[len(actions)-1]
+}
+
+func TestArchiveUnarchiveAndPurgeRecordEvents(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	todo := tt.create(t, 1, "lifecycle")
+
+	steps := []struct {
+		want string
+		run  func() error
+	}{
+		{entity.TodoEventArchived, func() error { return tt.service.ArchiveTodo(ctx, 1, todo.ID, 0) }},
+		{entity.TodoEventUnarchived, func() error { return tt.service.UnarchiveTodo(ctx, 1, todo.ID, 0) }},
+		{entity.TodoEventDeleted, func() error { return tt.service.DeleteTodo(ctx, 1, todo.ID, 0) }},
+		{entity.TodoEventPurged, func() error { return tt.service.PurgeTodo(ctx, 1, todo.ID) }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.want, err)
+		}
+		if got := tt.lastAction(todo.ID); got != step.want {
+			t.Fatalf("last event = %q, want %q", got, step.want)
+		}
+	}
+	archived := tt.events.events[1].Changes["archived_at"]
+	if archived.Old != nil || archived.New == nil {
+		t.Errorf("archived event changes = %+v", tt.events.events[1].Changes)
+	}
+}
+
+func TestBulkArchiveAndPurgeRecordEventPerTodo(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	var todos []*entity.Todo
+	for _, title := range []string{"a", "b"} {
+		todo := tt.create(t, 1, title)
+		completedAt := time.Now().Add(-48 * time.Hour)
+		tt.todos.todos[todo.ID].Done = true
+		tt.todos.todos[todo.ID].CompletedAt = &completedAt
+		todos = append(todos, todo)
+	}
+
+	if archived, err := tt.service.AutoArchive(ctx, 24*time.Hour); err != nil || archived != 2 {
+		t.Fatalf("AutoArchive = %d, %v; want 2", archived, err)
+	}
+	for _, todo := range todos {
+		if got := tt.lastAction(todo.ID); got != entity.TodoEventArchived {
+			t.Errorf("todo %d last event = %q, want archived", todo.ID, got)
+		}
+		tt.delete(t, todo)
+		tt.todos.todos[todo.ID].DeletedAt.Time = time.Now().Add(-48 * time.Hour)
+	}
+
+	if purged, err := tt.service.PurgeExpiredTrash(ctx, 24*time.Hour); err != nil || purged != 2 {
+		t.Fatalf("PurgeExpiredTrash = %d, %v; want 2", purged, err)
+	}
+	for _, todo := range todos {
+		if got := tt.lastAction(todo.ID); got != entity.TodoEventPurged {
+			t.Errorf("todo %d last event = %q, want purged", todo.ID, got)
+		}
+	}
+}
+
+func (tt *todoTest) orderedIDs(userID uint) []uint {
+	todos, _ := tt.todos.LockByUserID(context.Background(), userID)
+	return todoIDs(todos)
+}
+
+func TestMoveTodo(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	a := tt.create(t, 1, "a")
+	b := tt.create(t, 1, "b")
+	c := tt.create(t, 1, "c")
+	other := tt.create(t, 2, "other")
+
+	moved, err := tt.service.MoveTodo(ctx, 1, c.ID, &b.ID, &a.ID)
+	if err != nil {
+		t.Fatalf("MoveTodo: %v", err)
+	}
+	if got := tt.orderedIDs(1); !equalIDs(got, a.ID, c.ID, b.ID) {
+		t.Errorf("order = %v, want %d %d %d", got, a.ID, c.ID, b.ID)
+	}
+	if moved.Version != c.Version+1 || tt.todos.todos[c.ID].Version != moved.Version {
+		t.Errorf("version = %d (stored %d), want %d", moved.Version, tt.todos.todos[c.ID].Version, c.Version+1)
+	}
+	if got := tt.lastAction(c.ID); got != entity.TodoEventMoved {
+		t.Errorf("last event = %q, want moved", got)
+	}
+
+	if _, err := tt.service.MoveTodo(ctx, 1, a.ID, &other.ID, nil); err == nil {
+		t.Error("moving next to another user's todo succeeded")
+	}
+	if _, err := tt.service.MoveTodo(ctx, 2, a.ID, nil, &other.ID); err == nil {
+		t.Error("another user moved the todo")
+	}
+}
+
+func TestRebalancePositionsKeepsOrder(t *testing.T) {
+	ctx := context.Background()
+	tt := newTodoTest()
+	var ids []uint
+	for i, title := range []string{"a", "b", "c"} {
+		todo := tt.create(t, 1, title)
+		tt.todos.todos[todo.ID].Position = strings.Repeat("m", 10) + string(rune('a'+i))
+		ids = append(ids, todo.ID)
+	}
+	short := tt.create(t, 2, "short")
+
+	users, err := tt.service.RebalancePositions(ctx, 8)
+	if err != nil || users != 1 {
+		t.Fatalf("RebalancePositions = %d, %v; want 1", users, err)
+	}
+	if got := tt.orderedIDs(1); !equalIDs(got, ids...) {
+		t.Errorf("order = %v, want %v", got, ids)
+	}
+	for _, id := range ids {
+		if position := tt.todos.todos[id].Position; len(position) > 8 {
+			t.Errorf("todo %d position %q was not shortened", id, position)
+		}
+		if got := tt.lastAction(id); got != entity.TodoEventMoved {
+			t.Errorf("todo %d last event = %q, want moved", id, got)
+		}
+	}
+	if got := tt.lastAction(short.ID); got != entity.TodoEventCreated {
+		t.Errorf("todo of another user has event %q", got)
+	}
+}
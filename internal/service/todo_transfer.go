@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/todofmt"
+	"todo-list/pkg/rank"
+)
+
+const (
+	ImportRowCreated     = "created"
+	ImportRowWouldCreate = "would_create"
+	ImportRowDuplicate   = "duplicate"
+	ImportRowFailed      = "failed"
+)
+
+type ImportRow struct {
+	Line   int    `json:"line"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Commit     bool        `json:"commit"`
+	Total      int         `json:"total"`
+	Created    int         `json:"created"`
+	Duplicates int         `json:"duplicates"`
+	Failed     int         `json:"failed"`
+	Rows       []ImportRow `json:"rows"`
+}
+
+func (s *todoService) ExportTodos(ctx context.Context, userID uint, fn func(todo *entity.Todo) error) error {
+	return s.repo.StreamByUserID(ctx, userID, fn)
+}
+
+// ImportTodos memvalidasi dan (jika commit) menyimpan hasil import dalam
+// satu transaksi. Todo dengan title dan description yang sama dengan todo
+// yang sudah ada, atau dengan baris sebelumnya, dianggap duplikat.
+func (s *todoService) ImportTodos(ctx context.Context, userID uint, records []todofmt.Record, commit bool) (*ImportReport, error) {
+	seen := map[string]bool{}
+	err := s.repo.StreamByUserID(ctx, userID, func(todo *entity.Todo) error {
+		seen[dedupKey(todo)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{Commit: commit, Total: len(records), Rows: make([]ImportRow, 0, len(records))}
+	var todos []*entity.Todo
+	var rows []int
+	for _, record := range records {
+		row := ImportRow{Line: record.Line, Title: record.Todo.Title}
+		err := record.Err
+		if err == nil {
+			err = validateTodo(record.Todo.Title, record.Todo.Description)
+		}
+		if err == nil && !entity.ValidPriority(record.Todo.Priority) {
+			err = ErrInvalidPriority
+		}
+		if err == nil && !entity.ValidProject(record.Todo.Project) {
+			err = ErrInvalidProject
+		}
+		if err == nil {
+			record.Todo.Tags, err = normalizeTags(record.Todo.Tags)
+		}
+		switch {
+		case err != nil:
+			row.Status = ImportRowFailed
+			row.Error = err.Error()
+			report.Failed++
+		case seen[dedupKey(&record.Todo)]:
+			row.Status = ImportRowDuplicate
+			report.Duplicates++
+		default:
+			seen[dedupKey(&record.Todo)] = true
+			row.Status = ImportRowWouldCreate
+			todos = append(todos, importedTodo(userID, record.Todo))
+			rows = append(rows, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	if !commit || len(todos) == 0 {
+		return report, nil
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		last, err := s.repo.GetLastPosition(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for i, todo := range todos {
+			if todo.Position, err = rank.Between(last, ""); err != nil {
+				return err
+			}
+			last = todo.Position
+			if err := s.repo.Create(ctx, todo); err != nil {
+				return err
+			}
+			if err := s.recordEvent(ctx, todo, entity.TodoEventCreated, diffTodo(entity.Todo{}, *todo)); err != nil {
+				return err
+			}
+			report.Rows[rows[i]].Status = ImportRowCreated
+			report.Rows[rows[i]].ID = todo.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Created = len(todos)
+
+	return report, nil
+}
+
+// importedTodo hanya mengambil field yang boleh diisi dari file import;
+// id, user_id dan version selalu dibuat ulang.
+func importedTodo(userID uint, src entity.Todo) *entity.Todo {
+	todo := &entity.Todo{
+		UserID:      userID,
+		Title:       src.Title,
+		Description: src.Description,
+		Done:        src.Done,
+		Priority:    src.Priority,
+		DueAt:       src.DueAt,
+		DueAllDay:   src.DueAllDay,
+		Project:     src.Project,
+		Tags:        src.Tags,
+		CreatedAt:   src.CreatedAt,
+		CompletedAt: src.CompletedAt,
+		ArchivedAt:  src.ArchivedAt,
+	}
+	normalizeDue(todo)
+	if !todo.Done {
+		todo.CompletedAt = nil
+	} else if todo.CompletedAt == nil {
+		now := time.Now()
+		todo.CompletedAt = &now
+	}
+	return todo
+}
+
+func dedupKey(todo *entity.Todo) string {
+	return strings.ToLower(strings.TrimSpace(todo.Title)) + "\x00" + strings.TrimSpace(todo.Description)
+}
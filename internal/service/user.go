@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"net/mail"
+	"strconv"
 	"time"
 	"todo-list/internal/entity"
+	"todo-list/internal/event"
 	"todo-list/internal/repository"
+	"todo-list/pkg/actor"
 	"todo-list/pkg/cache"
+	"todo-list/pkg/securetoken"
 	"todo-list/pkg/token"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,21 +23,57 @@ import (
 type UserService interface {
 	FindAll(ctx context.Context) ([]entity.User, error)
 	Register(ctx context.Context, req *entity.UserReg) error
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password string) (*LoginResult, error)
+	VerifyMFA(ctx context.Context, challengeToken, code string) (*LoginResult, error)
+	EnrollMFA(ctx context.Context, challengeToken string) (*MFAEnrollment, error)
+	CompleteLogin(ctx context.Context, user *entity.User) (*LoginResult, error)
+	UpdateRole(ctx context.Context, userID int64, version uint, role string) error
+}
+
+var (
+	ErrInvalidRole            = errors.New("role must be either user or admin")
+	ErrUserPreconditionFailed = errors.New("the user has been modified, fetch the latest version and retry")
+	ErrInvalidEmail           = errors.New("email must be a valid email address")
+	ErrInvalidMFAChallenge    = errors.New("login challenge is invalid or has expired, log in again")
+)
+
+const (
+	mfaChallengeTTL = 5 * time.Minute
+	// maksimal kode salah per challenge sebelum harus login ulang
+	maxMFAAttempts = 5
+)
+
+// LoginResult berisi access token, atau challenge token jika user harus
+// memasukkan kode 2FA (atau mendaftarkan 2FA) terlebih dahulu.
+type LoginResult struct {
+	Token              string   `json:"token,omitempty"`
+	MFARequired        bool     `json:"mfa_required,omitempty"`
+	EnrollmentRequired bool     `json:"enrollment_required,omitempty"`
+	ChallengeToken     string   `json:"challenge_token,omitempty"`
+	ExpiresIn          int      `json:"expires_in,omitempty"`
+	RecoveryCodes      []string `json:"recovery_codes,omitempty"`
 }
 
 type userService struct {
 	userRepository repository.UserRepository
 	tokenUseCase   token.TokenUseCase
 	cacheable      cache.Cacheable
+	auditService   AuditService
+	publisher      event.Publisher
+	transactor     repository.Transactor
+	mfaService     MFAService
 }
 
 func NewUserService(
 	userRepository repository.UserRepository,
 	tokenUseCase token.TokenUseCase,
 	cacheable cache.Cacheable,
+	auditService AuditService,
+	publisher event.Publisher,
+	transactor repository.Transactor,
+	mfaService MFAService,
 ) UserService {
-	return &userService{userRepository, tokenUseCase, cacheable}
+	return &userService{userRepository, tokenUseCase, cacheable, auditService, publisher, transactor, mfaService}
 }
 
 func (s *userService) FindAll(ctx context.Context) (result []entity.User, err error) {
@@ -71,6 +112,18 @@ func (s *userService) Register(ctx context.Context, req *entity.UserReg) error {
 		return errors.New("username already exists")
 	}
 
+	req.Email = normalizeEmail(req.Email)
+	if req.Email != "" {
+		addr, err := mail.ParseAddress(req.Email)
+		if err != nil || addr.Name != "" {
+			return ErrInvalidEmail
+		}
+		// email yang belum diverifikasi user lain tidak menghalangi registrasi
+		if other, err := s.userRepository.FindByEmail(ctx, req.Email); err == nil && other.EmailVerifiedAt != nil {
+			return ErrEmailTaken
+		}
+	}
+
 	// Hash password sebelum disimpan
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -78,20 +131,148 @@ func (s *userService) Register(ctx context.Context, req *entity.UserReg) error {
 	}
 	req.Password = string(hashedPassword)
 	
-	return s.userRepository.CreateUser(ctx, req)
+	// user dan event user.registered disimpan dalam satu transaksi
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.CreateUser(ctx, req); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, s.userEvent(ctx, event.TypeUserRegistered, &entity.User{
+			ID:       req.ID,
+			Username: req.Username,
+			Role:     req.Role,
+			FullName: req.FullName,
+			Email:    req.Email,
+		}))
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, entity.AuditUserRegistered, req.ID, map[string]interface{}{
+		"username": req.Username,
+		"role":     req.Role,
+	})
+	return nil
 }
 
-func (s *userService) Login(ctx context.Context, username, password string) (string, error) {
+// Login memeriksa password. Jika user memakai 2FA, atau role-nya wajib
+// memakai 2FA, yang dikembalikan adalah challenge token untuk VerifyMFA.
+func (s *userService) Login(ctx context.Context, username, password string) (*LoginResult, error) {
 	user, err := s.userRepository.FindByUsername(ctx, username)
 	if err != nil {
 		log.Println(err.Error())
-		return "", errors.New("username or password invalid")
+		s.audit(ctx, entity.AuditLoginFailed, 0, map[string]interface{}{"username": username, "reason": "unknown username"})
+		return nil, errors.New("username or password invalid")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("username or password invalid")
+		s.audit(ctx, entity.AuditLoginFailed, user.ID, map[string]interface{}{"username": username, "reason": "wrong password"})
+		return nil, errors.New("username or password invalid")
+	}
+	return s.CompleteLogin(ctx, user)
+}
+
+// CompleteLogin menerbitkan access token, atau challenge 2FA, untuk user
+// yang sudah terautentikasi, baik lewat password maupun identity provider.
+func (s *userService) CompleteLogin(ctx context.Context, user *entity.User) (*LoginResult, error) {
+	enabled, err := s.mfaService.Enabled(ctx, uint(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.mfaService.Required(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled && !required {
+		return s.issueToken(ctx, user)
 	}
 
+	challenge, hash, err := securetoken.Generate("mfa_")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cacheable.Set(mfaChallengeKey(hash), user.ID, mfaChallengeTTL); err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		MFARequired:        true,
+		EnrollmentRequired: !enabled,
+		ChallengeToken:     challenge,
+		ExpiresIn:          int(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyMFA menyelesaikan login dengan kode TOTP atau recovery code. Untuk
+// admin yang wajib 2FA tetapi belum mendaftar, kode pertama sekaligus
+// mengaktifkan 2FA dan recovery code dikembalikan.
+func (s *userService) VerifyMFA(ctx context.Context, challengeToken, code string) (*LoginResult, error) {
+	user, key, err := s.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.cacheable.Incr(key+":attempts", mfaChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > maxMFAAttempts {
+		s.cacheable.Delete(key)
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	enabled, err := s.mfaService.Enabled(ctx, uint(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	var recoveryCodes []string
+	if enabled {
+		err = s.mfaService.Verify(ctx, uint(user.ID), code)
+	} else {
+		recoveryCodes, err = s.mfaService.Confirm(ctx, uint(user.ID), code)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.audit(ctx, entity.AuditLoginFailed, user.ID, map[string]interface{}{"username": user.Username, "reason": "wrong mfa code"})
+		}
+		return nil, err
+	}
+
+	// challenge hanya bisa dipakai sekali
+	if err := s.cacheable.Delete(key); err != nil {
+		return nil, err
+	}
+	result, err := s.issueToken(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	return result, nil
+}
+
+// EnrollMFA dipakai admin yang wajib 2FA tetapi belum mendaftar, sebelum
+// mereka punya access token.
+func (s *userService) EnrollMFA(ctx context.Context, challengeToken string) (*MFAEnrollment, error) {
+	user, _, err := s.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.mfaService.Enroll(ctx, uint(user.ID))
+}
+
+func (s *userService) challengeUser(ctx context.Context, challengeToken string) (*entity.User, string, error) {
+	key := mfaChallengeKey(securetoken.Hash(challengeToken))
+	userID, err := strconv.ParseInt(s.cacheable.Get(key), 10, 64)
+	if err != nil {
+		return nil, "", ErrInvalidMFAChallenge
+	}
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, "", ErrInvalidMFAChallenge
+	}
+	return user, key, nil
+}
+
+func (s *userService) issueToken(ctx context.Context, user *entity.User) (*LoginResult, error) {
+	s.audit(withActorUser(ctx, user), entity.AuditLoginSucceeded, user.ID, map[string]interface{}{"username": user.Username})
+
 	expiredTime := time.Now().Local().Add(time.Minute * 5)
 
 	claims := token.JwtCustomClaims{
@@ -107,7 +288,82 @@ func (s *userService) Login(ctx context.Context, username, password string) (str
 
 	token, err := s.tokenUseCase.GenerateAccessToken(claims)
 	if err != nil {
-		return "", errors.New("ada kesalahan di server")
+		return nil, errors.New("ada kesalahan di server")
+	}
+	return &LoginResult{Token: token}, nil
+}
+
+func mfaChallengeKey(hash string) string {
+	return "todo-list:mfa-challenge:" + hash
+}
+
+func (s *userService) UpdateRole(ctx context.Context, userID int64, version uint, role string) error {
+	if role != "user" && role != "admin" {
+		return ErrInvalidRole
+	}
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if version != 0 && version != user.Version {
+		return ErrUserPreconditionFailed
+	}
+	if user.Role == role {
+		return nil
+	}
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.UpdateRole(ctx, userID, user.Version, role); err != nil {
+			return err
+		}
+		changed := *user
+		changed.Role = role
+		changed.Version++
+		return s.publisher.Publish(ctx, s.userEvent(ctx, event.TypeUserRoleChanged, &changed))
+	})
+	if err != nil {
+		return err
+	}
+
+	keyFindAll := "todo-list:users:find-all"
+	if err := s.cacheable.Delete(keyFindAll); err != nil {
+		return errors.New("falied deleting key cache")
 	}
-	return token, nil
+	s.audit(ctx, entity.AuditRoleChanged, userID, map[string]interface{}{
+		"old_role": user.Role,
+		"new_role": role,
+	})
+	return nil
+}
+
+func (s *userService) userEvent(ctx context.Context, eventType string, user *entity.User) event.Event {
+	e := event.Event{
+		Type:       eventType,
+		UserID:     uint(user.ID),
+		User:       user,
+		OccurredAt: time.Now(),
+	}
+	if a, ok := actor.FromContext(ctx); ok {
+		e.ActorID = a.UserID
+	}
+	return e
+}
+
+// audit mencatat aksi ke audit log. Kegagalan hanya di-log agar tidak
+// menggagalkan login atau registrasi.
+func (s *userService) audit(ctx context.Context, action string, userID int64, metadata map[string]interface{}) {
+	targetID := ""
+	if userID != 0 {
+		targetID = strconv.FormatInt(userID, 10)
+	}
+	if err := s.auditService.Record(ctx, action, "user", targetID, metadata); err != nil {
+		log.Printf("failed to record audit log %s: %v", action, err)
+	}
+}
+
+// withActorUser menandai user yang baru berhasil login sebagai actor.
+func withActorUser(ctx context.Context, user *entity.User) context.Context {
+	a, _ := actor.FromContext(ctx)
+	a.UserID = uint(user.ID)
+	a.Role = user.Role
+	return actor.NewContext(ctx, a)
 }
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/securetoken"
+	"todo-list/pkg/webhook"
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL   = errors.New("url must be an absolute http or https URL")
+	ErrInvalidWebhookEvent = errors.New("events must contain at least one of todo.created, todo.updated, todo.completed, todo.deleted, todo.reminder or user.registered (admin only)")
+)
+
+// webhookEvents adalah tipe event yang bisa dilanggan webhook.
+var webhookEvents = map[string]bool{
+	event.TypeTodoCreated:    true,
+	event.TypeTodoUpdated:    true,
+	event.TypeTodoCompleted:  true,
+	event.TypeTodoDeleted:    true,
+	event.TypeTodoReminder:   true,
+	event.TypeUserRegistered: true,
+}
+
+const (
+	maxDeliveryLog  = 100
+	dispatchBatch   = 50
+	dispatchLease   = 5 * time.Minute
+	maxBackoffDelay = 24 * time.Hour
+)
+
+// WebhookService menyimpan delivery untuk setiap event yang di-publish lalu
+// mengirimnya secara asynchronous lewat DispatchDue.
+type WebhookService interface {
+	event.Publisher
+	CreateWebhook(ctx context.Context, ownerID uint, global bool, rawURL string, events []string) (*entity.Webhook, string, error)
+	GetWebhooks(ctx context.Context, ownerID uint) ([]entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, ownerID, id uint) error
+	GetDeliveries(ctx context.Context, ownerID, webhookID uint) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, ownerID, webhookID, deliveryID uint) (*entity.WebhookDelivery, error)
+	DispatchDue(ctx context.Context) (int, error)
+}
+
+type webhookService struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	sender       webhook.Sender
+	maxAttempts  int
+	backoffBase  time.Duration
+}
+
+func NewWebhookService(
+	webhookRepo repository.WebhookRepository,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	sender webhook.Sender,
+	maxAttempts int,
+	backoffBase time.Duration,
+) WebhookService {
+	return &webhookService{webhookRepo, deliveryRepo, sender, maxAttempts, backoffBase}
+}
+
+// CreateWebhook mengembalikan secret untuk verifikasi signature. Secret
+// hanya ditampilkan sekali.
+func (s *webhookService) CreateWebhook(ctx context.Context, ownerID uint, global bool, rawURL string, events []string) (*entity.Webhook, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(rawURL) > 2048 {
+		return nil, "", ErrInvalidWebhookURL
+	}
+	if len(events) == 0 {
+		return nil, "", ErrInvalidWebhookEvent
+	}
+	for _, e := range events {
+		if !webhookEvents[e] || (e == event.TypeUserRegistered && !global) {
+			return nil, "", ErrInvalidWebhookEvent
+		}
+	}
+
+	secret, _, err := securetoken.Generate("whsec_")
+	if err != nil {
+		return nil, "", err
+	}
+	hook := &entity.Webhook{
+		UserID: ownerID,
+		Global: global,
+		URL:    rawURL,
+		Secret: secret,
+		Events: events,
+	}
+	if err := s.webhookRepo.Create(ctx, hook); err != nil {
+		return nil, "", err
+	}
+	return hook, secret, nil
+}
+
+func (s *webhookService) GetWebhooks(ctx context.Context, ownerID uint) ([]entity.Webhook, error) {
+	return s.webhookRepo.GetByUserID(ctx, ownerID)
+}
+
+func (s *webhookService) getOwnedWebhook(ctx context.Context, ownerID, id uint) (*entity.Webhook, error) {
+	hook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil || hook.UserID != ownerID {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, ownerID, id uint) error {
+	if _, err := s.getOwnedWebhook(ctx, ownerID, id); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, ownerID, webhookID uint) ([]entity.WebhookDelivery, error) {
+	if _, err := s.getOwnedWebhook(ctx, ownerID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.GetByWebhookID(ctx, webhookID, maxDeliveryLog)
+}
+
+// Redeliver membuat delivery baru dengan event ID dan payload yang sama,
+// sehingga receiver bisa mengenali duplikat.
+func (s *webhookService) Redeliver(ctx context.Context, ownerID, webhookID, deliveryID uint) (*entity.WebhookDelivery, error) {
+	if _, err := s.getOwnedWebhook(ctx, ownerID, webhookID); err != nil {
+		return nil, err
+	}
+	original, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil || original.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	now := time.Now()
+	delivery := entity.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        entity.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	deliveries := []entity.WebhookDelivery{delivery}
+	if err := s.deliveryRepo.Create(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// webhookTypes memetakan event ke tipe webhook. Todo yang dipulihkan dari
+// trash dikirim sebagai todo.created dan revert sebagai todo.updated.
+func webhookTypes(e event.Event) []string {
+	switch e.Type {
+	case event.TypeTodoCreated, event.TypeTodoRestored:
+		return []string{event.TypeTodoCreated}
+	case event.TypeTodoUpdated, event.TypeTodoReverted:
+		if e.Completed() {
+			return []string{event.TypeTodoUpdated, event.TypeTodoCompleted}
+		}
+		return []string{event.TypeTodoUpdated}
+	case event.TypeTodoArchived, event.TypeTodoUnarchived, event.TypeTodoMoved:
+		return []string{event.TypeTodoUpdated}
+	case event.TypeTodoDeleted:
+		return []string{event.TypeTodoDeleted}
+	case event.TypeTodoReminder:
+		return []string{event.TypeTodoReminder}
+	case event.TypeUserRegistered:
+		return []string{event.TypeUserRegistered}
+	}
+	return nil
+}
+
+type webhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      event.Event `json:"data"`
+}
+
+// webhookEventID stabil untuk event yang sama sehingga receiver bisa
+// melakukan deduplikasi.
+func webhookEventID(eventType string, e event.Event) string {
+	if e.ID != 0 {
+		return fmt.Sprintf("%s.%d", eventType, e.ID)
+	}
+	if e.Notification != nil {
+		return fmt.Sprintf("%s.notification-%d", eventType, e.Notification.ID)
+	}
+	return fmt.Sprintf("%s.user-%d", eventType, e.UserID)
+}
+
+// Publish hanya menyimpan delivery, pengiriman dilakukan oleh DispatchDue.
+func (s *webhookService) Publish(ctx context.Context, e event.Event) error {
+	types := webhookTypes(e)
+	if len(types) == 0 {
+		return nil
+	}
+	hooks, err := s.webhookRepo.FindSubscribers(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []entity.WebhookDelivery
+	for _, eventType := range types {
+		var payload []byte
+		for _, hook := range hooks {
+			if !hook.Subscribed(eventType) {
+				continue
+			}
+			if payload == nil {
+				payload, err = json.Marshal(webhookPayload{
+					ID:        webhookEventID(eventType, e),
+					Type:      eventType,
+					CreatedAt: e.OccurredAt,
+					Data:      e,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			deliveries = append(deliveries, entity.WebhookDelivery{
+				WebhookID:     hook.ID,
+				EventID:       webhookEventID(eventType, e),
+				EventType:     eventType,
+				Payload:       entity.RawJSON(payload),
+				Status:        entity.WebhookDeliveryPending,
+				NextAttemptAt: &now,
+			})
+		}
+	}
+	return s.deliveryRepo.Create(ctx, deliveries)
+}
+
+// DispatchDue mengirim delivery yang sudah waktunya dan mengembalikan
+// jumlah yang berhasil terkirim.
+func (s *webhookService) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := s.deliveryRepo.ClaimDue(ctx, dispatchBatch, dispatchLease)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range deliveries {
+		if s.deliver(ctx, &deliveries[i]) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *webhookService) deliver(ctx context.Context, delivery *entity.WebhookDelivery) bool {
+	delivery.Attempts++
+	hook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		// webhook sudah dihapus
+		delivery.Status = entity.WebhookDeliveryFailed
+		delivery.LastError = "webhook no longer exists"
+		delivery.NextAttemptAt = nil
+		s.saveDelivery(ctx, delivery)
+		return false
+	}
+
+	status, err := s.sender.Send(ctx, webhook.Request{
+		URL:        hook.URL,
+		Secret:     hook.Secret,
+		DeliveryID: delivery.ID,
+		EventType:  delivery.EventType,
+		Body:       []byte(delivery.Payload),
+	})
+	delivery.ResponseStatus = status
+	if err == nil {
+		now := time.Now()
+		delivery.Status = entity.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		s.saveDelivery(ctx, delivery)
+		return true
+	}
+
+	delivery.LastError = truncate(err.Error(), 1000)
+	if delivery.Attempts >= s.maxAttempts {
+		delivery.Status = entity.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	} else {
+		next := time.Now().Add(s.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+	s.saveDelivery(ctx, delivery)
+	return false
+}
+
+// backoff menghitung jeda sebelum percobaan berikutnya secara eksponensial.
+func (s *webhookService) backoff(attempts int) time.Duration {
+	delay := s.backoffBase
+	for i := 1; i < attempts && delay < maxBackoffDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoffDelay {
+		delay = maxBackoffDelay
+	}
+	return delay
+}
+
+func (s *webhookService) saveDelivery(ctx context.Context, delivery *entity.WebhookDelivery) {
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		log.Printf("failed to save webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
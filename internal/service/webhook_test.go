@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+	"todo-list/internal/event"
+	"todo-list/internal/repository"
+	"todo-list/pkg/webhook"
+)
+
+type fakeWebhookRepository struct {
+	repository.WebhookRepository
+	hooks map[uint]*entity.Webhook
+}
+
+func (r *fakeWebhookRepository) GetByID(ctx context.Context, id uint) (*entity.Webhook, error) {
+	hook, ok := r.hooks[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	found := *hook
+	return &found, nil
+}
+
+func (r *fakeWebhookRepository) FindSubscribers(ctx context.Context, userID uint) ([]entity.Webhook, error) {
+	var hooks []entity.Webhook
+	for _, hook := range r.hooks {
+		if hook.Global || hook.UserID == userID {
+			hooks = append(hooks, *hook)
+		}
+	}
+	return hooks, nil
+}
+
+type fakeWebhookDeliveryRepository struct {
+	repository.WebhookDeliveryRepository
+	deliveries []entity.WebhookDelivery
+}
+
+func (r *fakeWebhookDeliveryRepository) Create(ctx context.Context, deliveries []entity.WebhookDelivery) error {
+	for i := range deliveries {
+		deliveries[i].ID = uint(len(r.deliveries) + 1)
+		deliveries[i].CreatedAt = time.Now()
+		r.deliveries = append(r.deliveries, deliveries[i])
+	}
+	return nil
+}
+
+func (r *fakeWebhookDeliveryRepository) GetByID(ctx context.Context, id uint) (*entity.WebhookDelivery, error) {
+	if id == 0 || int(id) > len(r.deliveries) {
+		return nil, errors.New("record not found")
+	}
+	found := r.deliveries[id-1]
+	return &found, nil
+}
+
+func (r *fakeWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	now := time.Now()
+	var due []entity.WebhookDelivery
+	for i := range r.deliveries {
+		d := &r.deliveries[i]
+		if d.Status == entity.WebhookDeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) && len(due) < limit {
+			leased := now.Add(lease)
+			d.NextAttemptAt = &leased
+			due = append(due, *d)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	r.deliveries[delivery.ID-1] = *delivery
+	return nil
+}
+
+// elapse memajukan waktu dengan memundurkan jadwal percobaan berikutnya.
+func (r *fakeWebhookDeliveryRepository) elapse(d time.Duration) {
+	for i := range r.deliveries {
+		if next := r.deliveries[i].NextAttemptAt; next != nil {
+			earlier := next.Add(-d)
+			r.deliveries[i].NextAttemptAt = &earlier
+		}
+	}
+}
+
+// webhookReceiver memverifikasi signature setiap request dan membalas
+// dengan status dari responses secara berurutan (200 jika habis).
+type webhookReceiver struct {
+	*httptest.Server
+	mu        sync.Mutex
+	responses []int
+	received  []receivedWebhook
+}
+
+type receivedWebhook struct {
+	deliveryID string
+	eventType  string
+	payload    webhookPayload
+}
+
+func newWebhookReceiver(t *testing.T, secret string, responses ...int) *webhookReceiver {
+	receiver := &webhookReceiver{responses: responses}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if err != nil || !webhook.Verify(secret, timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+			t.Errorf("invalid signature %q", r.Header.Get(webhook.HeaderSignature))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload webhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload %s: %v", body, err)
+		}
+
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		receiver.received = append(receiver.received, receivedWebhook{
+			deliveryID: r.Header.Get(webhook.HeaderDelivery),
+			eventType:  r.Header.Get(webhook.HeaderEvent),
+			payload:    payload,
+		})
+		status := http.StatusOK
+		if len(receiver.responses) > 0 {
+			status, receiver.responses = receiver.responses[0], receiver.responses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) requests() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.received...)
+}
+
+func newTestWebhookService(url string, maxAttempts int) (*webhookService, *fakeWebhookDeliveryRepository) {
+	deliveries := &fakeWebhookDeliveryRepository{}
+	hooks := &fakeWebhookRepository{hooks: map[uint]*entity.Webhook{
+		1: {ID: 1, UserID: 7, URL: url, Secret: "whsec_test", Events: entity.StringList{event.TypeTodoCreated}},
+	}}
+	s := NewWebhookService(hooks, deliveries, webhook.NewSender(time.Second, true), maxAttempts, time.Minute)
+	return s.(*webhookService), deliveries
+}
+
+func publishTodoCreated(t *testing.T, s *webhookService) {
+	t.Helper()
+	err := s.Publish(context.Background(), event.Event{ID: 10, Type: event.TypeTodoCreated, UserID: 7, OccurredAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookDispatchSignsPayload(t *testing.T) {
+	receiver := newWebhookReceiver(t, "whsec_test")
+	s, deliveries := newTestWebhookService(receiver.URL, 3)
+	publishTodoCreated(t, s)
+
+	sent, err := s.DispatchDue(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("DispatchDue = %d, %v", sent, err)
+	}
+	requests := receiver.requests()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests", len(requests))
+	}
+	if requests[0].eventType != event.TypeTodoCreated || requests[0].deliveryID != "1" || requests[0].payload.ID != "todo.created.10" {
+		t.Errorf("unexpected request %+v", requests[0])
+	}
+	delivery := deliveries.deliveries[0]
+	if delivery.Status != entity.WebhookDeliverySucceeded || delivery.Attempts != 1 || delivery.DeliveredAt == nil || delivery.NextAttemptAt != nil {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+}
+
+func TestWebhookRetryBackoff(t *testing.T) {
+	receiver := newWebhookReceiver(t, "whsec_test", http.StatusInternalServerError, http.StatusBadGateway)
+	s, deliveries := newTestWebhookService(receiver.URL, 5)
+	publishTodoCreated(t, s)
+
+	for attempt, wantDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		start := time.Now()
+		if sent, err := s.DispatchDue(context.Background()); err != nil || sent != 0 {
+			t.Fatalf("attempt %d: DispatchDue = %d, %v", attempt+1, sent, err)
+		}
+		delivery := deliveries.deliveries[0]
+		if delivery.Status != entity.WebhookDeliveryPending || delivery.Attempts != attempt+1 || delivery.LastError == "" {
+			t.Fatalf("attempt %d: unexpected delivery %+v", attempt+1, delivery)
+		}
+		delay := delivery.NextAttemptAt.Sub(start)
+		if delay < wantDelay || delay > wantDelay+time.Second {
+			t.Errorf("attempt %d: next attempt in %v, want %v", attempt+1, delay, wantDelay)
+		}
+
+		// belum waktunya dicoba lagi
+		if sent, _ := s.DispatchDue(context.Background()); sent != 0 || len(receiver.requests()) != attempt+1 {
+			t.Fatalf("attempt %d: delivery was retried before its backoff", attempt+1)
+		}
+		deliveries.elapse(wantDelay)
+	}
+
+	if sent, err := s.DispatchDue(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("DispatchDue = %d, %v", sent, err)
+	}
+	delivery := deliveries.deliveries[0]
+	if delivery.Status != entity.WebhookDeliverySucceeded || delivery.Attempts != 3 || delivery.ResponseStatus != http.StatusOK {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+	for _, r := range receiver.requests() {
+		if r.deliveryID != "1" || r.payload.ID != "todo.created.10" {
+			t.Errorf("retry changed the delivery: %+v", r)
+		}
+	}
+}
+
+func TestWebhookGivesUpAfterMaxAttempts(t *testing.T) {
+	receiver := newWebhookReceiver(t, "whsec_test", 500, 500, 500)
+	s, deliveries := newTestWebhookService(receiver.URL, 2)
+	publishTodoCreated(t, s)
+
+	for i := 0; i < 3; i++ {
+		s.DispatchDue(context.Background())
+		deliveries.elapse(time.Hour)
+	}
+	delivery := deliveries.deliveries[0]
+	if delivery.Status != entity.WebhookDeliveryFailed || delivery.Attempts != 2 || delivery.NextAttemptAt != nil {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+	if n := len(receiver.requests()); n != 2 {
+		t.Errorf("receiver got %d requests, want 2", n)
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	receiver := newWebhookReceiver(t, "whsec_test", 500)
+	s, deliveries := newTestWebhookService(receiver.URL, 1)
+	publishTodoCreated(t, s)
+	s.DispatchDue(context.Background())
+	if deliveries.deliveries[0].Status != entity.WebhookDeliveryFailed {
+		t.Fatalf("unexpected delivery %+v", deliveries.deliveries[0])
+	}
+
+	if _, err := s.Redeliver(context.Background(), 8, 1, 1); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("Redeliver by another user: %v", err)
+	}
+	if _, err := s.Redeliver(context.Background(), 7, 1, 99); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("Redeliver of a missing delivery: %v", err)
+	}
+	redelivery, err := s.Redeliver(context.Background(), 7, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.ID == 1 || redelivery.EventID != "todo.created.10" || redelivery.Status != entity.WebhookDeliveryPending {
+		t.Fatalf("unexpected redelivery %+v", redelivery)
+	}
+
+	if sent, err := s.DispatchDue(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("DispatchDue = %d, %v", sent, err)
+	}
+	requests := receiver.requests()
+	if len(requests) != 2 {
+		t.Fatalf("receiver got %d requests", len(requests))
+	}
+	// event ID sama agar receiver bisa deduplikasi, delivery ID berbeda
+	if requests[1].payload.ID != requests[0].payload.ID || requests[1].deliveryID != strconv.FormatUint(uint64(redelivery.ID), 10) {
+		t.Errorf("unexpected redelivered request %+v", requests[1])
+	}
+	if deliveries.deliveries[0].Status != entity.WebhookDeliveryFailed {
+		t.Errorf("original delivery changed: %+v", deliveries.deliveries[0])
+	}
+}
@@ -0,0 +1,159 @@
+package todofmt
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+)
+
+var csvHeader = []string{
+	"id", "user_id", "title", "description", "done", "priority", "due_at",
+	"due_all_day", "project", "tags", "position", "version", "created_at", "completed_at", "archived_at",
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	e := &csvEncoder{csv.NewWriter(w)}
+	if err := e.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *csvEncoder) Encode(todo *entity.Todo) error {
+	err := e.w.Write([]string{
+		strconv.FormatUint(uint64(todo.ID), 10),
+		strconv.FormatUint(uint64(todo.UserID), 10),
+		todo.Title,
+		todo.Description,
+		strconv.FormatBool(todo.Done),
+		todo.Priority,
+		formatTime(todo.DueAt),
+		strconv.FormatBool(todo.DueAllDay),
+		todo.Project,
+		strings.Join(todo.Tags, ","),
+		todo.Position,
+		strconv.FormatUint(uint64(todo.Version), 10),
+		formatTime(&todo.CreatedAt),
+		formatTime(todo.CompletedAt),
+		formatTime(todo.ArchivedAt),
+	})
+	if err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// decodeCSV membaca CSV dengan header. Hanya kolom title yang wajib ada,
+// kolom lain yang tidak dikenal diabaikan.
+func decodeCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("csv import requires a title column")
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			records = append(records, Record{Line: line, Err: err})
+			continue
+		}
+		record := Record{Line: line}
+		record.Todo, record.Err = csvTodo(row, columns)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func csvTodo(row []string, columns map[string]int) (entity.Todo, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	todo := entity.Todo{
+		Title:       field("title"),
+		Description: field("description"),
+		Priority:    field("priority"),
+		Project:     field("project"),
+	}
+	if v := field("tags"); v != "" {
+		todo.Tags = strings.Split(v, ",")
+	}
+	var err error
+	if v := field("done"); v != "" {
+		if todo.Done, err = strconv.ParseBool(v); err != nil {
+			return todo, fmt.Errorf("invalid done value %q", v)
+		}
+	}
+	if todo.DueAt, err = parseTime(field("due_at")); err != nil {
+		return todo, fmt.Errorf("invalid due_at: %w", err)
+	}
+	if v := field("due_all_day"); v != "" {
+		if todo.DueAllDay, err = strconv.ParseBool(v); err != nil {
+			return todo, fmt.Errorf("invalid due_all_day value %q", v)
+		}
+	}
+	createdAt, err := parseTime(field("created_at"))
+	if err != nil {
+		return todo, fmt.Errorf("invalid created_at: %w", err)
+	}
+	if createdAt != nil {
+		todo.CreatedAt = *createdAt
+	}
+	if todo.CompletedAt, err = parseTime(field("completed_at")); err != nil {
+		return todo, fmt.Errorf("invalid completed_at: %w", err)
+	}
+	if todo.ArchivedAt, err = parseTime(field("archived_at")); err != nil {
+		return todo, fmt.Errorf("invalid archived_at: %w", err)
+	}
+	return todo, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
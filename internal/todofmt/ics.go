@@ -0,0 +1,275 @@
+package todofmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+)
+
+// iCalendar (RFC 5545). Setiap todo menjadi satu komponen VTODO. Todo belum
+// punya aturan pengulangan, sehingga RRULE belum ditulis.
+const (
+	icsDateTime = "20060102T150405Z"
+	icsDate     = "20060102"
+	icsLineMax  = 75
+	icsProdID   = "-//todo-list//todo-list//EN"
+)
+
+type icsEncoder struct {
+	w       *bufio.Writer
+	stamp   time.Time
+	started bool
+}
+
+func newICSEncoder(w io.Writer) *icsEncoder {
+	return &icsEncoder{w: bufio.NewWriter(w), stamp: time.Now().UTC()}
+}
+
+func (e *icsEncoder) begin() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + icsProdID)
+	e.line("CALSCALE:GREGORIAN")
+}
+
+func (e *icsEncoder) Encode(todo *entity.Todo) error {
+	e.begin()
+	e.line("BEGIN:VTODO")
+	e.line("UID:" + icsEscape(TodoUID(todo)))
+	e.line("DTSTAMP:" + e.stamp.Format(icsDateTime))
+	if !todo.CreatedAt.IsZero() {
+		e.line("CREATED:" + todo.CreatedAt.UTC().Format(icsDateTime))
+	}
+	e.line("SUMMARY:" + icsEscape(todo.Title))
+	if todo.Description != "" {
+		e.line("DESCRIPTION:" + icsEscape(todo.Description))
+	}
+	if todo.DueAt != nil {
+		e.line("DUE" + icsFormatTime(*todo.DueAt, todo.DueAllDay))
+	}
+	if priority := icsPriority(todo.Priority); priority != 0 {
+		e.line("PRIORITY:" + strconv.Itoa(priority))
+	}
+	if todo.Done {
+		e.line("STATUS:COMPLETED")
+		if todo.CompletedAt != nil {
+			e.line("COMPLETED:" + todo.CompletedAt.UTC().Format(icsDateTime))
+		}
+	} else {
+		e.line("STATUS:NEEDS-ACTION")
+	}
+	if todo.Version > 0 {
+		e.line("SEQUENCE:" + strconv.FormatUint(uint64(todo.Version-1), 10))
+	}
+	e.line("END:VTODO")
+	return e.w.Flush()
+}
+
+func (e *icsEncoder) Close() error {
+	e.begin()
+	e.line("END:VCALENDAR")
+	return e.w.Flush()
+}
+
+// line menulis satu content line dan melipatnya per 75 octet tanpa
+// memotong karakter UTF-8.
+func (e *icsEncoder) line(s string) {
+	for len(s) > icsLineMax {
+		cut := icsLineMax
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		e.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+	}
+	e.w.WriteString(s + "\r\n")
+}
+
+// TodoUID adalah UID VTODO untuk sebuah todo.
+func TodoUID(todo *entity.Todo) string {
+	if todo.ICalUID != "" {
+		return todo.ICalUID
+	}
+	return fmt.Sprintf("todo-%d@todo-list", todo.ID)
+}
+
+// icsFormatTime menulis DUE all-day sebagai DATE, selain itu sebagai
+// DATE-TIME UTC walaupun jatuh tepat tengah malam.
+func icsFormatTime(t time.Time, allDay bool) string {
+	t = t.UTC()
+	if allDay {
+		return ";VALUE=DATE:" + t.Format(icsDate)
+	}
+	return ":" + t.Format(icsDateTime)
+}
+
+// icsPriority memetakan priority A-I ke 1-9. Priority setelah I dianggap 9
+// dan tanpa priority menjadi 0 (undefined).
+func icsPriority(priority string) int {
+	if priority == "" {
+		return 0
+	}
+	if p := int(priority[0]-'A') + 1; p < 9 {
+		return p
+	}
+	return 9
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+var icsUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// decodeICS membaca setiap VTODO di dalam VCALENDAR. Komponen lain
+// diabaikan.
+func decodeICS(r io.Reader) ([]Record, error) {
+	lines, err := icsUnfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		records []Record
+		current *Record
+	)
+	for i, line := range lines {
+		name, params, value := icsSplit(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			current = &Record{Line: i + 1}
+		case name == "END" && strings.EqualFold(value, "VTODO"):
+			if current != nil {
+				records = append(records, *current)
+				current = nil
+			}
+		case current != nil && current.Err == nil:
+			current.Err = icsApply(&current.Todo, name, params, value)
+		}
+	}
+	return records, nil
+}
+
+// ParseVTODO membaca satu VCALENDAR yang berisi tepat satu VTODO dan
+// mengembalikan todo beserta UID-nya.
+func ParseVTODO(r io.Reader) (entity.Todo, string, error) {
+	lines, err := icsUnfold(r)
+	if err != nil {
+		return entity.Todo{}, "", err
+	}
+	var (
+		todo  entity.Todo
+		uid   string
+		count int
+		in    bool
+	)
+	for _, line := range lines {
+		name, params, value := icsSplit(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			in = true
+			count++
+		case name == "END" && strings.EqualFold(value, "VTODO"):
+			in = false
+		case in && count == 1 && name == "UID":
+			uid = icsUnescaper.Replace(value)
+		case in && count == 1:
+			if err := icsApply(&todo, name, params, value); err != nil {
+				return todo, uid, err
+			}
+		}
+	}
+	if count != 1 {
+		return todo, uid, fmt.Errorf("calendar object must contain exactly one VTODO")
+	}
+	return todo, uid, nil
+}
+
+func icsApply(todo *entity.Todo, name, params, value string) error {
+	switch name {
+	case "SUMMARY":
+		todo.Title = icsUnescaper.Replace(value)
+	case "DESCRIPTION":
+		todo.Description = icsUnescaper.Replace(value)
+	case "STATUS":
+		todo.Done = strings.EqualFold(value, "COMPLETED")
+	case "PRIORITY":
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 0 || p > 9 {
+			return fmt.Errorf("invalid PRIORITY %q", value)
+		}
+		todo.Priority = ""
+		if p > 0 {
+			todo.Priority = string(rune('A' + p - 1))
+		}
+	case "DUE", "COMPLETED", "CREATED":
+		t, allDay, err := icsParseTime(params, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		switch name {
+		case "DUE":
+			todo.DueAt = &t
+			todo.DueAllDay = allDay
+		case "COMPLETED":
+			todo.CompletedAt = &t
+			todo.Done = true
+		case "CREATED":
+			todo.CreatedAt = t
+		}
+	}
+	return nil
+}
+
+// icsParseTime menerima DATE, DATE-TIME UTC dan DATE-TIME floating. TZID
+// tidak di-resolve, waktunya dianggap UTC. allDay bernilai true untuk DATE.
+func icsParseTime(params, value string) (t time.Time, allDay bool, err error) {
+	if strings.Contains(strings.ToUpper(params), "VALUE=DATE") && !strings.Contains(strings.ToUpper(params), "VALUE=DATE-TIME") {
+		t, err = time.Parse(icsDate, value)
+		return t, true, err
+	}
+	if t, err = time.Parse(icsDateTime, value); err == nil {
+		return t, false, nil
+	}
+	if t, err = time.Parse("20060102T150405", value); err == nil {
+		return t, false, nil
+	}
+	t, err = time.Parse(icsDate, value)
+	return t, true, err
+}
+
+// icsUnfold menggabungkan content line yang dilipat.
+func icsUnfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// icsSplit memecah "NAME;PARAM=x:value" menjadi name, params dan value.
+func icsSplit(line string) (name, params, value string) {
+	head, value, _ := strings.Cut(line, ":")
+	name, params, _ = strings.Cut(head, ";")
+	return strings.ToUpper(name), params, value
+}
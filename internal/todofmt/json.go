@@ -0,0 +1,63 @@
+package todofmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"todo-list/internal/entity"
+)
+
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) Encode(todo *entity.Todo) error {
+	b, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if e.count == 0 {
+		prefix = "[\n"
+	}
+	e.count++
+	_, err = fmt.Fprintf(e.w, "%s%s", prefix, b)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.count == 0 {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+func decodeJSON(r io.Reader) ([]Record, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("json import must be an array of todos")
+	}
+
+	var records []Record
+	for line := 1; decoder.More(); line++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		record := Record{Line: line}
+		record.Err = json.Unmarshal(raw, &record.Todo)
+		records = append(records, record)
+	}
+	return records, nil
+}
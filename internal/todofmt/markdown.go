@@ -0,0 +1,169 @@
+package todofmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+)
+
+// Format markdown yang dipakai:
+//
+//   - [x] Judul todo
+//     <!-- id:1 position:U version:2 completed_at:2024-01-02T03:04:05Z -->
+//     deskripsi markdown, diindentasi dua spasi
+const (
+	markdownIndent    = "  "
+	metaPrefix        = "<!-- "
+	metaSuffix        = " -->"
+	markdownOpen      = "- [ ] "
+	markdownDone      = "- [x] "
+	markdownDoneUpper = "- [X] "
+)
+
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func newMarkdownEncoder(w io.Writer) *markdownEncoder {
+	return &markdownEncoder{w}
+}
+
+func (e *markdownEncoder) Encode(todo *entity.Todo) error {
+	var b strings.Builder
+	if todo.Done {
+		b.WriteString(markdownDone)
+	} else {
+		b.WriteString(markdownOpen)
+	}
+	b.WriteString(strings.ReplaceAll(todo.Title, "\n", " "))
+	b.WriteString("\n")
+
+	meta := []string{
+		fmt.Sprintf("id:%d", todo.ID),
+		fmt.Sprintf("user_id:%d", todo.UserID),
+		fmt.Sprintf("version:%d", todo.Version),
+	}
+	if todo.Position != "" {
+		meta = append(meta, "position:"+todo.Position)
+	}
+	if todo.Priority != "" {
+		meta = append(meta, "priority:"+todo.Priority)
+	}
+	if todo.DueAt != nil {
+		meta = append(meta, "due_at:"+formatTime(todo.DueAt))
+	}
+	if todo.DueAllDay {
+		meta = append(meta, "due_all_day:true")
+	}
+	if !todo.CreatedAt.IsZero() {
+		meta = append(meta, "created_at:"+formatTime(&todo.CreatedAt))
+	}
+	if todo.CompletedAt != nil {
+		meta = append(meta, "completed_at:"+formatTime(todo.CompletedAt))
+	}
+	if todo.ArchivedAt != nil {
+		meta = append(meta, "archived_at:"+formatTime(todo.ArchivedAt))
+	}
+	if todo.DeletedAt.Valid {
+		meta = append(meta, "deleted_at:"+formatTime(&todo.DeletedAt.Time))
+	}
+	b.WriteString(markdownIndent + metaPrefix + strings.Join(meta, " ") + metaSuffix + "\n")
+
+	if todo.Description != "" {
+		for _, line := range strings.Split(todo.Description, "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString(markdownIndent + line + "\n")
+		}
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *markdownEncoder) Close() error {
+	return nil
+}
+
+func decodeMarkdown(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []Record
+	var current *Record
+	var description []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Todo.Description = strings.TrimRight(strings.Join(description, "\n"), "\n")
+		records = append(records, *current)
+		current, description = nil, nil
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, markdownOpen),
+			strings.HasPrefix(text, markdownDone),
+			strings.HasPrefix(text, markdownDoneUpper):
+			flush()
+			current = &Record{Line: line}
+			current.Todo.Done = !strings.HasPrefix(text, markdownOpen)
+			current.Todo.Title = strings.TrimSpace(text[len(markdownOpen):])
+		case current == nil:
+			// teks di luar item todo (mis. heading) diabaikan
+		case len(description) == 0 && isMetaLine(text):
+			current.Err = applyMarkdownMeta(&current.Todo, text)
+		case text == "" || strings.HasPrefix(text, markdownIndent):
+			description = append(description, strings.TrimPrefix(text, markdownIndent))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return records, scanner.Err()
+}
+
+func isMetaLine(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, metaPrefix) && strings.HasSuffix(text, metaSuffix)
+}
+
+func applyMarkdownMeta(todo *entity.Todo, text string) error {
+	text = strings.TrimSpace(text)
+	text = strings.TrimSuffix(strings.TrimPrefix(text, metaPrefix), metaSuffix)
+	for _, pair := range strings.Fields(text) {
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "priority":
+			todo.Priority = value
+		case "due_at":
+			todo.DueAt, err = parseTime(value)
+		case "due_all_day":
+			todo.DueAllDay, err = strconv.ParseBool(value)
+		case "created_at":
+			var createdAt *time.Time
+			if createdAt, err = parseTime(value); createdAt != nil {
+				todo.CreatedAt = *createdAt
+			}
+		case "completed_at":
+			todo.CompletedAt, err = parseTime(value)
+		case "archived_at":
+			todo.ArchivedAt, err = parseTime(value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return nil
+}
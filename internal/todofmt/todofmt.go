@@ -0,0 +1,89 @@
+// Package todofmt berisi encoder dan decoder todo untuk fitur import dan
+// export.
+package todofmt
+
+import (
+	"errors"
+	"io"
+	"todo-list/internal/entity"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+	FormatTodoTxt  = "todotxt"
+	FormatICS      = "ics"
+)
+
+var ErrUnknownFormat = errors.New("unknown format, use json, csv, md, todotxt or ics")
+
+// Encoder menulis todo satu per satu sehingga export tidak perlu memuat
+// seluruh todo ke memory.
+type Encoder interface {
+	Encode(todo *entity.Todo) error
+	Close() error
+}
+
+// Record adalah satu baris hasil decode. Err diisi jika baris tidak valid.
+type Record struct {
+	Line int
+	Todo entity.Todo
+	Err  error
+}
+
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatJSON:
+		return newJSONEncoder(w), nil
+	case FormatCSV:
+		return newCSVEncoder(w)
+	case FormatMarkdown:
+		return newMarkdownEncoder(w), nil
+	case FormatTodoTxt:
+		return &todoTxtEncoder{w}, nil
+	case FormatICS:
+		return newICSEncoder(w), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+func Decode(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatMarkdown:
+		return decodeMarkdown(r)
+	case FormatTodoTxt:
+		return decodeTodoTxt(r)
+	case FormatICS:
+		return decodeICS(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatTodoTxt:
+		return "text/plain; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// Extension mengembalikan ekstensi file untuk format export.
+func Extension(format string) string {
+	if format == FormatTodoTxt {
+		return "txt"
+	}
+	return format
+}
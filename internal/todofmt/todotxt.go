@@ -0,0 +1,263 @@
+package todofmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"todo-list/internal/entity"
+)
+
+// Format todo.txt (https://github.com/todotxt/todo.txt):
+//
+//	x (A) 2024-01-02 2024-01-01 Judul +project @context due:2024-01-10
+//
+// +project pertama dipetakan ke Project dan setiap @context ke Tags.
+// Extension key:value lain dibiarkan di dalam title. Extension yang
+// dipetakan ke field todo:
+//
+//	due:     jatuh tempo (DueAt), berupa tanggal untuk due all-day atau
+//	         RFC 3339 jika ada jam
+//	pri:     priority todo yang sudah selesai, karena todo.txt membuang
+//	         penanda (A) saat todo diselesaikan
+//	created: tanggal dibuat untuk todo selesai tanpa tanggal selesai
+//	desc:    description yang di-escape dengan url.PathEscape
+//	title:   title yang di-escape dengan url.PathEscape, dipakai jika title
+//	         akan terbaca sebagai sintaks todo.txt
+//
+// Tanggal dibuat dan tanggal selesai hanya disimpan sampai tingkat hari.
+const todoTxtDate = "2006-01-02"
+
+// formatTodoTxt mengubah todo menjadi satu baris todo.txt.
+func formatTodoTxt(todo *entity.Todo) string {
+	var parts []string
+	if todo.Done {
+		parts = append(parts, "x")
+		if todo.CompletedAt != nil {
+			parts = append(parts, todo.CompletedAt.UTC().Format(todoTxtDate))
+		}
+	} else if todo.Priority != "" {
+		parts = append(parts, "("+todo.Priority+")")
+	}
+	// tanggal dibuat hanya boleh muncul setelah tanggal selesai pada todo yang sudah selesai
+	createdInline := !todo.Done || todo.CompletedAt != nil
+	if !todo.CreatedAt.IsZero() && createdInline {
+		parts = append(parts, todo.CreatedAt.UTC().Format(todoTxtDate))
+	}
+
+	if todo.Title != "" {
+		if plainTodoTxtTitle(todo.Title) {
+			parts = append(parts, todo.Title)
+		} else {
+			parts = append(parts, "title:"+url.PathEscape(todo.Title))
+		}
+	}
+	if todo.Project != "" {
+		parts = append(parts, "+"+todo.Project)
+	}
+	for _, tag := range todo.Tags {
+		parts = append(parts, "@"+tag)
+	}
+	if todo.Done && todo.Priority != "" {
+		parts = append(parts, "pri:"+todo.Priority)
+	}
+	if !todo.CreatedAt.IsZero() && !createdInline {
+		parts = append(parts, "created:"+todo.CreatedAt.UTC().Format(todoTxtDate))
+	}
+	if todo.DueAt != nil {
+		parts = append(parts, "due:"+formatTodoTxtDue(*todo.DueAt, todo.DueAllDay))
+	}
+	if todo.Description != "" {
+		parts = append(parts, "desc:"+url.PathEscape(todo.Description))
+	}
+	return strings.Join(parts, " ")
+}
+
+// plainTodoTxtTitle mengecek apakah title bisa ditulis apa adanya tanpa
+// terbaca sebagai penanda selesai, priority, tanggal, project, context
+// atau extension, dan tanpa spasi yang hilang saat dipecah per kata.
+func plainTodoTxtTitle(title string) bool {
+	fields := strings.Fields(title)
+	if strings.Join(fields, " ") != title {
+		return false
+	}
+	if fields[0] == "x" || isTodoTxtPriority(fields[0]) {
+		return false
+	}
+	if _, ok := parseTodoTxtDate(fields); ok {
+		return false
+	}
+	for _, field := range fields {
+		if len(field) > 1 && (field[0] == '+' || field[0] == '@') {
+			return false
+		}
+		if key, value, ok := strings.Cut(field, ":"); ok && value != "" && todoTxtExtensions[key] {
+			return false
+		}
+	}
+	return true
+}
+
+var todoTxtExtensions = map[string]bool{
+	"due":     true,
+	"pri":     true,
+	"created": true,
+	"desc":    true,
+	"title":   true,
+}
+
+// formatTodoTxtDue menulis tanggal saja untuk due all-day, selain itu
+// lengkap dengan jam agar tidak ada yang hilang.
+func formatTodoTxtDue(due time.Time, allDay bool) string {
+	due = due.UTC()
+	if allDay {
+		return due.Format(todoTxtDate)
+	}
+	return due.Format(time.RFC3339Nano)
+}
+
+func parseTodoTxtDue(value string) (due time.Time, allDay bool, err error) {
+	if due, err = time.Parse(todoTxtDate, value); err == nil {
+		return due, true, nil
+	}
+	if due, err = time.Parse(time.RFC3339, value); err != nil {
+		return time.Time{}, false, err
+	}
+	return due.UTC(), false, nil
+}
+
+// parseTodoTxt membaca satu baris todo.txt.
+func parseTodoTxt(line string) (entity.Todo, error) {
+	var todo entity.Todo
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return todo, fmt.Errorf("empty todo.txt line")
+	}
+
+	if fields[0] == "x" {
+		todo.Done = true
+		fields = fields[1:]
+		if completedAt, ok := parseTodoTxtDate(fields); ok {
+			todo.CompletedAt = &completedAt
+			fields = fields[1:]
+			if createdAt, ok := parseTodoTxtDate(fields); ok {
+				todo.CreatedAt = createdAt
+				fields = fields[1:]
+			}
+		}
+	} else {
+		if len(fields) > 0 && isTodoTxtPriority(fields[0]) {
+			todo.Priority = fields[0][1:2]
+			fields = fields[1:]
+		}
+		if createdAt, ok := parseTodoTxtDate(fields); ok {
+			todo.CreatedAt = createdAt
+			fields = fields[1:]
+		}
+	}
+
+	var escapedTitle string
+	title := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len(field) > 1 && field[0] == '+' && todo.Project == "" && entity.ValidProject(field[1:]) {
+			todo.Project = field[1:]
+			continue
+		}
+		if len(field) > 1 && field[0] == '@' && entity.ValidTag(field[1:]) {
+			if !todo.HasTag(field[1:]) {
+				todo.Tags = append(todo.Tags, field[1:])
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			title = append(title, field)
+			continue
+		}
+		switch key {
+		case "due":
+			due, allDay, err := parseTodoTxtDue(value)
+			if err != nil {
+				return todo, fmt.Errorf("invalid due date %q", value)
+			}
+			todo.DueAt = &due
+			todo.DueAllDay = allDay
+		case "pri":
+			if !isTodoTxtPriority("(" + value + ")") {
+				return todo, fmt.Errorf("invalid priority %q", value)
+			}
+			todo.Priority = value
+		case "created":
+			createdAt, err := time.Parse(todoTxtDate, value)
+			if err != nil {
+				return todo, fmt.Errorf("invalid created date %q", value)
+			}
+			todo.CreatedAt = createdAt
+		case "desc":
+			description, err := url.PathUnescape(value)
+			if err != nil {
+				return todo, fmt.Errorf("invalid description: %w", err)
+			}
+			todo.Description = description
+		case "title":
+			var err error
+			if escapedTitle, err = url.PathUnescape(value); err != nil {
+				return todo, fmt.Errorf("invalid title: %w", err)
+			}
+		default:
+			title = append(title, field)
+		}
+	}
+	todo.Title = strings.Join(title, " ")
+	switch {
+	case escapedTitle != "" && todo.Title != "":
+		todo.Title = escapedTitle + " " + todo.Title
+	case escapedTitle != "":
+		todo.Title = escapedTitle
+	}
+	return todo, nil
+}
+
+func parseTodoTxtDate(fields []string) (time.Time, bool) {
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(todoTxtDate, fields[0])
+	return t, err == nil
+}
+
+func isTodoTxtPriority(field string) bool {
+	return len(field) == 3 && field[0] == '(' && field[2] == ')' && field[1] >= 'A' && field[1] <= 'Z'
+}
+
+type todoTxtEncoder struct {
+	w io.Writer
+}
+
+func (e *todoTxtEncoder) Encode(todo *entity.Todo) error {
+	_, err := io.WriteString(e.w, formatTodoTxt(todo)+"\n")
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return nil
+}
+
+func decodeTodoTxt(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []Record
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		record := Record{Line: line}
+		record.Todo, record.Err = parseTodoTxt(text)
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,205 @@
+package todofmt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+	"todo-list/internal/entity"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(todoTxtDate, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func datePtr(s string) *time.Time {
+	t := date(s)
+	return &t
+}
+
+func timePtr(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		todo entity.Todo
+	}{
+		{"plain title", entity.Todo{Title: "Buy milk"}},
+		{"empty title with description", entity.Todo{Description: "only a description"}},
+		{"priority and created date", entity.Todo{Title: "Call mom", Priority: "A", CreatedAt: date("2024-01-01")}},
+		{"done with dates", entity.Todo{Title: "Pay rent", Done: true, CompletedAt: datePtr("2024-01-02"), CreatedAt: date("2024-01-01")}},
+		{"done with priority", entity.Todo{Title: "Ship it", Done: true, Priority: "B", CompletedAt: datePtr("2024-01-02")}},
+		{"done without completed date keeps created date", entity.Todo{Title: "Old task", Done: true, CreatedAt: date("2024-01-01")}},
+		{"project and tags", entity.Todo{Title: "Write report", Project: "work", Tags: entity.StringList{"office", "computer"}}},
+		{"all-day due", entity.Todo{Title: "Renew passport", DueAt: datePtr("2024-03-01"), DueAllDay: true}},
+		{"due at midnight is not all-day", entity.Todo{Title: "Midnight launch", DueAt: timePtr("2024-03-01T00:00:00Z")}},
+		{"due with time of day", entity.Todo{Title: "Standup", DueAt: timePtr("2024-03-01T15:00:00Z")}},
+		{"due with fraction of a second", entity.Todo{Title: "Deploy", DueAt: timePtr("2024-03-01T15:04:05.123Z")}},
+		{"multi-line description", entity.Todo{Title: "Plan trip", Description: "# Packing\n- passport\n- 100% charged phone: yes"}},
+		{"title starting with x", entity.Todo{Title: "x marks the spot"}},
+		{"done title starting with x", entity.Todo{Title: "x marks the spot", Done: true, CompletedAt: datePtr("2024-01-02")}},
+		{"title starting with priority", entity.Todo{Title: "(B) literal"}},
+		{"title starting with priority after priority", entity.Todo{Title: "(B) literal", Priority: "A"}},
+		{"title starting with a date", entity.Todo{Title: "2024-01-01 is a date"}},
+		{"title with a date after created date", entity.Todo{Title: "2024-01-01 is a date", CreatedAt: date("2023-12-31")}},
+		{"title with desc extension", entity.Todo{Title: "see desc:foo later"}},
+		{"title with invalid due extension", entity.Todo{Title: "meet at due:noon"}},
+		{"title with title extension", entity.Todo{Title: "rename title:foo"}},
+		{"title with project and context words", entity.Todo{Title: "email +alice about @home", Project: "inbox"}},
+		{"title with unknown extension", entity.Todo{Title: "read isbn:12345"}},
+		{"title with repeated whitespace", entity.Todo{Title: "two  spaces\tand a tab"}},
+		{"title with surrounding whitespace", entity.Todo{Title: " padded "}},
+		{"title with a lone plus and at", entity.Todo{Title: "1 + 1 @ home"}},
+		{"title with percent", entity.Todo{Title: "100%20 done x"}},
+		{
+			"every field",
+			entity.Todo{
+				Title:       "x (A) 2024-01-01 +p @c due:x",
+				Description: "desc: with colon",
+				Done:        true,
+				Priority:    "C",
+				DueAt:       timePtr("2024-02-03T04:05:06Z"),
+				Project:     "home",
+				Tags:        entity.StringList{"errand"},
+				CreatedAt:   date("2024-01-01"),
+				CompletedAt: datePtr("2024-01-05"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := formatTodoTxt(&tt.todo)
+			got, err := parseTodoTxt(line)
+			if err != nil {
+				t.Fatalf("parse(%q): %v", line, err)
+			}
+			if !reflect.DeepEqual(got, tt.todo) {
+				t.Errorf("round trip through %q\ngot  %+v\nwant %+v", line, got, tt.todo)
+			}
+		})
+	}
+}
+
+func TestParseTodoTxt(t *testing.T) {
+	tests := []struct {
+		line string
+		want entity.Todo
+	}{
+		{
+			"(A) Thank Mom for the meatballs @phone",
+			entity.Todo{Title: "Thank Mom for the meatballs", Priority: "A", Tags: entity.StringList{"phone"}},
+		},
+		{
+			"(B) Schedule Goodwill pickup +GarageSale @phone",
+			entity.Todo{Title: "Schedule Goodwill pickup", Priority: "B", Project: "GarageSale", Tags: entity.StringList{"phone"}},
+		},
+		{
+			"x 2011-03-03 2011-03-01 Review Tim's pull request +TodoTxtTouch @github",
+			entity.Todo{
+				Title:       "Review Tim's pull request",
+				Done:        true,
+				Project:     "TodoTxtTouch",
+				Tags:        entity.StringList{"github"},
+				CreatedAt:   date("2011-03-01"),
+				CompletedAt: datePtr("2011-03-03"),
+			},
+		},
+		{
+			"Post signs around the neighborhood +GarageSale +Community @errand @errand",
+			entity.Todo{Title: "Post signs around the neighborhood +Community", Project: "GarageSale", Tags: entity.StringList{"errand"}},
+		},
+		{
+			"2011-03-02 Document +TodoTxt task format due:2011-03-05",
+			entity.Todo{Title: "Document task format", Project: "TodoTxt", CreatedAt: date("2011-03-02"), DueAt: datePtr("2011-03-05"), DueAllDay: true},
+		},
+		{
+			"Really gotta call Mom (A) @phone @someday",
+			entity.Todo{Title: "Really gotta call Mom (A)", Tags: entity.StringList{"phone", "someday"}},
+		},
+		{
+			"xylophone lesson",
+			entity.Todo{Title: "xylophone lesson"},
+		},
+		{
+			"X 2012-01-01 Call Mom",
+			entity.Todo{Title: "X 2012-01-01 Call Mom"},
+		},
+		{
+			"email a@b.c about url:http://example.com",
+			entity.Todo{Title: "email a@b.c about url:http://example.com"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseTodoTxt(tt.line)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", tt.line, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parse(%q)\ngot  %+v\nwant %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseTodoTxtErrors(t *testing.T) {
+	for _, line := range []string{
+		"Meet at due:noon",
+		"x Done pri:AB",
+		"Broken desc:%zz",
+		"Broken title:%zz",
+		"Old created:yesterday",
+	} {
+		if _, err := parseTodoTxt(line); err == nil {
+			t.Errorf("parse(%q) expected an error", line)
+		}
+	}
+}
+
+func TestTodoTxtEncodeDecode(t *testing.T) {
+	todos := []entity.Todo{
+		{Title: "first", Project: "a"},
+		{Title: "x second", Done: true, CompletedAt: datePtr("2024-01-02")},
+		{Title: "third", Description: "line 1\nline 2"},
+	}
+	var buf bytes.Buffer
+	encoder, err := NewEncoder(FormatTodoTxt, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range todos {
+		if err := encoder.Encode(&todos[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Decode(FormatTodoTxt, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(todos) {
+		t.Fatalf("decoded %d records, want %d", len(records), len(todos))
+	}
+	for i, record := range records {
+		if record.Err != nil {
+			t.Fatalf("line %d: %v", record.Line, record.Err)
+		}
+		if record.Line != i+1 {
+			t.Errorf("record %d has line %d", i, record.Line)
+		}
+		if !reflect.DeepEqual(record.Todo, todos[i]) {
+			t.Errorf("line %d\ngot  %+v\nwant %+v", record.Line, record.Todo, todos[i])
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+	"todo-list/internal/service"
+)
+
+// AutoArchiveJob mengarsipkan todo yang sudah selesai lebih lama dari after.
+func AutoArchiveJob(todoService service.TodoService, after time.Duration) service.JobHandler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		archived, err := todoService.AutoArchive(ctx, after)
+		if err != nil {
+			return err
+		}
+		if archived > 0 {
+			log.Printf("job auto-archiver: archived %d todos", archived)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-list/internal/service"
+)
+
+// NewOutboxRelay meneruskan event dari tabel outbox ke semua sink.
+func NewOutboxRelay(relay service.OutboxRelay, interval time.Duration) *Periodic {
+	return NewPeriodic("outbox-relay", interval, func(ctx context.Context) error {
+		published, err := relay.Relay(ctx)
+		if err != nil {
+			return err
+		}
+		if published > 0 {
+			log.Printf("worker outbox-relay: published %d events", published)
+		}
+		return nil
+	})
+}
+
+// NewOutboxPurger menghapus message outbox yang sudah terkirim lebih lama
+// dari retention.
+func NewOutboxPurger(relay service.OutboxRelay, retention, interval time.Duration) *Periodic {
+	return NewPeriodic("outbox-purger", interval, func(ctx context.Context) error {
+		purged, err := relay.PurgePublished(ctx, retention)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("worker outbox-purger: purged %d messages", purged)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,23 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"todo-list/internal/service"
+)
+
+// RebalanceJob merapikan position todo yang key-nya sudah terlalu panjang
+// akibat banyak perpindahan di titik yang sama.
+func RebalanceJob(todoService service.TodoService, maxLength int) service.JobHandler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		users, err := todoService.RebalancePositions(ctx, maxLength)
+		if err != nil {
+			return err
+		}
+		if users > 0 {
+			log.Printf("job rank-rebalancer: rebalanced todos of %d users", users)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"todo-list/internal/service"
+	"todo-list/pkg/mailer"
+)
+
+// ReminderJob mengirim reminder todo yang sudah waktunya.
+func ReminderJob(reminderService service.ReminderService) service.JobHandler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		sent, err := reminderService.SendDueReminders(ctx)
+		if sent > 0 {
+			log.Printf("job reminder-dispatcher: sent %d reminders", sent)
+		}
+		return err
+	}
+}
+
+// SendEmailJob mengirim email yang dijadwalkan oleh channel email.
+func SendEmailJob(m mailer.Mailer) service.JobHandler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var msg mailer.Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		return m.Send(ctx, msg)
+	}
+}
+
+// AccountEmailJob mengirim link verifikasi email dan token reset password.
+func AccountEmailJob(accountService service.AccountService) service.JobHandler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		return accountService.SendTokenEmail(ctx, payload)
+	}
+}
+
+// DigestJob menjadwalkan email digest yang sudah waktunya.
+func DigestJob(digestService service.DigestService) service.JobHandler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		sent, err := digestService.SendDue(ctx)
+		if sent > 0 {
+			log.Printf("job digest-dispatcher: queued %d digests", sent)
+		}
+		return err
+	}
+}
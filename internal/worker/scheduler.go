@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/internal/service"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser menerima ekspresi cron 5 field dan descriptor seperti @daily.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// alignedEvery berjalan setiap kelipatan interval (time.Truncate),
+// sehingga semua replica menghasilkan waktu jadwal yang sama.
+type alignedEvery time.Duration
+
+func (e alignedEvery) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+type schedule struct {
+	name     string
+	schedule cron.Schedule
+	next     time.Time
+}
+
+// Scheduler meng-enqueue job sesuai jadwal lalu menjalankan job yang sudah
+// waktunya. Setiap waktu jadwal di-enqueue dengan unique key, sehingga
+// walaupun semua replica menjalankan Scheduler, tiap jadwal hanya dijalankan
+// sekali.
+type Scheduler struct {
+	jobService service.JobService
+	handlers   map[string]service.JobHandler
+	schedules  []*schedule
+}
+
+func NewScheduler(jobService service.JobService) *Scheduler {
+	return &Scheduler{jobService: jobService, handlers: map[string]service.JobHandler{}}
+}
+
+// Handle mendaftarkan handler untuk job dengan nama tersebut, termasuk job
+// yang di-enqueue dari service lain.
+func (s *Scheduler) Handle(name string, handler service.JobHandler) {
+	s.handlers[name] = handler
+}
+
+// Cron menjadwalkan job dengan ekspresi cron dalam UTC. "@every" tidak
+// didukung karena waktunya bergantung pada kapan replica dijalankan,
+// gunakan Every.
+func (s *Scheduler) Cron(name, spec string, handler service.JobHandler) error {
+	sched, err := cronParser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	if _, ok := sched.(*cron.SpecSchedule); !ok {
+		return fmt.Errorf("job %s: unsupported schedule %q", name, spec)
+	}
+	s.add(name, sched, handler)
+	return nil
+}
+
+// Every menjadwalkan job setiap interval.
+func (s *Scheduler) Every(name string, interval time.Duration, handler service.JobHandler) {
+	s.add(name, alignedEvery(interval), handler)
+}
+
+func (s *Scheduler) add(name string, sched cron.Schedule, handler service.JobHandler) {
+	s.Handle(name, handler)
+	s.schedules = append(s.schedules, &schedule{name: name, schedule: sched})
+}
+
+// Worker mengembalikan worker yang memeriksa jadwal dan job setiap interval.
+func (s *Scheduler) Worker(interval time.Duration) *Periodic {
+	return NewPeriodic("scheduler", interval, s.tick)
+}
+
+func (s *Scheduler) tick(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, sched := range s.schedules {
+		if sched.next.IsZero() {
+			sched.next = sched.schedule.Next(now)
+			continue
+		}
+		if now.Before(sched.next) {
+			continue
+		}
+		// jadwal yang terlewat saat replica mati tidak dijalankan ulang
+		key := fmt.Sprintf("%s@%d", sched.name, sched.next.Unix())
+		if _, err := s.jobService.EnqueueUnique(ctx, key, sched.name, nil, sched.next); err != nil {
+			return err
+		}
+		sched.next = sched.schedule.Next(now)
+	}
+
+	succeeded, err := s.jobService.RunDue(ctx, s.handlers)
+	if err != nil {
+		return err
+	}
+	if succeeded > 0 {
+		log.Printf("worker scheduler: ran %d jobs", succeeded)
+	}
+	return nil
+}
+
+// JobPurgeJob menghapus riwayat job yang sudah selesai lebih lama dari
+// retention.
+func JobPurgeJob(jobService service.JobService, retention time.Duration) service.JobHandler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		purged, err := jobService.PurgeFinished(ctx, retention)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("job job-purger: purged %d job runs", purged)
+		}
+		return nil
+	}
+}
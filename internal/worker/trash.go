@@ -0,0 +1,24 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+	"todo-list/internal/service"
+)
+
+// TrashPurgeJob menghapus permanen todo yang sudah berada di trash lebih
+// lama dari retention.
+func TrashPurgeJob(todoService service.TodoService, retention time.Duration) service.JobHandler {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		purged, err := todoService.PurgeExpiredTrash(ctx, retention)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("job trash-purger: purged %d todos", purged)
+		}
+		return nil
+	}
+}
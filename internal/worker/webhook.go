@@ -0,0 +1,23 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-list/internal/service"
+)
+
+// NewWebhookDispatcher mengirim delivery webhook yang sudah waktunya,
+// termasuk percobaan ulang dari delivery yang gagal.
+func NewWebhookDispatcher(webhookService service.WebhookService, interval time.Duration) *Periodic {
+	return NewPeriodic("webhook-dispatcher", interval, func(ctx context.Context) error {
+		sent, err := webhookService.DispatchDue(ctx)
+		if err != nil {
+			return err
+		}
+		if sent > 0 {
+			log.Printf("worker webhook-dispatcher: delivered %d webhooks", sent)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Periodic menjalankan sebuah task secara berkala sampai context dibatalkan.
+type Periodic struct {
+	name     string
+	interval time.Duration
+	task     func(ctx context.Context) error
+}
+
+func NewPeriodic(name string, interval time.Duration, task func(ctx context.Context) error) *Periodic {
+	return &Periodic{name, interval, task}
+}
+
+func (p *Periodic) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.task(ctx); err != nil {
+			log.Printf("worker %s: %v", p.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,18 @@
+-- Tabel awal aplikasi. Memakai IF NOT EXISTS karena database yang sudah
+-- berjalan membuat tabel ini secara manual.
+CREATE TABLE IF NOT EXISTS public.users (
+    id        bigserial PRIMARY KEY,
+    username  text NOT NULL,
+    password  text NOT NULL,
+    role      text NOT NULL DEFAULT 'user',
+    full_name text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS public.todos (
+    id      bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    title   text NOT NULL,
+    done    boolean NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_id ON public.todos (user_id);
@@ -0,0 +1 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '';
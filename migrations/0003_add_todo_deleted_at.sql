@@ -0,0 +1,3 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON public.todos (deleted_at);
@@ -0,0 +1,2 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS completed_at timestamptz;
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS archived_at timestamptz;
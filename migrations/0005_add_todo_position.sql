@@ -0,0 +1,23 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS position varchar(255) NOT NULL DEFAULT '';
+
+-- Todo yang sudah ada diberi key pkg/rank berurutan sesuai id: nomor urut
+-- per user ditulis sebagai 4 digit base62, lalu nol di belakang dibuang
+-- karena key rank tidak boleh diakhiri digit nol.
+WITH ordered AS (
+    SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY id) AS n
+    FROM public.todos
+    WHERE position = ''
+), digits AS (
+    SELECT '0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz'::text AS d
+)
+UPDATE public.todos t
+SET position = rtrim(
+    substr(digits.d, ((o.n / 238328) % 62 + 1)::int, 1) ||
+    substr(digits.d, ((o.n / 3844) % 62 + 1)::int, 1) ||
+    substr(digits.d, ((o.n / 62) % 62 + 1)::int, 1) ||
+    substr(digits.d, (o.n % 62 + 1)::int, 1),
+    '0')
+FROM ordered o, digits
+WHERE t.id = o.id;
+
+CREATE INDEX IF NOT EXISTS idx_todos_position ON public.todos (position);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.todo_events (
+    id         bigserial PRIMARY KEY,
+    todo_id    bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    actor_id   bigint NOT NULL,
+    as_admin   boolean NOT NULL DEFAULT false,
+    action     text NOT NULL,
+    changes    jsonb NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_events_todo_id ON public.todo_events (todo_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS public.audit_logs (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz NOT NULL,
+    action      text NOT NULL,
+    actor_id    bigint,
+    actor_role  text NOT NULL DEFAULT '',
+    target_type text NOT NULL DEFAULT '',
+    target_id   text NOT NULL DEFAULT '',
+    ip          text NOT NULL DEFAULT '',
+    user_agent  text NOT NULL DEFAULT '',
+    request_id  text NOT NULL DEFAULT '',
+    metadata    text NOT NULL DEFAULT '',
+    prev_hash   text NOT NULL DEFAULT '',
+    hash        text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON public.audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON public.audit_logs (actor_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_hash ON public.audit_logs (hash);
+
+-- settings menyimpan head hash chain audit log (audit.head).
+CREATE TABLE IF NOT EXISTS public.settings (
+    key        varchar(100) PRIMARY KEY,
+    value      text NOT NULL DEFAULT '',
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS project varchar(100) NOT NULL DEFAULT '';
+-- tags disimpan sebagai text yang dipisahkan koma (entity.StringList)
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS tags text NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_todos_project ON public.todos (project);
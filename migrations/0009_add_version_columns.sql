@@ -0,0 +1,2 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
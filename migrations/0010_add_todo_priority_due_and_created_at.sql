@@ -0,0 +1,3 @@
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS priority varchar(1) NOT NULL DEFAULT '';
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS due_at timestamptz;
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS public.feed_tokens (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_feed_tokens_user_id ON public.feed_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feed_tokens_token_hash ON public.feed_tokens (token_hash);
+
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS due_all_day boolean NOT NULL DEFAULT false;
+
+-- sebelum kolom ini ada, due tepat tengah malam UTC ditulis sebagai DATE
+-- di feed, sehingga todo lama tetap tampil sebagai all-day
+UPDATE public.todos
+SET due_all_day = true
+WHERE due_at IS NOT NULL AND due_at = date_trunc('day', due_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS public.app_passwords (
+    id            bigserial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    name          varchar(100) NOT NULL DEFAULT '',
+    password_hash varchar(64) NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    last_used_at  timestamptz,
+    revoked_at    timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_app_passwords_user_id ON public.app_passwords (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_app_passwords_password_hash ON public.app_passwords (password_hash);
+
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS ical_uid varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE public.todos ADD COLUMN IF NOT EXISTS caldav_name varchar(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_todos_caldav_name ON public.todos (caldav_name);
+
+-- sync-collection membaca event per user setelah sync token tertentu
+CREATE INDEX IF NOT EXISTS idx_todo_events_user_id_id ON public.todo_events (user_id, id);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS public.webhooks (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    global     boolean NOT NULL DEFAULT false,
+    url        varchar(2048) NOT NULL,
+    secret     varchar(64) NOT NULL,
+    -- entity.StringList, dipisahkan koma
+    events     text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON public.webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS public.webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    webhook_id      bigint NOT NULL,
+    event_id        varchar(100) NOT NULL,
+    event_type      varchar(50) NOT NULL,
+    payload         text NOT NULL,
+    status          varchar(20) NOT NULL,
+    attempts        bigint NOT NULL DEFAULT 0,
+    response_status bigint NOT NULL DEFAULT 0,
+    last_error      varchar(1000) NOT NULL DEFAULT '',
+    next_attempt_at timestamptz,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    delivered_at    timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON public.webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON public.webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON public.webhook_deliveries (next_attempt_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS public.outbox_messages (
+    id           bigserial PRIMARY KEY,
+    event_type   varchar(50) NOT NULL,
+    payload      text NOT NULL,
+    attempts     bigint NOT NULL DEFAULT 0,
+    last_error   varchar(1000) NOT NULL DEFAULT '',
+    available_at timestamptz NOT NULL DEFAULT now(),
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    published_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_available_at ON public.outbox_messages (available_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_published_at ON public.outbox_messages (published_at);
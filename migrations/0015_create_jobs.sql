@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS public.jobs (
+    id           bigserial PRIMARY KEY,
+    name         varchar(100) NOT NULL,
+    unique_key   varchar(200),
+    payload      text NOT NULL DEFAULT '',
+    status       varchar(20) NOT NULL,
+    attempts     bigint NOT NULL DEFAULT 0,
+    max_attempts bigint NOT NULL DEFAULT 0,
+    last_error   varchar(1000) NOT NULL DEFAULT '',
+    run_at       timestamptz NOT NULL DEFAULT now(),
+    started_at   timestamptz,
+    finished_at  timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    updated_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_name ON public.jobs (name);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON public.jobs (status);
+CREATE INDEX IF NOT EXISTS idx_jobs_run_at ON public.jobs (run_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON public.jobs (finished_at);
+-- wajib ada untuk INSERT ... ON CONFLICT (unique_key) DO NOTHING di
+-- jobRepository.Create. Tidak boleh partial karena ON CONFLICT tanpa
+-- predikat hanya memakai index unik penuh; NULL tetap boleh berulang.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_key ON public.jobs (unique_key);
@@ -0,0 +1,31 @@
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS email varchar(255) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS public.notifications (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    todo_id    bigint,
+    type       varchar(50) NOT NULL,
+    title      varchar(300) NOT NULL,
+    body       text NOT NULL DEFAULT '',
+    read_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON public.notifications (user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_read_at ON public.notifications (read_at);
+
+CREATE TABLE IF NOT EXISTS public.todo_reminders (
+    id             bigserial PRIMARY KEY,
+    todo_id        bigint NOT NULL,
+    user_id        bigint NOT NULL,
+    remind_at      timestamptz,
+    minutes_before bigint,
+    -- entity.StringList, dipisahkan koma
+    channels       text NOT NULL DEFAULT '',
+    sent_at        timestamptz,
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_reminders_todo_id ON public.todo_reminders (todo_id);
+CREATE INDEX IF NOT EXISTS idx_todo_reminders_user_id ON public.todo_reminders (user_id);
+CREATE INDEX IF NOT EXISTS idx_todo_reminders_sent_at ON public.todo_reminders (sent_at);
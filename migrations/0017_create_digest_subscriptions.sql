@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS public.digest_subscriptions (
+    id                     bigserial PRIMARY KEY,
+    user_id                bigint NOT NULL,
+    frequency              varchar(10) NOT NULL,
+    timezone               varchar(64) NOT NULL,
+    hour                   bigint NOT NULL DEFAULT 0,
+    weekday                bigint NOT NULL DEFAULT 0,
+    unsubscribe_token_hash varchar(64) NOT NULL,
+    next_send_at           timestamptz NOT NULL,
+    last_sent_at           timestamptz,
+    created_at             timestamptz NOT NULL DEFAULT now(),
+    updated_at             timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_digest_subscriptions_user_id ON public.digest_subscriptions (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_digest_subscriptions_unsubscribe_token_hash ON public.digest_subscriptions (unsubscribe_token_hash);
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_next_send_at ON public.digest_subscriptions (next_send_at);
@@ -0,0 +1,15 @@
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS public.user_tokens (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    purpose    varchar(30) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    email      varchar(255) NOT NULL DEFAULT '',
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON public.user_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON public.user_tokens (token_hash);
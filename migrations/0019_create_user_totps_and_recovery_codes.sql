@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.user_totps (
+    user_id        bigint PRIMARY KEY,
+    secret         varchar(64) NOT NULL,
+    confirmed_at   timestamptz,
+    last_used_step bigint NOT NULL DEFAULT 0,
+    created_at     timestamptz NOT NULL DEFAULT now(),
+    updated_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.user_recovery_codes (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    code_hash  varchar(64) NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON public.user_recovery_codes (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_recovery_codes_code_hash ON public.user_recovery_codes (code_hash);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS public.personal_access_tokens (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    name         varchar(100) NOT NULL,
+    hint         varchar(20) NOT NULL DEFAULT '',
+    token_hash   varchar(64) NOT NULL,
+    -- entity.StringList, dipisahkan koma
+    scopes       text NOT NULL DEFAULT '',
+    expires_at   timestamptz NOT NULL,
+    last_used_at timestamptz,
+    last_used_ip varchar(45) NOT NULL DEFAULT '',
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    revoked_at   timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON public.personal_access_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_personal_access_tokens_token_hash ON public.personal_access_tokens (token_hash);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.user_identities (
+    id            bigserial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    issuer        varchar(255) NOT NULL,
+    subject       varchar(255) NOT NULL,
+    email         varchar(255) NOT NULL DEFAULT '',
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    last_login_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON public.user_identities (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_subject ON public.user_identities (issuer, subject);
@@ -0,0 +1,10 @@
+// Package migrations berisi migrasi SQL database. File dijalankan
+// berurutan berdasarkan nomor versi di awal nama file dan tidak boleh
+// diubah setelah dirilis, perubahan skema selalu ditambahkan sebagai file
+// baru.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
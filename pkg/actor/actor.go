@@ -0,0 +1,37 @@
+// Package actor menyimpan identitas user yang sedang melakukan request di
+// dalam context sehingga bisa dibaca oleh layer service.
+package actor
+
+import "context"
+
+type Actor struct {
+	UserID    uint
+	Role      string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// RoleSystem adalah role actor untuk perubahan yang dilakukan aplikasi,
+// bukan oleh user, misalnya role yang disinkronkan dari identity provider.
+const RoleSystem = "system"
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+func FromContext(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(contextKey{}).(Actor)
+	return a, ok
+}
+
+// System mengganti actor di ctx dengan actor sistem. IP, user agent dan
+// request ID dari request yang memicu perubahan tetap disimpan.
+func System(ctx context.Context) context.Context {
+	a, _ := FromContext(ctx)
+	a.UserID = 0
+	a.Role = RoleSystem
+	return NewContext(ctx, a)
+}
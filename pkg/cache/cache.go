@@ -22,6 +22,8 @@ type Cacheable interface {
 	Set(key string, value interface{}, duration time.Duration) error
 	Get(key string) string
 	Delete(key string) error
+	SetNX(key string, value interface{}, duration time.Duration) (bool, error)
+	Incr(key string, window time.Duration) (int64, error)
 }
 
 type cacheable struct {
@@ -51,4 +53,25 @@ func (c *cacheable) Get(key string) string {
 }
 func (c *cacheable) Delete(key string) error {
     return c.rdb.Del(context.Background(), key).Err()
-}
\ No newline at end of file
+}
+
+// SetNX hanya menyimpan value jika key belum ada.
+func (c *cacheable) SetNX(key string, value interface{}, duration time.Duration) (bool, error) {
+	return c.rdb.SetNX(context.Background(), key, value, duration).Result()
+}
+
+// incrScript menjalankan INCR dan PEXPIRE secara atomik sehingga key tidak
+// pernah tertinggal tanpa TTL.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Incr menambah counter dan memasang expiry window saat counter pertama kali
+// dibuat, dipakai untuk rate limit fixed window.
+func (c *cacheable) Incr(key string, window time.Duration) (int64, error) {
+	return incrScript.Run(context.Background(), c.rdb, []string{key}, window.Milliseconds()).Int64()
+}
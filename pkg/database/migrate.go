@@ -0,0 +1,63 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID adalah key advisory lock agar replica yang start
+// bersamaan tidak menjalankan migrasi yang sama.
+const migrationLockID = 7346100
+
+// Migrate menjalankan file .sql di fsys yang belum tercatat di
+// schema_migrations, berurutan berdasarkan nama file. Setiap file berjalan
+// dalam transaksinya sendiri.
+func Migrate(db *gorm.DB, fsys fs.FS) error {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS public.schema_migrations (
+		version    varchar(255) PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := applyMigration(db, fsys, name); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *gorm.DB, fsys fs.FS, name string) error {
+	version := strings.TrimSuffix(name, ".sql")
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+		var applied int64
+		if err := tx.Table("public.schema_migrations").Where("version = ?", version).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			return nil
+		}
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec(string(script)).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO public.schema_migrations (version) VALUES (?)", version).Error
+	})
+}
@@ -0,0 +1,258 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid expectation %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// Contoh dari RFC 6902 appendix A. A.13 (member "op" ganda) tidak diuji
+// karena encoding/json selalu memakai member terakhir.
+func TestApplyRFC6902Examples(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			"A.1 adding an object member",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			"A.2 adding an array element",
+			`{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			"A.3 removing an object member",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`,
+		},
+		{
+			"A.4 removing an array element",
+			`{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`,
+		},
+		{
+			"A.5 replacing a value",
+			`{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			"A.6 moving a value",
+			`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			"A.7 moving an array element",
+			`{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			"A.8 testing a value: success",
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			"A.10 adding a nested member object",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			"A.11 ignoring unrecognized elements",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			`{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			"A.14 ~ escape ordering",
+			`{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": 10}]`,
+			`{"/": 9, "~1": 10}`,
+		},
+		{
+			"A.16 adding an array value",
+			`{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		{
+			"replacing a value with null",
+			`{"x": 1}`,
+			`[{"op": "replace", "path": "/x", "value": null}]`,
+			`{"x": null}`,
+		},
+		{
+			"adding null",
+			`{}`,
+			`[{"op": "add", "path": "/x", "value": null}]`,
+			`{"x": null}`,
+		},
+		{
+			"testing null",
+			`{"x": null}`,
+			`[{"op": "test", "path": "/x", "value": null}]`,
+			`{"x": null}`,
+		},
+		{
+			"copying a value",
+			`{"foo": {"bar": 1}}`,
+			`[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			`{"foo": {"bar": 1}, "baz": {"bar": 2}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyRFC6902Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		patch      string
+		testFailed bool
+	}{
+		{
+			"A.9 testing a value: error",
+			`{"baz": "qux"}`,
+			`[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			true,
+		},
+		{
+			"A.12 adding to a nonexistent target",
+			`{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			false,
+		},
+		{
+			"A.15 comparing strings and numbers",
+			`{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": "10"}]`,
+			true,
+		},
+		{
+			"missing value",
+			`{"x": 1}`,
+			`[{"op": "replace", "path": "/x"}]`,
+			false,
+		},
+		{
+			"replacing a nonexistent member",
+			`{"x": 1}`,
+			`[{"op": "replace", "path": "/y", "value": 2}]`,
+			false,
+		},
+		{
+			"removing a nonexistent member",
+			`{"x": 1}`,
+			`[{"op": "remove", "path": "/y"}]`,
+			false,
+		},
+		{
+			"array index with leading zero",
+			`{"foo": [1, 2]}`,
+			`[{"op": "remove", "path": "/foo/01"}]`,
+			false,
+		},
+		{
+			"array index out of bounds",
+			`{"foo": [1, 2]}`,
+			`[{"op": "add", "path": "/foo/3", "value": 3}]`,
+			false,
+		},
+		{
+			"unknown op",
+			`{}`,
+			`[{"op": "merge", "path": "/x", "value": 1}]`,
+			false,
+		},
+		{
+			"invalid pointer",
+			`{}`,
+			`[{"op": "add", "path": "x", "value": 1}]`,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if errors.Is(err, ErrTestFailed) != tt.testFailed {
+				t.Errorf("errors.Is(err, ErrTestFailed) = %v, want %v (%v)", !tt.testFailed, tt.testFailed, err)
+			}
+		})
+	}
+}
+
+// Contoh dari RFC 7396 appendix A.
+func TestMergePatchRFC7396Examples(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("MergePatch(%s, %s): %v", tt.doc, tt.patch, err)
+		}
+		assertJSONEqual(t, got, tt.want)
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	if _, err := MergePatch([]byte(`{}`), []byte(`{"a":`)); err == nil {
+		t.Fatal("expected an error for an invalid merge patch")
+	}
+}
@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"sync"
+	"time"
+)
+
+type consoleMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewConsoleMailer menulis email ke w (biasanya stdout) tanpa mengirimnya,
+// untuk development.
+func NewConsoleMailer(w io.Writer, from string) Mailer {
+	return &consoleMailer{w: w, from: from}
+}
+
+func (m *consoleMailer) Send(ctx context.Context, msg Message) error {
+	body, err := composeMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = fmt.Fprintf(m.w, "----- email to %s -----\n%s\n----- end of email -----\n", msg.To, body)
+	return err
+}
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer menyimpan setiap email sebagai file .eml di dir yang bisa
+// dibuka dengan email client, untuk development.
+func NewFileMailer(dir, from string) Mailer {
+	return &fileMailer{dir, from}
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	body, err := composeMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(m.dir, time.Now().UTC().Format("20060102T150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func composeMessage(from string, msg Message) ([]byte, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+	return compose(fromAddr, to, msg)
+}
@@ -0,0 +1,240 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession adalah isi satu sesi yang diterima fakeSMTPServer.
+type smtpSession struct {
+	auth string
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTPServer menerima satu koneksi SMTP tanpa TLS dan mengirim hasilnya
+// ke channel setelah QUIT.
+func fakeSMTPServer(t *testing.T, advertiseAuth bool) (host string, port int, sessions <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	ch := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		tp := textproto.NewConn(conn)
+		var session smtpSession
+
+		tp.PrintfLine("220 localhost ESMTP fake")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				if advertiseAuth {
+					tp.PrintfLine("250-localhost")
+					tp.PrintfLine("250 AUTH PLAIN")
+				} else {
+					tp.PrintfLine("250 localhost")
+				}
+			case "AUTH":
+				session.auth = arg
+				tp.PrintfLine("235 2.7.0 Authentication successful")
+			case "MAIL":
+				session.from = arg
+				tp.PrintfLine("250 2.1.0 Ok")
+			case "RCPT":
+				session.rcpt = append(session.rcpt, arg)
+				tp.PrintfLine("250 2.1.5 Ok")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(tp.DotReader())
+				if err != nil {
+					return
+				}
+				session.data = string(data)
+				tp.PrintfLine("250 2.0.0 Ok: queued")
+			case "QUIT":
+				tp.PrintfLine("221 2.0.0 Bye")
+				ch <- session
+				return
+			default:
+				tp.PrintfLine("502 5.5.2 Error: command not recognized")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func receive(t *testing.T, sessions <-chan smtpSession) smtpSession {
+	t.Helper()
+	select {
+	case session := <-sessions:
+		return session
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server did not receive a message")
+		return smtpSession{}
+	}
+}
+
+func decodeQuotedPrintable(t *testing.T, r io.Reader) string {
+	t.Helper()
+	b, err := io.ReadAll(quotedprintable.NewReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSMTPMailerSendPlainText(t *testing.T) {
+	host, port, sessions := fakeSMTPServer(t, true)
+	m := NewSMTPMailer(host, port, "user", "secret", "Todo List <noreply@example.com>", 5*time.Second)
+
+	body := "Halo,\n\nTodo \"Bayar listrik\" jatuh tempo besok. " + strings.Repeat("panjang ", 20) + "sekali "
+	err := m.Send(context.Background(), Message{
+		To:      "Budi <budi@example.com>",
+		Subject: "Pengingat: Bayar listrik ⏰",
+		Body:    body,
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/unsubscribe>"},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := receive(t, sessions)
+	wantAuth := "PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00user\x00secret"))
+	if session.auth != wantAuth {
+		t.Errorf("AUTH %q, want %q", session.auth, wantAuth)
+	}
+	if session.from != "FROM:<noreply@example.com>" {
+		t.Errorf("MAIL %q", session.from)
+	}
+	if len(session.rcpt) != 1 || session.rcpt[0] != "TO:<budi@example.com>" {
+		t.Errorf("RCPT %q", session.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("invalid message %q: %v", session.data, err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Pengingat: Bayar listrik ⏰" {
+		t.Errorf("Subject %q (%v)", msg.Header.Get("Subject"), err)
+	}
+	if to := msg.Header.Get("To"); to != `"Budi" <budi@example.com>` {
+		t.Errorf("To %q", to)
+	}
+	if msg.Header.Get("List-Unsubscribe") != "<https://example.com/unsubscribe>" {
+		t.Errorf("List-Unsubscribe %q", msg.Header.Get("List-Unsubscribe"))
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	// body yang diterima server memakai CRLF dan diakhiri baris baru
+	got := strings.ReplaceAll(decodeQuotedPrintable(t, msg.Body), "\r\n", "\n")
+	if got = strings.TrimSuffix(got, "\n"); got != body {
+		t.Errorf("body %q, want %q", got, body)
+	}
+}
+
+func TestSMTPMailerSendHTML(t *testing.T) {
+	host, port, sessions := fakeSMTPServer(t, false)
+	m := NewSMTPMailer(host, port, "", "", "noreply@example.com", 5*time.Second)
+
+	err := m.Send(context.Background(), Message{
+		To:      "budi@example.com",
+		Subject: "Digest",
+		Body:    "3 todo terlambat",
+		HTML:    "<p>3 todo <b>terlambat</b></p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := receive(t, sessions)
+	if session.auth != "" {
+		t.Errorf("authenticated without a username: %q", session.auth)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "3 todo terlambat"},
+		{"text/html; charset=utf-8", "<p>3 todo <b>terlambat</b></p>"},
+	}
+	for i, w := range want {
+		part, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != w.contentType {
+			t.Errorf("part %d Content-Type %q", i, ct)
+		}
+		if got := decodeQuotedPrintable(t, part); got != w.body {
+			t.Errorf("part %d body %q, want %q", i, got, w.body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("unexpected extra part: %v", err)
+	}
+}
+
+func TestSMTPMailerRejectsInvalidMessages(t *testing.T) {
+	// tidak ada server: pesan harus ditolak sebelum dial
+	m := NewSMTPMailer("127.0.0.1", 1, "", "", "noreply@example.com", time.Second)
+	for _, msg := range []Message{
+		{To: "not an address", Subject: "x", Body: "x"},
+		{To: "budi@example.com", Subject: "x", Body: "x", Headers: map[string]string{"X-Test": "a\r\nBcc: eve@example.com"}},
+	} {
+		if err := m.Send(context.Background(), msg); err == nil || strings.Contains(err.Error(), "connect") {
+			t.Errorf("Send(%+v) = %v, want a validation error", msg, err)
+		}
+	}
+}
+
+func TestSMTPMailerServerUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	m := NewSMTPMailer("127.0.0.1", port, "", "", "noreply@example.com", time.Second)
+	if err := m.Send(context.Background(), Message{To: "budi@example.com", Subject: "x", Body: "x"}); err == nil {
+		t.Fatal("expected an error when the SMTP server is down")
+	}
+}
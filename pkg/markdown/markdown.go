@@ -0,0 +1,37 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy   = newPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// checkbox dari task list GFM
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// Render mengubah markdown menjadi HTML yang sudah disanitasi sehingga aman
+// ditampilkan langsung oleh client.
+func Render(source string) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,156 @@
+// Package oidc membungkus authorization code flow OpenID Connect dengan
+// PKCE. Discovery dilakukan saat pertama kali dipakai sehingga aplikasi
+// tetap bisa start walaupun identity provider sedang tidak bisa diakses.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Identity adalah klaim dari ID token yang sudah diverifikasi. Claims
+// berisi seluruh klaim untuk membaca klaim tambahan seperti role.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+	Claims        map[string]interface{}
+}
+
+type Provider struct {
+	cfg      Config
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+func NewProvider(cfg Config) *Provider {
+	return &Provider{cfg: cfg}
+}
+
+// GenerateVerifier membuat code verifier PKCE.
+func GenerateVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// AuthCodeURL mengembalikan URL login identity provider dengan challenge
+// S256 dari verifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	config, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange menukar code dengan token lalu memverifikasi ID token, termasuk
+// nonce-nya.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	config, idVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response does not contain an id_token")
+	}
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce does not match")
+	}
+
+	var claims struct {
+		Email             string    `json:"email"`
+		EmailVerified     claimBool `json:"email_verified"`
+		PreferredUsername string    `json:"preferred_username"`
+		Name              string    `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	all := make(map[string]interface{})
+	if err := idToken.Claims(&all); err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Username:      claims.PreferredUsername,
+		Name:          claims.Name,
+		Claims:        all,
+	}, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *gooidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+	provider, err := gooidc.NewProvider(context.WithoutCancel(ctx), p.cfg.Issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = provider.Verifier(&gooidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth2, p.verifier, nil
+}
+
+// claimBool menerima boolean atau string "true"/"false", karena beberapa
+// identity provider (misalnya AWS Cognito) mengirim email_verified sebagai
+// string.
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("claim must be a boolean or \"true\" or \"false\"")
+		}
+		*b = claimBool(parsed)
+	case nil:
+		*b = false
+	default:
+		return errors.New("claim must be a boolean or \"true\" or \"false\"")
+	}
+	return nil
+}
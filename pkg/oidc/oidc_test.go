@@ -0,0 +1,38 @@
+package oidc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClaimBoolAcceptsBooleanAndString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`"true"`, true},
+		{`"false"`, false},
+		{`null`, false},
+	}
+	for _, test := range tests {
+		var claims struct {
+			EmailVerified claimBool `json:"email_verified"`
+		}
+		if err := json.Unmarshal([]byte(`{"email_verified":`+test.input+`}`), &claims); err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if bool(claims.EmailVerified) != test.want {
+			t.Errorf("%s = %v, want %v", test.input, claims.EmailVerified, test.want)
+		}
+	}
+
+	for _, input := range []string{`"yes"`, `1`} {
+		var b claimBool
+		if err := json.Unmarshal([]byte(input), &b); err == nil {
+			t.Errorf("%s was accepted", input)
+		}
+	}
+}
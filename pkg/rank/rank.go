@@ -0,0 +1,111 @@
+// Package rank menghasilkan key urutan leksikografis (fractional indexing)
+// sehingga sebuah item bisa dipindah di antara dua item lain tanpa
+// mengubah key item lainnya.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+var ErrInvalidRange = errors.New("rank: lower bound must be less than upper bound")
+
+// Between mengembalikan key yang berada di antara a dan b. String kosong
+// pada a berarti tanpa batas bawah, pada b berarti tanpa batas atas.
+// Key harus dibandingkan secara byte-wise (COLLATE "C" di Postgres).
+func Between(a, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", ErrInvalidRange
+	}
+	if !valid(a) || !valid(b) {
+		return "", errors.New("rank: invalid key")
+	}
+	return midpoint(a, b), nil
+}
+
+// Spread mengembalikan n key dengan jarak merata, dipakai untuk rebalancing.
+func Spread(n int) []string {
+	width := 1
+	for capacity := base; capacity <= n; capacity *= base {
+		width++
+	}
+
+	total := 1
+	for i := 0; i < width; i++ {
+		total *= base
+	}
+	step := total / (n + 1)
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strings.TrimRight(encode((i+1)*step, width), digits[:1])
+	}
+	return keys
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(digits, a[0])
+	}
+	digitB := base
+	if b != "" {
+		digitB = strings.IndexByte(digits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(digits[(digitA+digitB)/2])
+	}
+	if b != "" && len(b) > 1 {
+		return b[:1]
+	}
+
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	return string(digits[digitA]) + midpoint(rest, "")
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func valid(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(key, digits[:1])
+}
+
+func encode(value, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[value%base]
+		value /= base
+	}
+	return string(buf)
+}
@@ -0,0 +1,135 @@
+package rank
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"A", "B"},
+		{"A", "A1"},
+		{"A1", "A2"},
+		{"az", "b"},
+		{"z", ""},
+		{"zzz", ""},
+		{"", "001"},
+		{"0001", "0002"},
+		{"Vz", "W01"},
+	}
+	for _, tt := range tests {
+		key, err := Between(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", tt.a, tt.b, err)
+		}
+		if !valid(key) || key == "" {
+			t.Errorf("Between(%q, %q) = %q, not a valid key", tt.a, tt.b, key)
+		}
+		if key <= tt.a || (tt.b != "" && key >= tt.b) {
+			t.Errorf("Between(%q, %q) = %q, not between bounds", tt.a, tt.b, key)
+		}
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"B", "A"},
+		{"A", "A"},
+		{"A0", ""},
+		{"", "a-b"},
+	}
+	for _, tt := range tests {
+		if _, err := Between(tt.a, tt.b); err == nil {
+			t.Errorf("Between(%q, %q) expected an error", tt.a, tt.b)
+		}
+	}
+}
+
+func TestBetweenRepeatedInserts(t *testing.T) {
+	// menyisipkan berulang di titik yang sama menambah satu digit setiap
+	// log2(62) kali, karena itu position perlu di-rebalance secara berkala.
+	tests := []struct {
+		name   string
+		insert func(keys []string) (lower, upper string, at int)
+		maxLen int
+	}{
+		{"append", func(keys []string) (string, string, int) {
+			return keys[len(keys)-1], "", len(keys)
+		}, 1000/5 + 2},
+		{"prepend", func(keys []string) (string, string, int) {
+			return "", keys[0], 0
+		}, 1000/5 + 2},
+		{"middle", func(keys []string) (string, string, int) {
+			return keys[0], keys[1], 1
+		}, 1000/5 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, _ := Between("", "")
+			second, _ := Between(first, "")
+			keys := []string{first, second}
+			for i := 0; i < 1000; i++ {
+				lower, upper, at := tt.insert(keys)
+				key, err := Between(lower, upper)
+				if err != nil {
+					t.Fatalf("insert %d: %v", i, err)
+				}
+				keys = append(keys[:at], append([]string{key}, keys[at:]...)...)
+			}
+			if !sort.StringsAreSorted(keys) {
+				t.Fatal("keys are not sorted after repeated inserts")
+			}
+			for _, key := range keys {
+				if len(key) > tt.maxLen {
+					t.Fatalf("key %q is longer than %d", key, tt.maxLen)
+				}
+			}
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 61, 62, 100, 5000} {
+		keys := Spread(n)
+		if len(keys) != n {
+			t.Fatalf("Spread(%d) returned %d keys", n, len(keys))
+		}
+		for i, key := range keys {
+			if !valid(key) || key == "" {
+				t.Fatalf("Spread(%d)[%d] = %q, not a valid key", n, i, key)
+			}
+			if i > 0 && keys[i-1] >= key {
+				t.Fatalf("Spread(%d) is not strictly increasing at %d: %q >= %q", n, i, keys[i-1], key)
+			}
+		}
+		width := 1
+		for capacity := base; capacity <= n; capacity *= base {
+			width++
+		}
+		for _, key := range keys {
+			if len(key) > width {
+				t.Fatalf("Spread(%d) key %q is longer than %d", n, key, width)
+			}
+		}
+	}
+}
+
+func TestSpreadLeavesRoomBetweenKeys(t *testing.T) {
+	keys := Spread(100)
+	for i := 1; i < len(keys); i++ {
+		key, err := Between(keys[i-1], keys[i])
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", keys[i-1], keys[i], err)
+		}
+		if len(key) > 3 {
+			t.Errorf("Between(%q, %q) = %q, expected a short key", keys[i-1], keys[i], key)
+		}
+	}
+}
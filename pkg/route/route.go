@@ -1,10 +1,32 @@
 package route
 
-import "github.com/labstack/echo/v4"
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
 
 type Route struct {
 	Method  string
 	Path    string
 	Handler echo.HandlerFunc
 	Roles   []string
+	// Middlewares dijalankan setelah middleware bawaan server, misalnya
+	// autentikasi khusus untuk route tertentu.
+	Middlewares []echo.MiddlewareFunc
+	// QueryToken mengizinkan JWT dikirim lewat query access_token, untuk
+	// EventSource dan WebSocket di browser yang tidak bisa mengirim header.
+	QueryToken bool
+	// RateLimit membatasi jumlah request per IP, misalnya untuk endpoint
+	// lupa password.
+	RateLimit *RateLimit
+	// Scopes wajib dimiliki personal access token untuk mengakses route ini.
+	// Route tanpa Scopes tidak bisa diakses dengan personal access token.
+	Scopes []string
+}
+
+// RateLimit mengizinkan Requests request per Window untuk setiap IP.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
 }
@@ -0,0 +1,27 @@
+// Package securetoken membuat token acak untuk kredensial yang disimpan
+// dalam bentuk hash, misalnya token feed kalender.
+package securetoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Generate membuat token acak 256-bit dengan prefix dan mengembalikan token
+// beserta hash-nya. Hanya hash yang boleh disimpan.
+func Generate(prefix string) (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, Hash(token), nil
+}
+
+// Hash mengembalikan SHA-256 token dalam hex.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
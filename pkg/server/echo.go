@@ -2,51 +2,117 @@ package server
 
 import (
 	"todo-list/configs"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/cache"
 	"todo-list/pkg/response"
 	"todo-list/pkg/route"
 	"todo-list/pkg/token"
+	"net"
 	"net/http"
 
 	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 type Server struct {
 	*echo.Echo
 }
 
-func NewServer(cfg *configs.Config,
+func NewServer(cfg *configs.Config, cacheable cache.Cacheable, keys *token.KeySet, authenticator TokenAuthenticator,
 	publicRoutes, privateRoutes []route.Route) *Server {
 	e := echo.New()
 	e.HideBanner = true
+	e.IPExtractor = IPExtractor(cfg.TrustedProxies)
+	e.Use(middleware.RequestID(), RequestMetadataMiddleware())
+
+	e.GET("/.well-known/jwks.json", JWKSHandler(keys))
 
 	v1 := e.Group("/api/v1")
 
 	if len(publicRoutes) > 0 {
 		for _, route := range publicRoutes {
-			v1.Add(route.Method, route.Path, route.Handler)
+			var middlewares []echo.MiddlewareFunc
+			if route.RateLimit != nil {
+				middlewares = append(middlewares, RateLimitMiddleware(cacheable, route.Method+" "+route.Path, *route.RateLimit))
+			}
+			v1.Add(route.Method, route.Path, route.Handler, append(middlewares, route.Middlewares...)...)
 		}
 	}
 
 	if len(privateRoutes) > 0 {
 		for _, route := range privateRoutes {
-			v1.Add(route.Method, route.Path, route.Handler, JWTMiddleware(cfg.JWT.SecretKey), RBACMiddleware(route.Roles))
+			middlewares := []echo.MiddlewareFunc{JWTMiddleware(keys, route.QueryToken, authenticator), RBACMiddleware(route.Roles),
+				ScopeMiddleware(route.Scopes), IdempotencyMiddleware(cacheable, cfg.Idempotency.TTL)}
+			if route.RateLimit != nil {
+				middlewares = append(middlewares, RateLimitMiddleware(cacheable, route.Method+" "+route.Path, *route.RateLimit))
+			}
+			v1.Add(route.Method, route.Path, route.Handler, append(middlewares, route.Middlewares...)...)
 		}
 	}
 	return &Server{e}
 }
 
-func JWTMiddleware(secretKey string) echo.MiddlewareFunc {
-	return echojwt.WithConfig(echojwt.Config{
+// IPExtractor menentukan IP client untuk rate limit dan audit log. Tanpa
+// trusted proxy, header X-Forwarded-For diabaikan agar tidak bisa dipalsukan
+// client. Dengan trusted proxy, IP diambil dari X-Forwarded-For selama hop
+// berasal dari range yang dipercaya. cidrs sudah divalidasi di config.
+func IPExtractor(cidrs []string) echo.IPExtractor {
+	if len(cidrs) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range cidrs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			options = append(options, echo.TrustIPRange(ipNet))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// JWTMiddleware juga menerima personal access token (prefix tdl_) jika
+// authenticator diberikan.
+func JWTMiddleware(keys *token.KeySet, queryToken bool, authenticator TokenAuthenticator) echo.MiddlewareFunc {
+	tokenLookup := "header:Authorization:Bearer "
+	if queryToken {
+		tokenLookup += ",query:access_token"
+	}
+	jwtMiddleware := echojwt.WithConfig(echojwt.Config{
+		TokenLookup: tokenLookup,
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(token.JwtCustomClaims)
 		},
-		SigningKey: []byte(secretKey),
+		KeyFunc: keys.Keyfunc,
 		ErrorHandler: func(ctx echo.Context, err error) error {
 			return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "anda harus login untuk megakses resource ini."))
 		},
 	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withJWT := jwtMiddleware(next)
+		return func(ctx echo.Context) error {
+			if rawToken, ok := personalAccessToken(ctx, queryToken); ok && authenticator != nil {
+				return authenticateToken(authenticator, rawToken, next)(ctx)
+			}
+			return withJWT(ctx)
+		}
+	}
+}
+
+// RequestMetadataMiddleware menyimpan IP, user agent dan request ID ke
+// request context, termasuk untuk route public seperti login.
+func RequestMetadataMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			ctx.SetRequest(req.WithContext(actor.NewContext(req.Context(), actor.Actor{
+				IP:        ctx.RealIP(),
+				UserAgent: req.UserAgent(),
+				RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+			})))
+			return next(ctx)
+		}
+	}
 }
 
 func RBACMiddleware(roles []string) echo.MiddlewareFunc {
@@ -60,6 +126,13 @@ func RBACMiddleware(roles []string) echo.MiddlewareFunc {
 				ctx.Set("user_id", claims.UserID)
 			}
 
+			// Simpan actor ke request context untuk dipakai di service
+			req := ctx.Request()
+			a, _ := actor.FromContext(req.Context())
+			a.UserID = claims.UserID
+			a.Role = claims.Role
+			ctx.SetRequest(req.WithContext(actor.NewContext(req.Context(), a)))
+
 			allowed := false
 			for _, role := range roles {
 				if role == claims.Role {
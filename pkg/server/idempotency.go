@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderIdempotencyKey    = "Idempotency-Key"
+	HeaderIdempotentReplay  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyRecord disimpan di redis per user dan key. Status 0 berarti
+// request pertama masih diproses.
+type idempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// replayedHeaders adalah header response yang ikut diputar ulang.
+var replayedHeaders = []string{echo.HeaderContentType, "ETag", echo.HeaderLocation}
+
+// IdempotencyMiddleware menyimpan response pertama dari request mutasi yang
+// membawa header Idempotency-Key, lalu memutarnya ulang untuk retry dengan
+// key yang sama. Key yang dipakai ulang dengan payload berbeda ditolak 422.
+func IdempotencyMiddleware(cacheable cache.Cacheable, ttl time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			idempotencyKey := req.Header.Get(HeaderIdempotencyKey)
+			if idempotencyKey == "" || !isMutation(req.Method) {
+				return next(ctx)
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, "Idempotency-Key is too long"))
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return ctx.JSON(http.StatusBadRequest, response.ErrorResponse(http.StatusBadRequest, err.Error()))
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			a, _ := actor.FromContext(req.Context())
+			key := fmt.Sprintf("todo-list:idempotency:%d:%s", a.UserID, idempotencyKey)
+			fingerprint := requestFingerprint(req, body)
+
+			pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+			acquired, err := cacheable.SetNX(key, pending, ttl)
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse(http.StatusInternalServerError, err.Error()))
+			}
+			if !acquired {
+				return replay(ctx, cacheable.Get(key), fingerprint)
+			}
+
+			// pending key dilepas jika response tidak tersimpan, termasuk saat
+			// handler panic, agar retry tidak tertahan 409 sampai TTL habis
+			recorded := false
+			defer func() {
+				if !recorded {
+					_ = cacheable.Delete(key)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: ctx.Response().Writer}
+			ctx.Response().Writer = recorder
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			status := ctx.Response().Status
+			if status >= http.StatusInternalServerError {
+				// error server boleh dicoba ulang dengan key yang sama
+				return nil
+			}
+
+			record := idempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      status,
+				Header:      map[string]string{},
+				Body:        recorder.body.Bytes(),
+			}
+			for _, name := range replayedHeaders {
+				if v := ctx.Response().Header().Get(name); v != "" {
+					record.Header[name] = v
+				}
+			}
+			stored, err := json.Marshal(record)
+			if err == nil {
+				err = cacheable.Set(key, stored, ttl)
+			}
+			if err != nil {
+				ctx.Logger().Errorf("failed to store idempotent response: %v", err)
+				return nil
+			}
+			recorded = true
+			return nil
+		}
+	}
+}
+
+func replay(ctx echo.Context, stored, fingerprint string) error {
+	var record idempotencyRecord
+	if stored == "" || json.Unmarshal([]byte(stored), &record) != nil {
+		return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, "a request with this Idempotency-Key is being processed"))
+	}
+	if record.Fingerprint != fingerprint {
+		return ctx.JSON(http.StatusUnprocessableEntity, response.ErrorResponse(http.StatusUnprocessableEntity, "Idempotency-Key has already been used with a different payload"))
+	}
+	if record.Status == 0 {
+		return ctx.JSON(http.StatusConflict, response.ErrorResponse(http.StatusConflict, "a request with this Idempotency-Key is being processed"))
+	}
+
+	for name, value := range record.Header {
+		ctx.Response().Header().Set(name, value)
+	}
+	ctx.Response().Header().Set(HeaderIdempotentReplay, "true")
+	ctx.Response().WriteHeader(record.Status)
+	_, err := ctx.Response().Write(record.Body)
+	return err
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint juga mencakup If-Match dan Content-Type karena keduanya
+// menentukan hasil request (412/409 atau cara body di-decode).
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.RequestURI())
+	fmt.Fprintf(h, "%s\n%s\n", req.Header.Get("If-Match"), req.Header.Get(echo.HeaderContentType))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder meneruskan response ke client sekaligus menyalin body-nya.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
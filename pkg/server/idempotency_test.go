@@ -0,0 +1,196 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"todo-list/pkg/actor"
+	"todo-list/pkg/cache"
+
+	"github.com/labstack/echo/v4"
+)
+
+type fakeCache struct {
+	cache.Cacheable
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{values: map[string]string{}}
+}
+
+func (c *fakeCache) Get(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *fakeCache) Set(key string, value interface{}, duration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = string(value.([]byte))
+	return nil
+}
+
+func (c *fakeCache) SetNX(key string, value interface{}, duration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key] = string(value.([]byte))
+	return true, nil
+}
+
+func (c *fakeCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+type idempotencyTest struct {
+	echo  *echo.Echo
+	cache *fakeCache
+	calls atomic.Int32
+}
+
+// newIdempotencyTest memasang handler yang mengembalikan jumlah pemanggilan
+// sehingga replay bisa dibedakan dari eksekusi ulang.
+func newIdempotencyTest(handler echo.HandlerFunc) *idempotencyTest {
+	it := &idempotencyTest{echo: echo.New(), cache: newFakeCache()}
+	if handler == nil {
+		handler = func(ctx echo.Context) error {
+			return ctx.String(http.StatusCreated, strconv.Itoa(int(it.calls.Load())))
+		}
+	}
+	it.echo.POST("/todos", func(ctx echo.Context) error {
+		it.calls.Add(1)
+		return handler(ctx)
+	}, IdempotencyMiddleware(it.cache, time.Hour))
+	return it
+}
+
+func (it *idempotencyTest) post(userID uint, key, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderIdempotencyKey, key)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req = req.WithContext(actor.NewContext(req.Context(), actor.Actor{UserID: userID}))
+	rec := httptest.NewRecorder()
+	it.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	it := newIdempotencyTest(nil)
+
+	first := it.post(1, "key-1", `{"title":"a"}`, nil)
+	if first.Code != http.StatusCreated || first.Header().Get(HeaderIdempotentReplay) != "" {
+		t.Fatalf("first call = %d replayed=%q", first.Code, first.Header().Get(HeaderIdempotentReplay))
+	}
+	retry := it.post(1, "key-1", `{"title":"a"}`, nil)
+	if retry.Code != http.StatusCreated || retry.Header().Get(HeaderIdempotentReplay) != "true" {
+		t.Fatalf("retry = %d replayed=%q", retry.Code, retry.Header().Get(HeaderIdempotentReplay))
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get(echo.HeaderContentType) != first.Header().Get(echo.HeaderContentType) {
+		t.Errorf("retry body %q, want %q", retry.Body.String(), first.Body.String())
+	}
+	if calls := it.calls.Load(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	it := newIdempotencyTest(nil)
+	it.post(1, "key-1", `{"title":"a"}`, nil)
+
+	if rec := it.post(1, "key-1", `{"title":"b"}`, nil); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body = %d, want 422", rec.Code)
+	}
+	if rec := it.post(1, "key-1", `{"title":"a"}`, http.Header{"If-Match": {`"3"`}}); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different If-Match = %d, want 422", rec.Code)
+	}
+	if calls := it.calls.Load(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRequest(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	it := newIdempotencyTest(func(ctx echo.Context) error {
+		close(entered)
+		<-release
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	done := make(chan int)
+	go func() { done <- it.post(1, "key-1", `{}`, nil).Code }()
+	<-entered
+
+	if rec := it.post(1, "key-1", `{}`, nil); rec.Code != http.StatusConflict {
+		t.Errorf("concurrent request = %d, want 409", rec.Code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusCreated {
+		t.Errorf("first request = %d, want 201", code)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	var it *idempotencyTest
+	it = newIdempotencyTest(func(ctx echo.Context) error {
+		if it.calls.Load() == 1 {
+			return ctx.NoContent(http.StatusServiceUnavailable)
+		}
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	if rec := it.post(1, "key-1", `{}`, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first call = %d, want 503", rec.Code)
+	}
+	rec := it.post(1, "key-1", `{}`, nil)
+	if rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplay) != "" {
+		t.Errorf("retry after 5xx = %d replayed=%q, want a fresh 201", rec.Code, rec.Header().Get(HeaderIdempotentReplay))
+	}
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	var it *idempotencyTest
+	it = newIdempotencyTest(func(ctx echo.Context) error {
+		if it.calls.Load() == 1 {
+			panic("boom")
+		}
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+		it.post(1, "key-1", `{}`, nil)
+	}()
+	if rec := it.post(1, "key-1", `{}`, nil); rec.Code != http.StatusCreated {
+		t.Errorf("retry after panic = %d, want 201", rec.Code)
+	}
+}
+
+func TestIdempotencyKeyIsPerUser(t *testing.T) {
+	it := newIdempotencyTest(nil)
+
+	first := it.post(1, "key-1", `{}`, nil)
+	other := it.post(2, "key-1", `{}`, nil)
+	if other.Code != http.StatusCreated || other.Header().Get(HeaderIdempotentReplay) != "" {
+		t.Errorf("same key from another user = %d replayed=%q", other.Code, other.Header().Get(HeaderIdempotentReplay))
+	}
+	if other.Body.String() == first.Body.String() {
+		t.Errorf("another user got the first user's response %q", other.Body.String())
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+	"todo-list/pkg/token"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JWKSHandler mempublikasikan public key untuk verifikasi JWT agar service
+// lain bisa memverifikasi token tanpa berbagi secret. Cache dibuat singkat
+// supaya key baru cepat terlihat saat rotasi.
+func JWKSHandler(keys *token.KeySet) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		ctx.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return ctx.JSON(http.StatusOK, keys.JWKS())
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/pkg/cache"
+	"todo-list/pkg/response"
+	"todo-list/pkg/route"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimitMiddleware membatasi request per IP dengan fixed window di Redis.
+// Jika Redis gagal, request tetap dilayani.
+func RateLimitMiddleware(cacheable cache.Cacheable, name string, limit route.RateLimit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key := fmt.Sprintf("todo-list:ratelimit:%s:%s", name, ctx.RealIP())
+			n, err := cacheable.Incr(key, limit.Window)
+			if err != nil {
+				ctx.Logger().Errorf("rate limit %s: %v", name, err)
+				return next(ctx)
+			}
+			if n > int64(limit.Requests) {
+				ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(limit.Window.Seconds())))
+				return ctx.JSON(http.StatusTooManyRequests, response.ErrorResponse(http.StatusTooManyRequests, "too many requests, try again later"))
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"todo-list/pkg/response"
+	"todo-list/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// contextKeyScopes menandai request yang diautentikasi dengan personal
+// access token. Nilainya adalah scope token tersebut.
+const contextKeyScopes = "token_scopes"
+
+// TokenAuthenticator memvalidasi personal access token dan mengembalikan
+// claims pemiliknya beserta scope token.
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, rawToken string) (*token.JwtCustomClaims, []string, error)
+}
+
+// personalAccessToken mengambil personal access token dari header
+// Authorization, atau dari query access_token jika route mengizinkan.
+func personalAccessToken(ctx echo.Context, queryToken bool) (string, bool) {
+	raw := strings.TrimPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if raw == "" && queryToken {
+		raw = ctx.QueryParam("access_token")
+	}
+	return raw, strings.HasPrefix(raw, token.PersonalAccessTokenPrefix)
+}
+
+// authenticateToken menyimpan claims pemilik token dengan bentuk yang sama
+// seperti JWT sehingga RBACMiddleware tidak perlu dibedakan.
+func authenticateToken(authenticator TokenAuthenticator, rawToken string, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		claims, scopes, err := authenticator.AuthenticateToken(ctx.Request().Context(), rawToken)
+		if err != nil {
+			return ctx.JSON(http.StatusUnauthorized, response.ErrorResponse(http.StatusUnauthorized, "anda harus login untuk megakses resource ini."))
+		}
+		ctx.Set("user", &jwt.Token{Claims: claims, Valid: true})
+		ctx.Set(contextKeyScopes, scopes)
+		return next(ctx)
+	}
+}
+
+// ScopeMiddleware hanya berlaku untuk request dengan personal access token.
+// Request dengan JWT dari login tidak dibatasi scope.
+func ScopeMiddleware(scopes []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			granted, ok := ctx.Get(contextKeyScopes).([]string)
+			if !ok {
+				return next(ctx)
+			}
+			if len(scopes) == 0 {
+				return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, "personal access tokens cannot access this resource"))
+			}
+			for _, scope := range scopes {
+				if !hasScope(granted, scope) {
+					return ctx.JSON(http.StatusForbidden, response.ErrorResponse(http.StatusForbidden, fmt.Sprintf("the token is missing the %s scope", scope)))
+				}
+			}
+			return next(ctx)
+		}
+	}
+}
+
+func hasScope(granted []string, scope string) bool {
+	for _, g := range granted {
+		if g == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo-list/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeAuthenticator menerima token yang ada di roles dengan role dan scope
+// yang ditentukan test. Token lain dianggap kedaluwarsa atau dicabut.
+type fakeAuthenticator struct {
+	roles  map[string]string
+	scopes []string
+}
+
+func (a *fakeAuthenticator) AuthenticateToken(ctx context.Context, rawToken string) (*token.JwtCustomClaims, []string, error) {
+	role, ok := a.roles[rawToken]
+	if !ok {
+		return nil, nil, errors.New("personal access token is invalid, expired or revoked")
+	}
+	return &token.JwtCustomClaims{UserID: 1, Role: role}, a.scopes, nil
+}
+
+func newScopeTest(authenticator TokenAuthenticator, keys *token.KeySet, roles, scopes []string) *echo.Echo {
+	e := echo.New()
+	e.GET("/resource", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}, JWTMiddleware(keys, false, authenticator), RBACMiddleware(roles), ScopeMiddleware(scopes))
+	return e
+}
+
+func getResource(e *echo.Echo, bearer string) int {
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+bearer)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestPersonalAccessTokenRejectedWhenInvalid(t *testing.T) {
+	authenticator := &fakeAuthenticator{roles: map[string]string{"tdl_active": "user"}, scopes: []string{"todos:read"}}
+	e := newScopeTest(authenticator, token.NewHMACKeySet("secret"), []string{"user"}, []string{"todos:read"})
+
+	if code := getResource(e, "tdl_active"); code != http.StatusOK {
+		t.Errorf("active token = %d, want 200", code)
+	}
+	if code := getResource(e, "tdl_revoked"); code != http.StatusUnauthorized {
+		t.Errorf("expired or revoked token = %d, want 401", code)
+	}
+}
+
+func TestPersonalAccessTokenForbiddenOnRouteWithoutScopes(t *testing.T) {
+	keys := token.NewHMACKeySet("secret")
+	authenticator := &fakeAuthenticator{roles: map[string]string{"tdl_active": "user"}, scopes: []string{"todos:read", "todos:write"}}
+	e := newScopeTest(authenticator, keys, []string{"user"}, nil)
+
+	if code := getResource(e, "tdl_active"); code != http.StatusForbidden {
+		t.Errorf("token on route without scopes = %d, want 403", code)
+	}
+
+	// JWT dari login tidak dibatasi scope
+	claims := token.JwtCustomClaims{UserID: 1, Role: "user"}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	jwtToken, err := keys.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := getResource(e, jwtToken); code != http.StatusOK {
+		t.Errorf("JWT on route without scopes = %d, want 200", code)
+	}
+}
+
+func TestPersonalAccessTokenUsesRoleFromAuthenticator(t *testing.T) {
+	authenticator := &fakeAuthenticator{roles: map[string]string{"tdl_admin": "user"}, scopes: []string{"admin:read"}}
+	e := newScopeTest(authenticator, token.NewHMACKeySet("secret"), []string{"admin"}, []string{"admin:read"})
+
+	// role pemilik sudah diturunkan, walaupun token punya scope admin
+	if code := getResource(e, "tdl_admin"); code != http.StatusForbidden {
+		t.Errorf("token of demoted admin = %d, want 403", code)
+	}
+	authenticator.roles["tdl_admin"] = "admin"
+	if code := getResource(e, "tdl_admin"); code != http.StatusOK {
+		t.Errorf("token of admin = %d, want 200", code)
+	}
+}
@@ -0,0 +1,287 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"todo-list/configs"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	minRSAKeyBits = 2048
+)
+
+// Key adalah satu key dengan kid. Private nil berarti key hanya dipakai
+// untuk verifikasi, misalnya key lama yang sedang dirotasi.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet berisi key untuk menandatangani token dan semua key yang masih
+// diterima saat verifikasi. Untuk HS256 hanya ada satu secret tanpa kid.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	secret  []byte
+}
+
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{secret: []byte(secret)}
+}
+
+// NewKeySet membuat KeySet asimetris. Key signing otomatis ikut diterima
+// saat verifikasi.
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	if signing == nil || signing.Private == nil {
+		return nil, errors.New("signing key must contain a private key")
+	}
+	k := &KeySet{signing: signing, keys: map[string]*Key{signing.ID: signing}}
+	for _, key := range verification {
+		if _, ok := k.keys[key.ID]; ok {
+			continue
+		}
+		k.keys[key.ID] = key
+	}
+	return k, nil
+}
+
+// InitKeySet memuat key dari konfigurasi. Di ENV dev tanpa SIGNING_KEY, key
+// dibuat acak setiap start sehingga token lama tidak berlaku setelah restart.
+func InitKeySet(cfg configs.JWTConfig, env string) (*KeySet, error) {
+	if cfg.Algorithm == AlgorithmHS256 {
+		return NewHMACKeySet(cfg.SecretKey), nil
+	}
+	if cfg.Algorithm != AlgorithmRS256 && cfg.Algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q, use HS256, RS256 or EdDSA", cfg.Algorithm)
+	}
+
+	var signing *Key
+	var err error
+	if cfg.SigningKey == "" {
+		if env != "dev" {
+			return nil, errors.New("JWT_SIGNING_KEY is required for asymmetric JWT signing")
+		}
+		log.Printf("JWT_SIGNING_KEY is empty, using a random %s key until the next restart", cfg.Algorithm)
+		signing, err = GenerateKey(cfg.Algorithm)
+	} else {
+		signing, err = LoadKey(cfg.SigningKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("%s does not contain a private key", cfg.SigningKey)
+	}
+	if signing.Method.Alg() != cfg.Algorithm {
+		return nil, fmt.Errorf("%s is a %s key but JWT_ALGORITHM is %s", cfg.SigningKey, signing.Method.Alg(), cfg.Algorithm)
+	}
+
+	verification := make([]*Key, 0, len(cfg.VerificationKeys))
+	for _, path := range cfg.VerificationKeys {
+		key, err := LoadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+	return NewKeySet(signing, verification...)
+}
+
+// GenerateKey membuat key baru untuk algorithm RS256 atau EdDSA.
+func GenerateKey(algorithm string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("cannot generate a key for %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newKey(private, private.Public())
+}
+
+// LoadKey membaca key dari file PEM. File bisa berisi private key (PKCS#8
+// atau PKCS#1) atau public key (PKIX). kid adalah JWK thumbprint
+// (RFC 7638) sehingga tidak perlu dikonfigurasi terpisah.
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key type", path)
+		}
+		return newKey(signer, signer.Public())
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return newKey(parsed, parsed.Public())
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return newKey(nil, parsed)
+	}
+	return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+}
+
+func newKey(private crypto.Signer, public crypto.PublicKey) (*Key, error) {
+	key := &Key{Private: private, Public: public}
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	key.ID = key.JWK().Thumbprint()
+	return key, nil
+}
+
+// Sign menandatangani claims dengan key signing dan menambahkan header kid.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.Private)
+}
+
+// Keyfunc memilih key verifikasi berdasarkan kid. Algorithm token harus
+// sama dengan algorithm key agar token HS256 tidak bisa diverifikasi
+// dengan public key.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if k.signing == nil {
+		if token.Method.Alg() != AlgorithmHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return k.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+// JWK adalah public key dalam format JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (key *Key) JWK() JWK {
+	jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// Thumbprint menghitung JWK thumbprint SHA-256 (RFC 7638) dari member
+// wajib key.
+func (jwk JWK) Thumbprint() string {
+	var members interface{}
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS mengembalikan semua public key yang diterima saat verifikasi. Untuk
+// HS256 daftarnya kosong karena secret tidak boleh dipublikasikan.
+func (k *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(k.keys))}
+	if k.signing != nil {
+		set.Keys = append(set.Keys, k.signing.JWK())
+	}
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.signing.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		set.Keys = append(set.Keys, k.keys[id].JWK())
+	}
+	return set
+}
@@ -0,0 +1,228 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims() JwtCustomClaims {
+	claims := JwtCustomClaims{UserID: 1, Username: "budi", Role: "user"}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	return claims
+}
+
+func parse(keys *KeySet, tokenString string) error {
+	_, err := jwt.ParseWithClaims(tokenString, new(JwtCustomClaims), keys.Keyfunc)
+	return err
+}
+
+func generateKey(t *testing.T, algorithm string) *Key {
+	t.Helper()
+	key, err := GenerateKey(algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// publicOnly mengembalikan key lama yang hanya dipakai untuk verifikasi.
+func publicOnly(key *Key) *Key {
+	return &Key{ID: key.ID, Method: key.Method, Public: key.Public}
+}
+
+func TestSignUsesSigningKeyID(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		signing := generateKey(t, algorithm)
+		keys, err := NewKeySet(signing, publicOnly(generateKey(t, algorithm)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokenString, err := keys.Sign(testClaims())
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, _, err := jwt.NewParser().ParseUnverified(tokenString, new(JwtCustomClaims))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Header["kid"] != signing.ID || parsed.Method.Alg() != algorithm {
+			t.Errorf("%s: header %v, want kid %s", algorithm, parsed.Header, signing.ID)
+		}
+		if err := parse(keys, tokenString); err != nil {
+			t.Errorf("%s: %v", algorithm, err)
+		}
+	}
+}
+
+func TestRetiredKeyVerifiesAfterRotation(t *testing.T) {
+	old := generateKey(t, AlgorithmRS256)
+	before, _ := NewKeySet(old)
+	tokenString, err := before.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// key baru menandatangani, key lama hanya diterima untuk verifikasi
+	rotated, err := NewKeySet(generateKey(t, AlgorithmEdDSA), publicOnly(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(rotated, tokenString); err != nil {
+		t.Errorf("token signed by the retired key: %v", err)
+	}
+	newToken, _ := rotated.Sign(testClaims())
+	if err := parse(rotated, newToken); err != nil {
+		t.Errorf("token signed by the new key: %v", err)
+	}
+
+	// setelah key lama dihapus dari konfigurasi, tokennya ditolak
+	removed, _ := NewKeySet(generateKey(t, AlgorithmEdDSA))
+	if err := parse(removed, tokenString); err == nil {
+		t.Error("token of a removed key was accepted")
+	}
+}
+
+func TestUnknownKeyIDRejected(t *testing.T) {
+	signing := generateKey(t, AlgorithmEdDSA)
+	keys, _ := NewKeySet(signing)
+
+	for _, kid := range []interface{}{"unknown", nil} {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, testClaims())
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		tokenString, err := token.SignedString(signing.Private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parse(keys, tokenString); err == nil {
+			t.Errorf("token with kid %v was accepted", kid)
+		}
+	}
+}
+
+// TestHS256WithPublicKeyRejected memastikan public key yang dipublikasikan
+// di JWKS tidak bisa dipakai sebagai secret HMAC.
+func TestHS256WithPublicKeyRejected(t *testing.T) {
+	signing := generateKey(t, AlgorithmRS256)
+	keys, _ := NewKeySet(signing)
+
+	der, err := x509.MarshalPKIXPublicKey(signing.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := [][]byte{
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		der,
+		signing.Public.(*rsa.PublicKey).N.Bytes(),
+	}
+	for i, secret := range secrets {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		token.Header["kid"] = signing.ID
+		tokenString, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parse(keys, tokenString); err == nil {
+			t.Errorf("HS256 token signed with public key encoding %d was accepted", i)
+		}
+	}
+}
+
+func TestHMACKeySetRejectsOtherAlgorithms(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	tokenString, err := keys.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(keys, tokenString); err != nil {
+		t.Errorf("HS256 token: %v", err)
+	}
+
+	signing := generateKey(t, AlgorithmEdDSA)
+	other, _ := NewKeySet(signing)
+	tokenString, _ = other.Sign(testClaims())
+	if err := parse(keys, tokenString); err == nil {
+		t.Error("EdDSA token was accepted by an HS256 key set")
+	}
+	if len(keys.JWKS().Keys) != 0 {
+		t.Errorf("JWKS of an HS256 key set = %+v, want empty", keys.JWKS())
+	}
+}
+
+func TestThumbprintMatchesRFC7638(t *testing.T) {
+	// contoh Ed25519 dari RFC 8037 lampiran A.3
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := newKey(nil, ed25519.PublicKey(x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; key.ID != want {
+		t.Errorf("Ed25519 kid = %s, want %s", key.ID, want)
+	}
+
+	// untuk RSA, member wajib diurutkan secara leksikografis tanpa spasi
+	rsaKey := generateKey(t, AlgorithmRS256)
+	jwk := rsaKey.JWK()
+	sum := sha256.Sum256([]byte(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)))
+	if want := base64.RawURLEncoding.EncodeToString(sum[:]); rsaKey.ID != want || jwk.KeyID != want {
+		t.Errorf("RSA kid = %s, want %s", rsaKey.ID, want)
+	}
+	if jwk.E != "AQAB" {
+		t.Errorf("RSA exponent = %s, want AQAB", jwk.E)
+	}
+}
+
+func TestJWKSContainsOnlyPublicKeys(t *testing.T) {
+	signing := generateKey(t, AlgorithmRS256)
+	retired := generateKey(t, AlgorithmEdDSA)
+	keys, err := NewKeySet(signing, retired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(keys.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0]["kid"] != signing.ID || set.Keys[1]["kid"] != retired.ID {
+		t.Fatalf("JWKS %s, want the signing key followed by the retired key", data)
+	}
+	for _, jwk := range set.Keys {
+		// member private key RSA (d, p, q, dp, dq, qi) dan OKP (d)
+		for _, private := range []string{"d", "p", "q", "dp", "dq", "qi"} {
+			if _, ok := jwk[private]; ok {
+				t.Errorf("JWK %s exposes private member %q", jwk["kid"], private)
+			}
+		}
+	}
+	for i, key := range []*Key{signing, retired} {
+		var parsed JWK
+		b, _ := json.Marshal(set.Keys[i])
+		if err := json.Unmarshal(b, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Thumbprint() != key.ID {
+			t.Errorf("thumbprint of published JWK %s = %s", key.ID, parsed.Thumbprint())
+		}
+	}
+}
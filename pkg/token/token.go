@@ -9,11 +9,11 @@ type TokenUseCase interface {
 }
 
 type tokenUseCase struct {
-	secretKey string
+	keys *KeySet
 }
 
-func NewTokenUseCase(secretKey string) TokenUseCase {
-	return &tokenUseCase{secretKey}
+func NewTokenUseCase(keys *KeySet) TokenUseCase {
+	return &tokenUseCase{keys}
 }
 
 type JwtCustomClaims struct {
@@ -25,12 +25,14 @@ type JwtCustomClaims struct {
 }
 
 func (t *tokenUseCase) GenerateAccessToken(claims JwtCustomClaims) (string, error) {
-	plainToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	encodedToken, err := plainToken.SignedString([]byte(t.secretKey))
+	encodedToken, err := t.keys.Sign(claims)
 	if err != nil {
 		return "", err
 	}
 
 	return encodedToken, nil
 }
+
+// PersonalAccessTokenPrefix membedakan personal access token dari JWT di
+// header Authorization.
+const PersonalAccessTokenPrefix = "tdl_"
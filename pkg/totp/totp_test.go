@@ -0,0 +1,89 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret adalah secret SHA1 dari RFC 6238 appendix B
+// ("12345678901234567890") dalam base32.
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// rfc6238Vectors adalah test vector SHA1 dari RFC 6238 appendix B. RFC
+// memakai 8 digit; kode 6 digit adalah 6 digit terakhirnya karena keduanya
+// diambil dari nilai yang sama modulo 10^digit.
+var rfc6238Vectors = []struct {
+	unix  int64
+	step  int64
+	code8 string
+}{
+	{59, 0x1, "94287082"},
+	{1111111109, 0x23523EC, "07081804"},
+	{1111111111, 0x23523ED, "14050471"},
+	{1234567890, 0x273EF07, "89005924"},
+	{2000000000, 0x3F940AA, "69279037"},
+	{20000000000, 0x27BC86AA, "65353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		if step := Step(time.Unix(v.unix, 0)); step != v.step {
+			t.Errorf("Step(%d) = %#x, want %#x", v.unix, step, v.step)
+		}
+		code, err := Code(rfc6238Secret, v.step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := v.code8[len(v.code8)-Digits:]; code != want {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, want)
+		}
+	}
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		code := v.code8[len(v.code8)-Digits:]
+		now := time.Unix(v.unix, 0)
+
+		if step, ok := Validate(rfc6238Secret, code, now, 0); !ok || step != v.step {
+			t.Errorf("Validate(%s) at %d = %#x, %v, want %#x", code, v.unix, step, ok, v.step)
+		}
+		if step, ok := Validate(rfc6238Secret, code[:3]+" "+code[3:], now, 0); !ok || step != v.step {
+			t.Errorf("Validate with a space at %d = %#x, %v", v.unix, step, ok)
+		}
+		// kode periode sebelumnya hanya diterima dengan skew
+		next := now.Add(Period * time.Second)
+		if _, ok := Validate(rfc6238Secret, code, next, 0); ok {
+			t.Errorf("Validate(%s) accepted the previous period without skew", code)
+		}
+		if step, ok := Validate(rfc6238Secret, code, next, 1); !ok || step != v.step {
+			t.Errorf("Validate(%s) with skew 1 = %#x, %v, want %#x", code, step, ok, v.step)
+		}
+	}
+}
+
+func TestValidateRejectsInvalidCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "94287082", "287083", "abcdef"} {
+		if _, ok := Validate(rfc6238Secret, code, now, 1); ok {
+			t.Errorf("Validate(%q) = true", code)
+		}
+	}
+	if _, ok := Validate("not base32!", "287082", now, 1); ok {
+		t.Error("Validate accepted an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret %q has %d characters, want 32", secret, len(secret))
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("Code with a generated secret: %v", err)
+	}
+}
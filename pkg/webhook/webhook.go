@@ -0,0 +1,137 @@
+// Package webhook mengirim payload JSON yang ditandatangani HMAC-SHA256 ke
+// endpoint milik user.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress dikembalikan jika host webhook resolve ke alamat
+// internal, agar webhook tidak bisa dipakai untuk SSRF.
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// Sign menghitung signature dari "<timestamp>.<body>". Receiver sebaiknya
+// menolak timestamp yang terlalu lama untuk mencegah replay.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify dipakai receiver untuk memeriksa signature.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+type Sender interface {
+	Send(ctx context.Context, req Request) (int, error)
+}
+
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID uint
+	EventType  string
+	Body       []byte
+}
+
+type sender struct {
+	client *http.Client
+}
+
+// NewSender menolak koneksi ke alamat loopback, private, link-local dan
+// unspecified kecuali allowPrivateNetworks, misalnya untuk development.
+// Pengecekan dilakukan pada alamat hasil resolve saat dial, sehingga DNS
+// rebinding tidak bisa melewatinya.
+func NewSender(timeout time.Duration, allowPrivateNetworks bool) Sender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateNetworks {
+		// proxy akan membuat yang dicek adalah alamat proxy, bukan tujuan
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   denyPrivateNetworks,
+		}).DialContext
+	}
+	return &sender{&http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// redirect tidak diikuti agar payload tidak dikirim ke host lain
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func denyPrivateNetworks(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// publicAddress mengecek apakah addr boleh menjadi tujuan webhook.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// Send mengembalikan status code response. Status selain 2xx dianggap gagal.
+func (s *sender) Send(ctx context.Context, req Request) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "todo-list-webhook/1.0")
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(req.DeliveryID), 10))
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	res, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"todo.created.1"}`)
+	signature := Sign("whsec_test", 1700000000, body)
+	if !Verify("whsec_test", 1700000000, body, signature) {
+		t.Fatal("signature does not verify")
+	}
+	if Verify("whsec_other", 1700000000, body, signature) {
+		t.Error("signature verifies with another secret")
+	}
+	if Verify("whsec_test", 1700000001, body, signature) {
+		t.Error("signature verifies with another timestamp")
+	}
+	if Verify("whsec_test", 1700000000, []byte(`{"id":"todo.created.2"}`), signature) {
+		t.Error("signature verifies with another body")
+	}
+}
+
+func TestSenderSignsRequest(t *testing.T) {
+	body := []byte(`{"id":"todo.created.1","type":"todo.created"}`)
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || !Verify("whsec_test", timestamp, got, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	status, err := NewSender(time.Second, true).Send(context.Background(), Request{
+		URL:        server.URL,
+		Secret:     "whsec_test",
+		DeliveryID: 42,
+		EventType:  "todo.created",
+		Body:       body,
+	})
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+	r := <-received
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+	}
+	if r.Header.Get(HeaderEvent) != "todo.created" || r.Header.Get(HeaderDelivery) != "42" {
+		t.Errorf("unexpected headers %v", r.Header)
+	}
+}
+
+func TestSenderFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	sender := NewSender(time.Second, true)
+
+	status, err := sender.Send(context.Background(), Request{URL: server.URL, Secret: "s"})
+	if err == nil || status != http.StatusInternalServerError {
+		t.Errorf("5xx response: Send = %d, %v", status, err)
+	}
+	// redirect tidak diikuti
+	status, err = sender.Send(context.Background(), Request{URL: server.URL + "/redirect", Secret: "s"})
+	if err == nil || status != http.StatusFound {
+		t.Errorf("redirect: Send = %d, %v", status, err)
+	}
+}
+
+func TestSenderDeniesPrivateNetworks(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	status, err := NewSender(time.Second, false).Send(context.Background(), Request{URL: server.URL, Secret: "s"})
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Send to %s = %d, %v, want ErrForbiddenAddress", server.URL, status, err)
+	}
+	if calls != 0 {
+		t.Errorf("receiver was called %d times", calls)
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddress(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("publicAddress(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}